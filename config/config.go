@@ -10,6 +10,10 @@ type Config struct {
 	Server   ServerConfig
 	Logger   LoggerConfig
 	Postgres PostgresConfig
+	Mail     MailConfig
+	Signin   SigninConfig
+	JWT      JWTConfig
+	OIDC     OIDCConfig
 }
 
 type ServerConfig struct {
@@ -21,7 +25,80 @@ type ServerConfig struct {
 	WSJwtExpTimeHour      int    `mapstructure:"ws_jwt_exp_time_hour"`
 	JwtRefreshSecretKey   string `mapstructure:"jwt_refresh_secret_key"`
 	JwtRefreshExpTimeHour int    `mapstructure:"jwt_refresh_exp_time_Hour"`
-	Mode                  string `mapstructure:"mode"`
+	PasswordResetExpMin   int    `mapstructure:"password_reset_exp_min"`
+	// EmailVerificationExpHour is the lifetime of email confirmation links.
+	EmailVerificationExpHour int `mapstructure:"email_verification_exp_hour"`
+	// RequireVerifiedEmail forbids signing in until the email is confirmed.
+	RequireVerifiedEmail bool `mapstructure:"require_verified_email"`
+	// InvitationExpHour is the default lifetime of classroom invitations and join codes.
+	InvitationExpHour int `mapstructure:"invitation_exp_hour"`
+	// ImpersonationExpMin is the lifetime of the tokens admins get to act as another user.
+	ImpersonationExpMin int `mapstructure:"impersonation_exp_min"`
+	// MFAIssuer is the name authenticator apps show next to the account.
+	MFAIssuer string `mapstructure:"mfa_issuer"`
+	// MFAChallengeExpMin is the time given to enter the second factor code after the password.
+	MFAChallengeExpMin int `mapstructure:"mfa_challenge_exp_min"`
+	// ClassroomRetentionDays is how long archived classrooms are kept before admins can purge them.
+	ClassroomRetentionDays int `mapstructure:"classroom_retention_days"`
+	// ExportDir is the directory institution exports are written to.
+	ExportDir string `mapstructure:"export_dir"`
+	// ExportExpHour is how long a finished institution export can be downloaded.
+	ExportExpHour int `mapstructure:"export_exp_hour"`
+	// PublicURL is the public address of this API the links to it are built with, like
+	// "https://api.example.com". The links are relative without it.
+	PublicURL string `mapstructure:"public_url"`
+	AppURL    string `mapstructure:"app_url"`
+	Mode      string `mapstructure:"mode"`
+}
+
+// MailConfig configures outgoing mail. Driver is one of "smtp", "file" or "log".
+type MailConfig struct {
+	Driver   string `mapstructure:"driver"`
+	Host     string `mapstructure:"host"`
+	Port     string `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+	Dir      string `mapstructure:"dir"`
+}
+
+// SigninConfig configures the protection against password guessing. After FreeAttempts failures
+// every next attempt is delayed exponentially starting from BaseDelaySec up to MaxDelaySec. Reaching
+// MaxAttempts for an account or IPMaxAttempts for an address locks it for LockoutMin minutes.
+// Failures older than WindowMin minutes are forgotten.
+type SigninConfig struct {
+	FreeAttempts  int `mapstructure:"free_attempts"`
+	MaxAttempts   int `mapstructure:"max_attempts"`
+	IPMaxAttempts int `mapstructure:"ip_max_attempts"`
+	BaseDelaySec  int `mapstructure:"base_delay_sec"`
+	MaxDelaySec   int `mapstructure:"max_delay_sec"`
+	LockoutMin    int `mapstructure:"lockout_min"`
+	WindowMin     int `mapstructure:"window_min"`
+}
+
+// JWTConfig lists the keys tokens are verified with. New tokens are signed with the key SigningKeyId
+// points to; the rest are kept so that tokens signed before a rotation stay valid until they expire.
+// Without keys tokens are signed with the HS256 secrets from ServerConfig.
+type JWTConfig struct {
+	SigningKeyId string         `mapstructure:"signing_key_id"`
+	Keys         []JWTKeyConfig `mapstructure:"keys"`
+}
+
+// JWTKeyConfig describes a PEM encoded key. Algorithm is "RS256" or "EdDSA". Keys used only for
+// verification may have just the public key.
+type JWTKeyConfig struct {
+	Id             string `mapstructure:"id"`
+	Algorithm      string `mapstructure:"algorithm"`
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PublicKeyFile  string `mapstructure:"public_key_file"`
+}
+
+// OIDCConfig configures single sign-on through the identity providers of institutions.
+// RedirectBaseURL is the public address of this API the providers redirect back to.
+type OIDCConfig struct {
+	RedirectBaseURL string `mapstructure:"redirect_base_url"`
+	HTTPTimeoutSec  int    `mapstructure:"http_timeout_sec"`
+	StateExpMin     int    `mapstructure:"state_exp_min"`
 }
 
 type LoggerConfig struct {
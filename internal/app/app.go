@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"github.com/migmatore/study-platform-api/config"
+	"github.com/migmatore/study-platform-api/internal/authz"
 	"github.com/migmatore/study-platform-api/internal/repository"
 	"github.com/migmatore/study-platform-api/internal/repository/psql"
 	"github.com/migmatore/study-platform-api/internal/service"
@@ -10,7 +11,9 @@ import (
 	restHandler "github.com/migmatore/study-platform-api/internal/transport/rest/handler"
 	"github.com/migmatore/study-platform-api/internal/transport/websocket"
 	"github.com/migmatore/study-platform-api/internal/usecase"
+	"github.com/migmatore/study-platform-api/pkg/jwt"
 	"github.com/migmatore/study-platform-api/pkg/logger"
+	"github.com/migmatore/study-platform-api/pkg/mailer"
 )
 
 type App struct {
@@ -41,36 +44,107 @@ func (a *App) Run(ctx context.Context) {
 	a.logger.Info("Storages initializing...")
 	repos := repository.New(a.logger, pool)
 
+	a.logger.Info("Mailer initializing...")
+	mail, err := mailer.New(a.cfg.Mail, a.logger)
+	if err != nil {
+		a.logger.Fatalf("Failed to initialize mailer: %s", err.Error())
+	}
+
+	a.logger.Info("JWT keys loading...")
+	keySet, err := jwt.NewKeySet(a.cfg)
+	if err != nil {
+		a.logger.Fatalf("Failed to load jwt keys: %s", err.Error())
+	}
+
 	a.logger.Info("Services initializing...")
 	services := service.New(a.cfg, service.Deps{
-		TransactorRepo:  repos.Transaction,
-		UserRepo:        repos.User,
-		RoleRepo:        repos.Role,
-		InstitutionRepo: repos.Institution,
-		ClassroomRepo:   repos.Classroom,
-		LessonRepo:      repos.Lesson,
+		TransactorRepo:        repos.Transaction,
+		UserRepo:              repos.User,
+		RoleRepo:              repos.Role,
+		InstitutionRepo:       repos.Institution,
+		ClassroomRepo:         repos.Classroom,
+		LessonRepo:            repos.Lesson,
+		RefreshTokenRepo:      repos.RefreshToken,
+		SessionRepo:           repos.Session,
+		PasswordResetRepo:     repos.PasswordReset,
+		EmailVerificationRepo: repos.EmailVerification,
+		MFARepo:               repos.MFA,
+		SigninThrottleRepo:    repos.SigninThrottle,
+		OIDCRepo:              repos.OIDC,
+		InvitationRepo:        repos.Invitation,
+		APIKeyRepo:            repos.APIKey,
+		ImpersonationRepo:     repos.Impersonation,
+		AuditRepo:             repos.Audit,
+		ExportRepo:            repos.Export,
+		PrivacyRepo:           repos.Privacy,
+		Mailer:                mail,
+		KeySet:                keySet,
 	})
 
 	a.logger.Info("Use cases initializing...")
-	useCases := usecase.New(usecase.Deps{
-		TransactionService: services.Transaction,
-		UserService:        services.User,
-		InstitutionService: services.Institution,
-		TokenService:       services.Token,
-		TeacherService:     services.Teacher,
-		StudentService:     services.Student,
-		ClassroomService:   services.Classroom,
-		LessonService:      services.Lesson,
+	authorizer := authz.New(
+		authz.DefaultPolicy(),
+		authz.DefaultScopes(),
+		services.Classroom,
+		services.User,
+		services.Teacher,
+		services.Role,
+	)
+
+	// The use cases close the websocket connections of users who lose access.
+	hub := websocket.NewHub()
+
+	useCases := usecase.New(a.cfg, usecase.Deps{
+		Logger:                   a.logger,
+		Authorizer:               authorizer,
+		TransactionService:       services.Transaction,
+		UserService:              services.User,
+		InstitutionService:       services.Institution,
+		TokenService:             services.Token,
+		TeacherService:           services.Teacher,
+		StudentService:           services.Student,
+		ClassroomService:         services.Classroom,
+		LessonService:            services.Lesson,
+		RefreshTokenService:      services.RefreshToken,
+		SessionService:           services.Session,
+		PasswordResetService:     services.PasswordReset,
+		EmailVerificationService: services.EmailVerification,
+		MFAService:               services.MFA,
+		SigninThrottleService:    services.SigninThrottle,
+		OIDCService:              services.OIDC,
+		InvitationService:        services.Invitation,
+		APIKeyService:            services.APIKey,
+		ImpersonationService:     services.Impersonation,
+		RoleService:              services.Role,
+		AuditService:             services.Audit,
+		MailService:              services.Mail,
+		ImportService:            services.Import,
+		ExportService:            services.Export,
+		PrivacyService:           services.Privacy,
+		ConnectionCloser:         hub,
 	})
 
 	a.logger.Info("Handlers initializing...")
 	restHandlers := restHandler.New(a.cfg, restHandler.Deps{
-		AuthUseCase:      useCases.Auth,
-		UserUseCase:      useCases.User,
-		ClassroomUseCase: useCases.Classroom,
-		LessonUseCase:    useCases.Lesson,
-		StudentUseCase:   useCases.Student,
-		TeacherUseCase:   useCases.Teacher,
+		KeySet:               keySet,
+		AuthUseCase:          useCases.Auth,
+		UserUseCase:          useCases.User,
+		MFAUseCase:           useCases.MFA,
+		InstitutionUseCase:   useCases.Institution,
+		InvitationUseCase:    useCases.Invitation,
+		APIKeyUseCase:        useCases.APIKey,
+		ImpersonationUseCase: useCases.Impersonation,
+		RoleUseCase:          useCases.Role,
+		AuditUseCase:         useCases.Audit,
+		ClassroomUseCase:     useCases.Classroom,
+		WaitlistUseCase:      useCases.Waitlist,
+		StaffUseCase:         useCases.Staff,
+		LessonUseCase:        useCases.Lesson,
+		StudentUseCase:       useCases.Student,
+		TeacherUseCase:       useCases.Teacher,
+		ImportUseCase:        useCases.Import,
+		ExportUseCase:        useCases.Export,
+		PrivacyUseCase:       useCases.Privacy,
 	})
 
 	restApp := restHandlers.Init(ctx)
@@ -80,8 +154,10 @@ func (a *App) Run(ctx context.Context) {
 	go restSrv.StartWithGracefulShutdown()
 
 	wsHandlers := websocket.NewHandler(a.cfg, websocket.HandlerDeps{
+		Hub:              hub,
 		AuthUseCase:      useCases.Auth,
 		ClassroomUseCase: useCases.Classroom,
+		StaffUseCase:     useCases.Staff,
 	})
 
 	wsApp := wsHandlers.Init()
@@ -8,6 +8,30 @@ var (
 	EntityNotFound           = errors.New("entity not found")
 	InvalidToken             = errors.New("invalid token")
 	ExpiredToken             = errors.New("expired token")
+	RevokedToken             = errors.New("revoked token")
 	AccessDenied             = errors.New("access is denied")
 	NumberOfStudentsExceeded = errors.New("number of students exceeded")
+	EmailNotVerified         = errors.New("email is not verified")
+	InvalidMFACode           = errors.New("invalid mfa code")
+	InvalidCredentials       = errors.New("invalid email or password")
+	TooManyAttempts          = errors.New("too many failed attempts, try again later")
+	InvalidOIDCProvider      = errors.New("invalid oidc provider")
+	InvalidAPIKeyScope       = errors.New("invalid api key scope")
+	InvalidPermission        = errors.New("invalid permission")
+	InvalidRoleName          = errors.New("invalid role name")
+	InvalidClassroom         = errors.New("invalid classroom")
+	MaxStudentsBelowEnrolled = errors.New("max students cannot be lower than the number of enrolled students")
+	InvalidStaffRole         = errors.New("invalid staff role")
+	ClassroomArchived        = errors.New("classroom is archived")
+	ClassroomNotArchived     = errors.New("classroom is not archived")
+	RetentionPeriodNotOver   = errors.New("retention period of the archived classroom is not over")
+	InvalidWaitlistOrder     = errors.New("the order must list every waitlisted student once")
+	InvalidImportFile        = errors.New("invalid import file")
+	InvalidImport            = errors.New("the import has rows with errors")
+	ExportInProgress         = errors.New("an export of the institution is already in progress")
+	ExportNotReady           = errors.New("the export is not finished or has expired")
+	ErasureInProgress        = errors.New("an erasure of the user is already requested")
+	ErasureReviewed          = errors.New("the erasure request is already reviewed")
+	UserErased               = errors.New("the personal data of the user is erased")
+	UserDeactivated          = errors.New("the user is deactivated")
 )
@@ -0,0 +1,252 @@
+// Package authz decides what users are allowed to do. A policy grants permissions to roles, and every
+// grant has a condition on the resource, such as the classroom belonging to the teacher. On top of
+// the policy, a user can be given an institution defined role in a classroom, which grants its
+// permissions on that classroom only.
+package authz
+
+import (
+	"context"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+)
+
+// Classrooms provides the facts about classrooms the conditions depend on.
+type Classrooms interface {
+	ById(ctx context.Context, id int) (core.Classroom, error)
+	IsIn(ctx context.Context, classroomId, studentId int) (bool, error)
+	IsBelongs(ctx context.Context, classroomId, teacherId int) (bool, error)
+}
+
+// Users provides the facts about users the conditions depend on.
+type Users interface {
+	ById(ctx context.Context, id int) (core.User, error)
+}
+
+// Teachers provides the facts about the students of teachers the conditions depend on.
+type Teachers interface {
+	Students(ctx context.Context, teacherId int) ([]core.Student, error)
+}
+
+// Roles provides the permissions of the institution defined roles assigned in classrooms.
+type Roles interface {
+	AssignedPermissions(ctx context.Context, classroomId, userId int) ([]string, error)
+}
+
+// Resource is the object of a permission. Zero fields are not set, so the zero Resource stands for
+// no object at all.
+type Resource struct {
+	ClassroomId int
+	UserId      int
+}
+
+// Classroom is the classroom with the id or anything inside it, like a lesson.
+func Classroom(id int) Resource {
+	return Resource{ClassroomId: id}
+}
+
+// User is the user with the id.
+func User(id int) Resource {
+	return Resource{UserId: id}
+}
+
+// Any is used for permissions which are not about a particular object.
+var Any = Resource{}
+
+// Request is what a condition is evaluated against.
+type Request struct {
+	Subject  core.TokenMetadata
+	Resource Resource
+	facts    *facts
+}
+
+// Condition decides whether a grant applies to the request.
+type Condition func(ctx context.Context, req Request) (bool, error)
+
+type Grant struct {
+	Permission Permission
+	Condition  Condition
+}
+
+// Policy lists the grants of every role.
+type Policy map[core.RoleType][]Grant
+
+// Scope is the part of the resources a list permission shows to the user.
+type Scope int
+
+const (
+	// NoScope is the scope of a user who cannot list the resources.
+	NoScope Scope = iota
+	// InstitutionScope covers everything in the institution of the user.
+	InstitutionScope
+	// TaughtScope covers the classrooms the teacher is on the staff of and the students of them.
+	TaughtScope
+	// EnrolledScope covers the classrooms the student studies in.
+	EnrolledScope
+)
+
+// Scopes tells every role what the lists of its list permissions are made of.
+type Scopes map[core.RoleType]map[Permission]Scope
+
+type Authorizer struct {
+	policy     Policy
+	scopes     Scopes
+	classrooms Classrooms
+	users      Users
+	teachers   Teachers
+	roles      Roles
+}
+
+func New(
+	policy Policy,
+	scopes Scopes,
+	classrooms Classrooms,
+	users Users,
+	teachers Teachers,
+	roles Roles,
+) *Authorizer {
+	return &Authorizer{
+		policy:     policy,
+		scopes:     scopes,
+		classrooms: classrooms,
+		users:      users,
+		teachers:   teachers,
+		roles:      roles,
+	}
+}
+
+// Can reports whether the user has the permission on the resource. Permissions which change a
+// classroom fail with apperrors.ClassroomArchived when the classroom is archived.
+func (a *Authorizer) Can(
+	ctx context.Context,
+	subject core.TokenMetadata,
+	permission Permission,
+	resource Resource,
+) (bool, error) {
+	ok, err := a.granted(ctx, subject, permission, resource)
+	if err != nil || !ok {
+		return ok, err
+	}
+
+	if resource.ClassroomId == 0 || !IsClassroomWritePermission(permission) {
+		return true, nil
+	}
+
+	classroom, err := a.classrooms.ById(ctx, resource.ClassroomId)
+	if err != nil {
+		return false, err
+	}
+
+	if classroom.ArchivedAt != nil {
+		return false, apperrors.ClassroomArchived
+	}
+
+	return true, nil
+}
+
+// granted reports whether the policy or a role assigned in the classroom gives the user the permission.
+func (a *Authorizer) granted(
+	ctx context.Context,
+	subject core.TokenMetadata,
+	permission Permission,
+	resource Resource,
+) (bool, error) {
+	req := Request{
+		Subject:  subject,
+		Resource: resource,
+		facts:    &facts{classrooms: a.classrooms, users: a.users, teachers: a.teachers},
+	}
+
+	for _, grant := range a.policy[core.RoleType(subject.Role)] {
+		if grant.Permission != permission {
+			continue
+		}
+
+		ok, err := grant.Condition(ctx, req)
+		if err != nil {
+			return false, err
+		}
+
+		if ok {
+			return true, nil
+		}
+	}
+
+	if resource.ClassroomId == 0 || !IsClassroomPermission(permission) {
+		return false, nil
+	}
+
+	assigned, err := a.roles.AssignedPermissions(ctx, resource.ClassroomId, subject.UserId)
+	if err != nil {
+		return false, err
+	}
+
+	for _, p := range assigned {
+		if Permission(p) == permission {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Authorize returns apperrors.AccessDenied when the user does not have the permission on the resource.
+func (a *Authorizer) Authorize(
+	ctx context.Context,
+	subject core.TokenMetadata,
+	permission Permission,
+	resource Resource,
+) error {
+	ok, err := a.Can(ctx, subject, permission, resource)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return apperrors.AccessDenied
+	}
+
+	return nil
+}
+
+// Scope authorizes the list permission and returns the scope of the list for the user. It returns
+// apperrors.AccessDenied when the user does not have the permission or the role has no scope for it.
+func (a *Authorizer) Scope(ctx context.Context, subject core.TokenMetadata, permission Permission) (Scope, error) {
+	if err := a.Authorize(ctx, subject, permission, Any); err != nil {
+		return NoScope, err
+	}
+
+	scope := a.scopes[core.RoleType(subject.Role)][permission]
+	if scope == NoScope {
+		return NoScope, apperrors.AccessDenied
+	}
+
+	return scope, nil
+}
+
+// facts loads what the conditions need once per request.
+type facts struct {
+	classrooms Classrooms
+	users      Users
+	teachers   Teachers
+
+	loadedUsers map[int]core.User
+}
+
+func (f *facts) user(ctx context.Context, id int) (core.User, error) {
+	if user, ok := f.loadedUsers[id]; ok {
+		return user, nil
+	}
+
+	user, err := f.users.ById(ctx, id)
+	if err != nil {
+		return core.User{}, err
+	}
+
+	if f.loadedUsers == nil {
+		f.loadedUsers = make(map[int]core.User)
+	}
+
+	f.loadedUsers[id] = user
+
+	return user, nil
+}
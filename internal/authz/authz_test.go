@@ -0,0 +1,314 @@
+package authz_test
+
+import (
+	"context"
+	"errors"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/authz"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"testing"
+	"time"
+)
+
+const (
+	admin          = 1
+	owner          = 2
+	coTeacher      = 3
+	student        = 4
+	otherAdmin     = 5
+	otherTeacher   = 6
+	assistant      = 7
+	classroom      = 10
+	archived       = 11
+	otherClassroom = 20
+)
+
+// fakeClassrooms knows the classrooms, their students and their staff.
+type fakeClassrooms struct {
+	classrooms map[int]core.Classroom
+	students   map[int][]int
+	staff      map[int][]int
+}
+
+func (f fakeClassrooms) ById(_ context.Context, id int) (core.Classroom, error) {
+	classroom, ok := f.classrooms[id]
+	if !ok {
+		return core.Classroom{}, apperrors.EntityNotFound
+	}
+
+	return classroom, nil
+}
+
+func (f fakeClassrooms) IsIn(_ context.Context, classroomId, studentId int) (bool, error) {
+	return contains(f.students[classroomId], studentId), nil
+}
+
+func (f fakeClassrooms) IsBelongs(_ context.Context, classroomId, teacherId int) (bool, error) {
+	return f.classrooms[classroomId].TeacherId == teacherId || contains(f.staff[classroomId], teacherId), nil
+}
+
+type fakeUsers map[int]core.User
+
+func (f fakeUsers) ById(_ context.Context, id int) (core.User, error) {
+	user, ok := f[id]
+	if !ok {
+		return core.User{}, apperrors.EntityNotFound
+	}
+
+	return user, nil
+}
+
+// fakeTeachers finds the students of a teacher in the classrooms the teacher is on the staff of.
+type fakeTeachers struct {
+	classrooms fakeClassrooms
+}
+
+func (f fakeTeachers) Students(ctx context.Context, teacherId int) ([]core.Student, error) {
+	var students []core.Student
+
+	for id := range f.classrooms.classrooms {
+		if ok, _ := f.classrooms.IsBelongs(ctx, id, teacherId); !ok {
+			continue
+		}
+
+		for _, studentId := range f.classrooms.students[id] {
+			students = append(students, core.Student{Id: studentId})
+		}
+	}
+
+	return students, nil
+}
+
+// fakeRoles holds the permissions assigned to users per classroom.
+type fakeRoles map[int]map[int][]string
+
+func (f fakeRoles) AssignedPermissions(_ context.Context, classroomId, userId int) ([]string, error) {
+	return f[classroomId][userId], nil
+}
+
+func contains(ids []int, id int) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newAuthorizer(policy authz.Policy) *authz.Authorizer {
+	institution, otherInstitution := 1, 2
+	archivedAt := time.Now()
+
+	classrooms := fakeClassrooms{
+		classrooms: map[int]core.Classroom{
+			classroom:      {Id: classroom, TeacherId: owner},
+			archived:       {Id: archived, TeacherId: owner, ArchivedAt: &archivedAt},
+			otherClassroom: {Id: otherClassroom, TeacherId: otherTeacher},
+		},
+		students: map[int][]int{classroom: {student}, archived: {student}},
+		staff:    map[int][]int{classroom: {coTeacher}},
+	}
+
+	users := fakeUsers{
+		admin:        {Id: admin, Role: core.AdminRole, InstitutionId: &institution},
+		owner:        {Id: owner, Role: core.TeacherRole, InstitutionId: &institution},
+		coTeacher:    {Id: coTeacher, Role: core.TeacherRole, InstitutionId: &institution},
+		student:      {Id: student, Role: core.StudentRole, InstitutionId: &institution},
+		otherAdmin:   {Id: otherAdmin, Role: core.AdminRole, InstitutionId: &otherInstitution},
+		otherTeacher: {Id: otherTeacher, Role: core.TeacherRole, InstitutionId: &otherInstitution},
+		assistant:    {Id: assistant, Role: core.StudentRole, InstitutionId: &institution},
+	}
+
+	roles := fakeRoles{
+		classroom: {assistant: {string(authz.LessonCreate), string(authz.ClassroomArchive)}},
+		archived:  {assistant: {string(authz.LessonCreate)}},
+	}
+
+	return authz.New(policy, authz.DefaultScopes(), classrooms, users, fakeTeachers{classrooms}, roles)
+}
+
+func subject(userId int, role core.RoleType) core.TokenMetadata {
+	return core.TokenMetadata{UserId: userId, Role: string(role)}
+}
+
+func TestDefaultPolicy(t *testing.T) {
+	a := newAuthorizer(authz.DefaultPolicy())
+
+	tests := []struct {
+		name       string
+		subject    core.TokenMetadata
+		permission authz.Permission
+		resource   authz.Resource
+		want       bool
+	}{
+		{"admin views a classroom of the institution", subject(admin, core.AdminRole), authz.ClassroomView, authz.Classroom(classroom), true},
+		{"admin views a classroom of another institution", subject(otherAdmin, core.AdminRole), authz.ClassroomView, authz.Classroom(classroom), false},
+		{"admin purges a classroom of the institution", subject(admin, core.AdminRole), authz.ClassroomPurge, authz.Classroom(classroom), true},
+		{"admin deletes a student of the institution", subject(admin, core.AdminRole), authz.StudentDelete, authz.User(student), true},
+		{"admin deletes a student of another institution", subject(otherAdmin, core.AdminRole), authz.StudentDelete, authz.User(student), false},
+		{"admin cannot create classrooms", subject(admin, core.AdminRole), authz.ClassroomCreate, authz.Any, false},
+		{"owner archives the classroom", subject(owner, core.TeacherRole), authz.ClassroomArchive, authz.Classroom(classroom), true},
+		{"co-teacher updates the classroom", subject(coTeacher, core.TeacherRole), authz.ClassroomUpdate, authz.Classroom(classroom), true},
+		{"co-teacher cannot archive the classroom", subject(coTeacher, core.TeacherRole), authz.ClassroomArchive, authz.Classroom(classroom), false},
+		{"co-teacher cannot manage the staff", subject(coTeacher, core.TeacherRole), authz.ClassroomStaffManage, authz.Classroom(classroom), false},
+		{"teacher creates a lesson in another classroom", subject(otherTeacher, core.TeacherRole), authz.LessonCreate, authz.Classroom(classroom), false},
+		{"teacher deletes an own student", subject(coTeacher, core.TeacherRole), authz.StudentDelete, authz.User(student), true},
+		{"teacher deletes another student", subject(otherTeacher, core.TeacherRole), authz.StudentDelete, authz.User(student), false},
+		{"teacher cannot list teachers", subject(owner, core.TeacherRole), authz.TeacherList, authz.Any, false},
+		{"student views the classroom", subject(student, core.StudentRole), authz.ClassroomView, authz.Classroom(classroom), true},
+		{"student views another classroom", subject(student, core.StudentRole), authz.ClassroomView, authz.Classroom(otherClassroom), false},
+		{"student cannot create a lesson", subject(student, core.StudentRole), authz.LessonCreate, authz.Classroom(classroom), false},
+		{"assigned role grants a classroom permission", subject(assistant, core.StudentRole), authz.LessonCreate, authz.Classroom(classroom), true},
+		{"assigned role is limited to the classroom", subject(assistant, core.StudentRole), authz.LessonCreate, authz.Classroom(otherClassroom), false},
+		{"assigned role cannot grant other permissions", subject(assistant, core.StudentRole), authz.ClassroomArchive, authz.Classroom(classroom), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.Can(context.Background(), tt.subject, tt.permission, tt.resource)
+			if err != nil {
+				t.Fatalf("Can() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Fatalf("Can() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanArchivedClassroom(t *testing.T) {
+	a := newAuthorizer(authz.DefaultPolicy())
+	ctx := context.Background()
+
+	ok, err := a.Can(ctx, subject(student, core.StudentRole), authz.ClassroomView, authz.Classroom(archived))
+	if err != nil || !ok {
+		t.Fatalf("Can() = %v, %v, want an archived classroom to be readable", ok, err)
+	}
+
+	for _, tt := range []struct {
+		subject    core.TokenMetadata
+		permission authz.Permission
+	}{
+		{subject(owner, core.TeacherRole), authz.ClassroomUpdate},
+		{subject(owner, core.TeacherRole), authz.LessonCreate},
+		{subject(admin, core.AdminRole), authz.ClassroomStudentsManage},
+		{subject(assistant, core.StudentRole), authz.LessonCreate},
+	} {
+		if _, err := a.Can(ctx, tt.subject, tt.permission, authz.Classroom(archived)); !errors.Is(err, apperrors.ClassroomArchived) {
+			t.Fatalf("Can(%d, %s) error = %v, want %v", tt.subject.UserId, tt.permission, err, apperrors.ClassroomArchived)
+		}
+	}
+
+	// Users without the permission are denied before the archive is checked.
+	ok, err = a.Can(ctx, subject(otherTeacher, core.TeacherRole), authz.ClassroomUpdate, authz.Classroom(archived))
+	if err != nil || ok {
+		t.Fatalf("Can() = %v, %v, want false without an error", ok, err)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	a := newAuthorizer(authz.DefaultPolicy())
+
+	err := a.Authorize(context.Background(), subject(student, core.StudentRole), authz.LessonCreate, authz.Classroom(classroom))
+	if !errors.Is(err, apperrors.AccessDenied) {
+		t.Fatalf("Authorize() error = %v, want %v", err, apperrors.AccessDenied)
+	}
+
+	err = a.Authorize(context.Background(), subject(owner, core.TeacherRole), authz.LessonCreate, authz.Classroom(classroom))
+	if err != nil {
+		t.Fatalf("Authorize() error = %v", err)
+	}
+}
+
+func TestScope(t *testing.T) {
+	a := newAuthorizer(authz.DefaultPolicy())
+
+	tests := []struct {
+		name       string
+		subject    core.TokenMetadata
+		permission authz.Permission
+		want       authz.Scope
+		wantErr    error
+	}{
+		{"admin lists the classrooms", subject(admin, core.AdminRole), authz.ClassroomList, authz.InstitutionScope, nil},
+		{"admin lists the students", subject(admin, core.AdminRole), authz.StudentList, authz.InstitutionScope, nil},
+		{"teacher lists the classrooms", subject(owner, core.TeacherRole), authz.ClassroomList, authz.TaughtScope, nil},
+		{"teacher lists the students", subject(owner, core.TeacherRole), authz.StudentList, authz.TaughtScope, nil},
+		{"teacher lists the teachers", subject(owner, core.TeacherRole), authz.TeacherList, authz.NoScope, apperrors.AccessDenied},
+		{"student lists the classrooms", subject(student, core.StudentRole), authz.ClassroomList, authz.EnrolledScope, nil},
+		{"student lists the students", subject(student, core.StudentRole), authz.StudentList, authz.NoScope, apperrors.AccessDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.Scope(context.Background(), tt.subject, tt.permission)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Scope() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Fatalf("Scope() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// A permission granted by the policy without a scope for the role is not a list the role can see.
+	a = authz.New(
+		authz.Policy{core.TeacherRole: {{authz.TeacherList, authz.Always}}},
+		authz.DefaultScopes(),
+		fakeClassrooms{},
+		fakeUsers{},
+		fakeTeachers{},
+		fakeRoles{},
+	)
+
+	if _, err := a.Scope(context.Background(), subject(owner, core.TeacherRole), authz.TeacherList); !errors.Is(err, apperrors.AccessDenied) {
+		t.Fatalf("Scope() error = %v, want %v", err, apperrors.AccessDenied)
+	}
+}
+
+func TestConditions(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition authz.Condition
+		subject   core.TokenMetadata
+		resource  authz.Resource
+		want      bool
+	}{
+		{"always without a resource", authz.Always, subject(student, core.StudentRole), authz.Any, true},
+		{"owns the classroom", authz.OwnsClassroom, subject(owner, core.TeacherRole), authz.Classroom(classroom), true},
+		{"co-teacher does not own the classroom", authz.OwnsClassroom, subject(coTeacher, core.TeacherRole), authz.Classroom(classroom), false},
+		{"owns no classroom without a resource", authz.OwnsClassroom, subject(owner, core.TeacherRole), authz.Any, false},
+		{"owner teaches the classroom", authz.TeachesClassroom, subject(owner, core.TeacherRole), authz.Classroom(classroom), true},
+		{"co-teacher teaches the classroom", authz.TeachesClassroom, subject(coTeacher, core.TeacherRole), authz.Classroom(classroom), true},
+		{"teacher of another classroom", authz.TeachesClassroom, subject(otherTeacher, core.TeacherRole), authz.Classroom(classroom), false},
+		{"student in the classroom", authz.InClassroom, subject(student, core.StudentRole), authz.Classroom(classroom), true},
+		{"student not in the classroom", authz.InClassroom, subject(student, core.StudentRole), authz.Classroom(otherClassroom), false},
+		{"teaches the student", authz.TeachesStudent, subject(coTeacher, core.TeacherRole), authz.User(student), true},
+		{"does not teach the student", authz.TeachesStudent, subject(otherTeacher, core.TeacherRole), authz.User(student), false},
+		{"teaches no student without a resource", authz.TeachesStudent, subject(owner, core.TeacherRole), authz.Any, false},
+		{"same institution user", authz.SameInstitution, subject(admin, core.AdminRole), authz.User(student), true},
+		{"same institution classroom", authz.SameInstitution, subject(admin, core.AdminRole), authz.Classroom(classroom), true},
+		{"other institution user", authz.SameInstitution, subject(otherAdmin, core.AdminRole), authz.User(student), false},
+		{"other institution classroom", authz.SameInstitution, subject(admin, core.AdminRole), authz.Classroom(otherClassroom), false},
+		{"same institution without a resource", authz.SameInstitution, subject(admin, core.AdminRole), authz.Any, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role := core.RoleType(tt.subject.Role)
+			a := newAuthorizer(authz.Policy{role: {{authz.ClassroomView, tt.condition}}})
+
+			got, err := a.Can(context.Background(), tt.subject, authz.ClassroomView, tt.resource)
+			if err != nil {
+				t.Fatalf("Can() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Fatalf("Can() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
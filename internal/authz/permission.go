@@ -0,0 +1,89 @@
+package authz
+
+// Permission names an action a user can be allowed to take.
+type Permission string
+
+const (
+	ClassroomList           Permission = "classroom.list"
+	ClassroomCreate         Permission = "classroom.create"
+	ClassroomView           Permission = "classroom.view"
+	ClassroomUpdate         Permission = "classroom.update"
+	ClassroomArchive        Permission = "classroom.archive"
+	ClassroomPurge          Permission = "classroom.purge"
+	ClassroomStudentsView   Permission = "classroom.students.view"
+	ClassroomStudentsManage Permission = "classroom.students.manage"
+	ClassroomRolesManage    Permission = "classroom.roles.manage"
+	ClassroomStaffManage    Permission = "classroom.staff.manage"
+	InvitationManage        Permission = "invitation.manage"
+
+	LessonView        Permission = "lesson.view"
+	LessonViewCurrent Permission = "lesson.view_current"
+	LessonCreate      Permission = "lesson.create"
+	LessonUpdate      Permission = "lesson.update"
+	LessonDelete      Permission = "lesson.delete"
+
+	StudentList   Permission = "student.list"
+	StudentCreate Permission = "student.create"
+	StudentDelete Permission = "student.delete"
+
+	TeacherList   Permission = "teacher.list"
+	TeacherCreate Permission = "teacher.create"
+	TeacherDelete Permission = "teacher.delete"
+
+	UserManage        Permission = "user.manage"
+	UserDeactivate    Permission = "user.deactivate"
+	UserImpersonate   Permission = "user.impersonate"
+	InstitutionManage Permission = "institution.manage"
+	InstitutionExport Permission = "institution.export"
+	AuditView         Permission = "audit.view"
+	MFAEnroll         Permission = "mfa.enroll"
+)
+
+// ClassroomPermissions are the permissions an institution defined role can grant. Such roles are
+// assigned per classroom, so only the permissions on the things inside a classroom make sense for them.
+var ClassroomPermissions = []Permission{
+	ClassroomView,
+	ClassroomStudentsView,
+	ClassroomStudentsManage,
+	InvitationManage,
+	LessonView,
+	LessonViewCurrent,
+	LessonCreate,
+	LessonUpdate,
+	LessonDelete,
+}
+
+// ClassroomWritePermissions change a classroom or the things inside it. They are not granted on
+// archived classrooms, which are read-only.
+var ClassroomWritePermissions = []Permission{
+	ClassroomUpdate,
+	ClassroomStudentsManage,
+	ClassroomRolesManage,
+	ClassroomStaffManage,
+	InvitationManage,
+	LessonCreate,
+	LessonUpdate,
+	LessonDelete,
+}
+
+// IsClassroomPermission reports whether the permission can be granted by an institution defined role.
+func IsClassroomPermission(permission Permission) bool {
+	for _, p := range ClassroomPermissions {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsClassroomWritePermission reports whether the permission changes a classroom or the things inside it.
+func IsClassroomWritePermission(permission Permission) bool {
+	for _, p := range ClassroomWritePermissions {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
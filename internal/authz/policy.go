@@ -0,0 +1,174 @@
+package authz
+
+import (
+	"context"
+	"github.com/migmatore/study-platform-api/internal/core"
+)
+
+// Always grants the permission whatever the resource is.
+func Always(context.Context, Request) (bool, error) {
+	return true, nil
+}
+
+// OwnsClassroom holds when the teacher is the owner of the classroom of the resource.
+func OwnsClassroom(ctx context.Context, req Request) (bool, error) {
+	if req.Resource.ClassroomId == 0 {
+		return false, nil
+	}
+
+	classroom, err := req.facts.classrooms.ById(ctx, req.Resource.ClassroomId)
+	if err != nil {
+		return false, err
+	}
+
+	return classroom.TeacherId == req.Subject.UserId, nil
+}
+
+// TeachesClassroom holds when the teacher is on the staff of the classroom of the resource, as the
+// owner, a co-teacher or an assistant.
+func TeachesClassroom(ctx context.Context, req Request) (bool, error) {
+	if req.Resource.ClassroomId == 0 {
+		return false, nil
+	}
+
+	return req.facts.classrooms.IsBelongs(ctx, req.Resource.ClassroomId, req.Subject.UserId)
+}
+
+// InClassroom holds when the student studies in the classroom of the resource.
+func InClassroom(ctx context.Context, req Request) (bool, error) {
+	if req.Resource.ClassroomId == 0 {
+		return false, nil
+	}
+
+	return req.facts.classrooms.IsIn(ctx, req.Resource.ClassroomId, req.Subject.UserId)
+}
+
+// TeachesStudent holds when the user of the resource studies in one of the classrooms of the teacher.
+func TeachesStudent(ctx context.Context, req Request) (bool, error) {
+	if req.Resource.UserId == 0 {
+		return false, nil
+	}
+
+	students, err := req.facts.teachers.Students(ctx, req.Subject.UserId)
+	if err != nil {
+		return false, err
+	}
+
+	for _, student := range students {
+		if student.Id == req.Resource.UserId {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// SameInstitution holds when the user or the classroom of the resource is in the institution of the
+// subject. A classroom is in the institution of its teacher.
+func SameInstitution(ctx context.Context, req Request) (bool, error) {
+	ownerId := req.Resource.UserId
+
+	if ownerId == 0 && req.Resource.ClassroomId != 0 {
+		classroom, err := req.facts.classrooms.ById(ctx, req.Resource.ClassroomId)
+		if err != nil {
+			return false, err
+		}
+
+		ownerId = classroom.TeacherId
+	}
+
+	if ownerId == 0 {
+		return false, nil
+	}
+
+	subject, err := req.facts.user(ctx, req.Subject.UserId)
+	if err != nil {
+		return false, err
+	}
+
+	owner, err := req.facts.user(ctx, ownerId)
+	if err != nil {
+		return false, err
+	}
+
+	return subject.InstitutionId != nil && owner.InstitutionId != nil &&
+		*subject.InstitutionId == *owner.InstitutionId, nil
+}
+
+// DefaultPolicy is the policy of the built-in roles.
+func DefaultPolicy() Policy {
+	return Policy{
+		core.AdminRole: {
+			{ClassroomList, Always},
+			{ClassroomView, SameInstitution},
+			{ClassroomUpdate, SameInstitution},
+			{ClassroomArchive, SameInstitution},
+			{ClassroomPurge, SameInstitution},
+			{ClassroomStudentsView, SameInstitution},
+			{ClassroomStudentsManage, SameInstitution},
+			{ClassroomRolesManage, SameInstitution},
+			{ClassroomStaffManage, SameInstitution},
+			{LessonView, SameInstitution},
+			{LessonViewCurrent, SameInstitution},
+			{LessonDelete, SameInstitution},
+			{StudentList, Always},
+			{StudentCreate, Always},
+			{StudentDelete, SameInstitution},
+			{TeacherList, Always},
+			{TeacherCreate, Always},
+			{TeacherDelete, SameInstitution},
+			{UserManage, SameInstitution},
+			{UserDeactivate, SameInstitution},
+			{UserImpersonate, SameInstitution},
+			{InstitutionManage, Always},
+			{InstitutionExport, Always},
+			{AuditView, Always},
+			{MFAEnroll, Always},
+		},
+		core.TeacherRole: {
+			{ClassroomList, Always},
+			{ClassroomCreate, Always},
+			{ClassroomView, TeachesClassroom},
+			{ClassroomUpdate, TeachesClassroom},
+			{ClassroomArchive, OwnsClassroom},
+			{ClassroomStudentsView, TeachesClassroom},
+			{ClassroomStudentsManage, TeachesClassroom},
+			{ClassroomRolesManage, OwnsClassroom},
+			{ClassroomStaffManage, OwnsClassroom},
+			{InvitationManage, TeachesClassroom},
+			{LessonView, TeachesClassroom},
+			{LessonViewCurrent, TeachesClassroom},
+			{LessonCreate, TeachesClassroom},
+			{LessonUpdate, TeachesClassroom},
+			{LessonDelete, TeachesClassroom},
+			{StudentList, Always},
+			{StudentCreate, Always},
+			{StudentDelete, TeachesStudent},
+			{UserDeactivate, TeachesStudent},
+			{MFAEnroll, Always},
+		},
+		core.StudentRole: {
+			{ClassroomList, Always},
+			{ClassroomView, InClassroom},
+			{LessonViewCurrent, InClassroom},
+		},
+	}
+}
+
+// DefaultScopes are the scopes of the list permissions of the built-in roles.
+func DefaultScopes() Scopes {
+	return Scopes{
+		core.AdminRole: {
+			ClassroomList: InstitutionScope,
+			StudentList:   InstitutionScope,
+			TeacherList:   InstitutionScope,
+		},
+		core.TeacherRole: {
+			ClassroomList: TaughtScope,
+			StudentList:   TaughtScope,
+		},
+		core.StudentRole: {
+			ClassroomList: EnrolledScope,
+		},
+	}
+}
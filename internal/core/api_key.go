@@ -0,0 +1,77 @@
+package core
+
+import "time"
+
+// APIKeyScopes lists the scopes a key can be given. A scope is "<resource>:<action>", where the resource
+// is the first segment of the route after /api/v1 and the action is read for GET requests and write for
+// any other method.
+var APIKeyScopes = []string{
+	"users:read",
+	"users:write",
+	"institutions:read",
+	"institutions:write",
+	"classrooms:read",
+	"classrooms:write",
+	"lessons:read",
+	"lessons:write",
+	"students:read",
+	"students:write",
+	"teachers:read",
+	"teachers:write",
+	"imports:write",
+	"exports:read",
+	"exports:write",
+	"erasures:read",
+	"audit:read",
+}
+
+type APIKeyModel struct {
+	Id            int
+	UserId        int
+	InstitutionId *int
+	Name          string
+	Prefix        string
+	KeyHash       string
+	Scopes        []string
+	ExpiresAt     *time.Time
+	LastUsedAt    *time.Time
+	RevokedAt     *time.Time
+	CreatedAt     time.Time
+}
+
+// APIKey authenticates integrations and scripts. Keys with InstitutionId are institution keys, the
+// others are personal keys of UserId.
+type APIKey struct {
+	Id            int
+	UserId        int
+	InstitutionId *int
+	Name          string
+	Prefix        string
+	Scopes        []string
+	ExpiresAt     *time.Time
+	LastUsedAt    *time.Time
+	RevokedAt     *time.Time
+	CreatedAt     time.Time
+}
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays *int     `json:"expires_in_days,omitempty"`
+}
+
+type APIKeyResponse struct {
+	Id         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreatedAPIKeyResponse carries the key itself, which is shown only once.
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
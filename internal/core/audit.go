@@ -0,0 +1,108 @@
+package core
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	AuditDelete AuditAction = "delete"
+)
+
+// Entity types of audit events.
+const (
+	AuditClassroom      = "classroom"
+	AuditClassroomRole  = "classroom_role"
+	AuditClassroomStaff = "classroom_staff"
+	AuditEnrollment     = "enrollment"
+	AuditLesson         = "lesson"
+	AuditStudent        = "student"
+	AuditTeacher        = "teacher"
+	AuditUser           = "user"
+	AuditSession        = "session"
+	AuditInvitation     = "invitation"
+	AuditAPIKey         = "api_key"
+	AuditRole           = "role"
+	AuditOIDCProvider   = "oidc_provider"
+	AuditWaitlist       = "waitlist"
+	AuditExport         = "export"
+	AuditErasure        = "erasure_request"
+)
+
+// RequestInfo describes the request a use case is called for.
+type RequestInfo struct {
+	Id     string
+	Client ClientInfo
+}
+
+type AuditEventModel struct {
+	Id             int64
+	ActorId        int
+	ActorRole      string
+	ImpersonatorId *int
+	InstitutionId  *int
+	Action         string
+	EntityType     string
+	EntityId       string
+	Before         []byte
+	After          []byte
+	RequestId      *string
+	IP             *string
+	CreatedAt      time.Time
+}
+
+// AuditEvent is a change made by a user. Before and After are the states of the entity, they are
+// stored as JSON with only the fields that changed. Before is nil for a create and After for a delete.
+type AuditEvent struct {
+	Action     AuditAction
+	EntityType string
+	EntityId   string
+	Before     interface{}
+	After      interface{}
+}
+
+// AuditRecord is a stored audit event.
+type AuditRecord struct {
+	Id             int64
+	ActorId        int
+	ActorRole      string
+	ImpersonatorId *int
+	InstitutionId  *int
+	Action         AuditAction
+	EntityType     string
+	EntityId       string
+	Before         json.RawMessage
+	After          json.RawMessage
+	RequestId      *string
+	IP             *string
+	CreatedAt      time.Time
+}
+
+type AuditFilter struct {
+	InstitutionId int
+	ActorId       *int
+	EntityType    *string
+	EntityId      *string
+	From          *time.Time
+	To            *time.Time
+	Limit         int
+}
+
+type AuditEventResponse struct {
+	Id             int64           `json:"id"`
+	ActorId        int             `json:"actor_id"`
+	ActorRole      string          `json:"actor_role"`
+	ImpersonatorId *int            `json:"impersonator_id,omitempty"`
+	Action         string          `json:"action"`
+	EntityType     string          `json:"entity_type"`
+	EntityId       string          `json:"entity_id"`
+	Before         json.RawMessage `json:"before,omitempty"`
+	After          json.RawMessage `json:"after,omitempty"`
+	RequestId      *string         `json:"request_id,omitempty"`
+	IP             *string         `json:"ip,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
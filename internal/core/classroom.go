@@ -1,11 +1,21 @@
 package core
 
+import "time"
+
 type ClassroomModel struct {
 	Id          int
 	Title       string
 	Description *string
 	TeacherId   int
 	MaxStudents int
+	ArchivedAt  *time.Time
+}
+
+type UpdateClassroomModel struct {
+	Id          int
+	Title       *string
+	Description *string
+	MaxStudents *int
 }
 
 type Classroom struct {
@@ -14,14 +24,23 @@ type Classroom struct {
 	Description *string
 	TeacherId   int
 	MaxStudents int
+	ArchivedAt  *time.Time
+}
+
+type UpdateClassroom struct {
+	Id          int
+	Title       *string
+	Description *string
+	MaxStudents *int
 }
 
 type ClassroomResponse struct {
-	Id          int     `json:"id"`
-	Title       string  `json:"title"`
-	Description *string `json:"description"`
-	TeacherId   int     `json:"teacher_id"`
-	MaxStudents int     `json:"max_students"`
+	Id          int        `json:"id"`
+	Title       string     `json:"title"`
+	Description *string    `json:"description"`
+	TeacherId   int        `json:"teacher_id"`
+	MaxStudents int        `json:"max_students"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
 }
 
 type CreateClassroomRequest struct {
@@ -29,3 +48,28 @@ type CreateClassroomRequest struct {
 	Description *string `json:"description,omitempty"`
 	MaxStudents int     `json:"max_students"`
 }
+
+type UpdateClassroomRequest struct {
+	Title       *string `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+	MaxStudents *int    `json:"max_students,omitempty"`
+}
+
+// CloneClassroomRequest configures the copy of a classroom. The copy keeps the title of the original
+// unless another one is given.
+type CloneClassroomRequest struct {
+	Title        *string `json:"title,omitempty"`
+	WithStudents bool    `json:"with_students"`
+	KeepActive   bool    `json:"keep_active"`
+}
+
+type EnrollStudentsRequest struct {
+	StudentsId []int `json:"students_id"`
+}
+
+type EnrollmentResponse struct {
+	ClassroomId int    `json:"classroom_id"`
+	StudentId   int    `json:"student_id"`
+	FullName    string `json:"full_name"`
+	Email       string `json:"email"`
+}
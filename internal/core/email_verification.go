@@ -0,0 +1,25 @@
+package core
+
+import "time"
+
+type EmailVerificationTokenModel struct {
+	Id        int
+	UserId    int
+	Email     string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+type EmailVerification struct {
+	UserId int
+	Email  string
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
@@ -0,0 +1,105 @@
+package core
+
+import "time"
+
+type ExportStatus string
+
+const (
+	ExportPending ExportStatus = "pending"
+	ExportRunning ExportStatus = "running"
+	ExportDone    ExportStatus = "done"
+	ExportFailed  ExportStatus = "failed"
+)
+
+type ExportJobModel struct {
+	Id            int
+	InstitutionId int
+	RequestedBy   *int
+	Status        string
+	Progress      int
+	Error         *string
+	FileName      *string
+	ExpiresAt     *time.Time
+	CreatedAt     time.Time
+	FinishedAt    *time.Time
+}
+
+// ExportJob builds a ZIP archive with all the data of an institution in the background. Progress is
+// the share of the work done in percent. The archive can be downloaded until the job expires.
+type ExportJob struct {
+	Id            int
+	InstitutionId int
+	RequestedBy   *int
+	Status        ExportStatus
+	Progress      int
+	Error         *string
+	FileName      *string
+	ExpiresAt     *time.Time
+	CreatedAt     time.Time
+	FinishedAt    *time.Time
+}
+
+type ExportJobResponse struct {
+	Id         int          `json:"id"`
+	Status     ExportStatus `json:"status"`
+	Progress   int          `json:"progress"`
+	Error      *string      `json:"error,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+	FinishedAt *time.Time   `json:"finished_at,omitempty"`
+	ExpiresAt  *time.Time   `json:"expires_at,omitempty"`
+}
+
+type ExportLink struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+type ExportLinkResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ExportFile is a finished export on disk.
+type ExportFile struct {
+	Path string
+	Name string
+}
+
+// The records below are the rows of the files in an institution export.
+
+type ExportUserRecord struct {
+	Id            int     `json:"id"`
+	FullName      string  `json:"full_name"`
+	Email         string  `json:"email"`
+	Phone         *string `json:"phone"`
+	Role          string  `json:"role"`
+	EmailVerified bool    `json:"email_verified"`
+}
+
+type ExportClassroomRecord struct {
+	Id          int        `json:"id"`
+	Title       string     `json:"title"`
+	Description *string    `json:"description"`
+	TeacherId   int        `json:"teacher_id"`
+	MaxStudents int        `json:"max_students"`
+	ArchivedAt  *time.Time `json:"archived_at"`
+}
+
+type ExportStaffRecord struct {
+	ClassroomId int    `json:"classroom_id"`
+	UserId      int    `json:"user_id"`
+	Role        string `json:"role"`
+}
+
+type ExportEnrollmentRecord struct {
+	ClassroomId int `json:"classroom_id"`
+	StudentId   int `json:"student_id"`
+}
+
+type ExportLessonRecord struct {
+	Id          int              `json:"id"`
+	ClassroomId int              `json:"classroom_id"`
+	Title       string           `json:"title"`
+	Active      bool             `json:"active"`
+	Content     *[]LessonContent `json:"content"`
+}
@@ -0,0 +1,40 @@
+package core
+
+import "time"
+
+type ImpersonationEventType string
+
+const (
+	// ImpersonationStarted is recorded when an admin gets a token to act as the user.
+	ImpersonationStarted ImpersonationEventType = "start"
+	// ImpersonatedRequest is recorded for every request made with the token.
+	ImpersonatedRequest ImpersonationEventType = "request"
+)
+
+type ImpersonationEventModel struct {
+	Id        int64
+	AdminId   int
+	UserId    int
+	Event     string
+	Method    *string
+	Path      *string
+	IP        *string
+	UserAgent *string
+	CreatedAt time.Time
+}
+
+type ImpersonationEvent struct {
+	AdminId int
+	UserId  int
+	Event   ImpersonationEventType
+	Method  string
+	Path    string
+	Client  ClientInfo
+}
+
+type ImpersonationResponse struct {
+	Token     string    `json:"token"`
+	UserId    int       `json:"user_id"`
+	Role      string    `json:"role"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,44 @@
+package core
+
+// ImportFile is an uploaded CSV or XLSX file with user accounts, one account per row after a header.
+type ImportFile struct {
+	Name    string
+	Content []byte
+}
+
+// ImportRow holds the cells of a row of the import file as they are. Line is the number of the row in
+// the file, counting the header.
+type ImportRow struct {
+	Line       int
+	FullName   string
+	Email      string
+	Phone      string
+	Role       string
+	Classrooms string
+}
+
+type ImportRowError struct {
+	Line   int      `json:"line"`
+	Email  string   `json:"email,omitempty"`
+	Errors []string `json:"errors"`
+}
+
+type ImportedAccount struct {
+	Line              int      `json:"line"`
+	Id                int      `json:"id"`
+	FullName          string   `json:"full_name"`
+	Email             string   `json:"email"`
+	Role              RoleType `json:"role"`
+	TemporaryPassword string   `json:"temporary_password"`
+	ClassroomsId      []int    `json:"classrooms_id"`
+	WaitlistedId      []int    `json:"waitlisted_id,omitempty"`
+}
+
+// ImportResponse reports the rows with errors. The accounts are only listed once the import is applied,
+// together with their temporary passwords, which are not shown again.
+type ImportResponse struct {
+	DryRun   bool              `json:"dry_run"`
+	Rows     int               `json:"rows"`
+	Errors   []ImportRowError  `json:"errors"`
+	Accounts []ImportedAccount `json:"accounts,omitempty"`
+}
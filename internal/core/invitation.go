@@ -0,0 +1,60 @@
+package core
+
+import "time"
+
+type InvitationModel struct {
+	Id          int
+	TokenHash   string
+	ClassroomId int
+	CreatedBy   int
+	Email       *string
+	MaxUses     *int
+	Uses        int
+	ExpiresAt   time.Time
+	RevokedAt   *time.Time
+	CreatedAt   time.Time
+}
+
+// Invitation lets students sign up into a classroom. A personal invitation is sent to Email and can
+// be used once; a join code has no email and can be shared with the whole class.
+type Invitation struct {
+	Id          int
+	ClassroomId int
+	CreatedBy   int
+	Email       *string
+	MaxUses     *int
+	Uses        int
+	ExpiresAt   time.Time
+	RevokedAt   *time.Time
+	CreatedAt   time.Time
+}
+
+type CreateInvitationRequest struct {
+	Email string `json:"email"`
+}
+
+type CreateJoinCodeRequest struct {
+	ExpiresInHours int  `json:"expires_in_hours,omitempty"`
+	MaxUses        *int `json:"max_uses,omitempty"`
+}
+
+type InvitationResponse struct {
+	Id        int       `json:"id"`
+	Email     *string   `json:"email,omitempty"`
+	Code      string    `json:"code,omitempty"`
+	MaxUses   *int      `json:"max_uses,omitempty"`
+	Uses      int       `json:"uses"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// InviteSignupRequest redeems either the token of a personal invitation or a join code.
+type InviteSignupRequest struct {
+	Token    string     `json:"token,omitempty"`
+	Code     string     `json:"code,omitempty"`
+	FullName string     `json:"full_name"`
+	Email    string     `json:"email,omitempty"`
+	Phone    *string    `json:"phone,omitempty"`
+	Password string     `json:"password"`
+	Client   ClientInfo `json:"-"`
+}
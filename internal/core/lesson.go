@@ -62,11 +62,9 @@ type CreateLessonRequest struct {
 }
 
 type UpdateLessonRequest struct {
-	// TODO: change current classroom id
-	CurrentClassroomId *int             `json:"classroom_id,omitempty"`
-	LessonId           *int             `json:"lesson_id,omitempty"`
-	Title              *string          `json:"title,omitempty"`
-	ClassroomId        *int             `json:"classroom_id,omitempty"`
-	Content            *[]LessonContent `json:"content,omitempty"`
-	Active             *bool            `json:"active,omitempty"`
+	LessonId    *int             `json:"lesson_id,omitempty"`
+	Title       *string          `json:"title,omitempty"`
+	ClassroomId *int             `json:"classroom_id,omitempty"`
+	Content     *[]LessonContent `json:"content,omitempty"`
+	Active      *bool            `json:"active,omitempty"`
 }
@@ -0,0 +1,74 @@
+package core
+
+import "time"
+
+type MFAModel struct {
+	UserId       int
+	Secret       string
+	ConfirmedAt  *time.Time
+	LastUsedStep int64
+}
+
+type MFA struct {
+	UserId       int
+	Secret       string
+	ConfirmedAt  *time.Time
+	LastUsedStep int64
+}
+
+type MFAChallengeModel struct {
+	Id        int
+	UserId    int
+	TokenHash string
+	Attempts  int
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+type MFAChallenge struct {
+	Id        int
+	UserId    int
+	Attempts  int
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+type MFAEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// SigninResult holds either the tokens of a completed sign in or, when the user has MFA enabled,
+// the challenge that has to be answered with a code first.
+type SigninResult struct {
+	Auth      *UserAuthResponse
+	Challenge *MFAChallengeResponse
+}
+
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
+type MFAVerifyRequest struct {
+	MFAToken string     `json:"mfa_token"`
+	Code     string     `json:"code"`
+	Client   ClientInfo `json:"-"`
+}
+
+type MFAEnrollResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code"`
+}
+
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type MFAStatusResponse struct {
+	Enabled bool `json:"enabled"`
+}
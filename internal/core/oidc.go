@@ -0,0 +1,78 @@
+package core
+
+import "time"
+
+type OIDCProviderModel struct {
+	InstitutionId int
+	Issuer        string
+	ClientId      string
+	ClientSecret  string
+	Scopes        string
+	RoleClaim     string
+	RoleMapping   map[string]string
+	DefaultRole   *string
+	Enabled       bool
+}
+
+// OIDCProvider is the identity provider of an institution. RoleMapping maps values of the RoleClaim
+// claim to roles; users matching none of them get DefaultRole or are not let in when it is empty.
+type OIDCProvider struct {
+	InstitutionId int
+	Issuer        string
+	ClientId      string
+	ClientSecret  string
+	Scopes        []string
+	RoleClaim     string
+	RoleMapping   map[string]RoleType
+	DefaultRole   *RoleType
+	Enabled       bool
+}
+
+type OIDCStateModel struct {
+	Id            int
+	StateHash     string
+	InstitutionId int
+	Nonce         string
+	CodeVerifier  string
+	ExpiresAt     time.Time
+	UsedAt        *time.Time
+}
+
+// OIDCIdentity is the user as described by the ID token of the provider.
+type OIDCIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	FullName      string
+	Role          RoleType
+}
+
+type OIDCCallbackRequest struct {
+	InstitutionId int
+	Code          string
+	State         string
+	Client        ClientInfo
+}
+
+type OIDCProviderResponse struct {
+	Issuer      string              `json:"issuer"`
+	ClientId    string              `json:"client_id"`
+	Scopes      []string            `json:"scopes"`
+	RoleClaim   string              `json:"role_claim"`
+	RoleMapping map[string]RoleType `json:"role_mapping"`
+	DefaultRole *RoleType           `json:"default_role,omitempty"`
+	Enabled     bool                `json:"enabled"`
+	RedirectURI string              `json:"redirect_uri"`
+}
+
+type UpdateOIDCProviderRequest struct {
+	Issuer       string              `json:"issuer"`
+	ClientId     string              `json:"client_id"`
+	ClientSecret *string             `json:"client_secret,omitempty"`
+	Scopes       []string            `json:"scopes,omitempty"`
+	RoleClaim    string              `json:"role_claim,omitempty"`
+	RoleMapping  map[string]RoleType `json:"role_mapping"`
+	DefaultRole  *RoleType           `json:"default_role,omitempty"`
+	Enabled      bool                `json:"enabled"`
+}
@@ -0,0 +1,20 @@
+package core
+
+import "time"
+
+type PasswordResetTokenModel struct {
+	Id        int
+	UserId    int
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
@@ -0,0 +1,113 @@
+package core
+
+import "time"
+
+type ErasureStatus string
+
+const (
+	ErasurePending  ErasureStatus = "pending"
+	ErasureApproved ErasureStatus = "approved"
+	ErasureRejected ErasureStatus = "rejected"
+)
+
+// ErasedUserName replaces the name of users whose personal data was erased.
+const ErasedUserName = "Deleted user"
+
+type ErasureRequestModel struct {
+	Id          int
+	UserId      int
+	FullName    string
+	Email       string
+	RequestedBy *int
+	Reason      *string
+	Status      string
+	ReviewedBy  *int
+	ReviewedAt  *time.Time
+	CreatedAt   time.Time
+}
+
+// ErasureRequest asks to erase the personal data of a user. FullName and Email are the current ones of
+// the user, after the erasure they are anonymized as well.
+type ErasureRequest struct {
+	Id          int
+	UserId      int
+	FullName    string
+	Email       string
+	RequestedBy *int
+	Reason      *string
+	Status      ErasureStatus
+	ReviewedBy  *int
+	ReviewedAt  *time.Time
+	CreatedAt   time.Time
+}
+
+type CreateErasureRequest struct {
+	Reason *string `json:"reason"`
+}
+
+type ErasureRequestResponse struct {
+	Id          int           `json:"id"`
+	UserId      int           `json:"user_id"`
+	FullName    string        `json:"full_name"`
+	Email       string        `json:"email"`
+	RequestedBy *int          `json:"requested_by"`
+	Reason      *string       `json:"reason,omitempty"`
+	Status      ErasureStatus `json:"status"`
+	ReviewedBy  *int          `json:"reviewed_by,omitempty"`
+	ReviewedAt  *time.Time    `json:"reviewed_at,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+// PersonalData is everything stored about a user, as given to the user on request.
+type PersonalData struct {
+	Profile        PersonalProfile           `json:"profile"`
+	Enrollments    []PersonalClassroomRecord `json:"enrollments"`
+	Waitlist       []PersonalClassroomRecord `json:"waitlist"`
+	ClassroomRoles []PersonalClassroomRecord `json:"classroom_roles"`
+	Sessions       []PersonalSessionRecord   `json:"sessions"`
+	Identities     []PersonalIdentityRecord  `json:"identities"`
+	APIKeys        []PersonalAPIKeyRecord    `json:"api_keys"`
+	ExportedAt     time.Time                 `json:"exported_at"`
+}
+
+type PersonalProfile struct {
+	Id            int     `json:"id"`
+	FullName      string  `json:"full_name"`
+	Email         string  `json:"email"`
+	Phone         *string `json:"phone"`
+	Role          string  `json:"role"`
+	InstitutionId *int    `json:"institution_id"`
+	EmailVerified bool    `json:"email_verified"`
+}
+
+// PersonalClassroomRecord is a classroom the user is in. Role is the staff or the custom role of the
+// user in the classroom, Position is the place on the waitlist.
+type PersonalClassroomRecord struct {
+	ClassroomId int     `json:"classroom_id"`
+	Title       string  `json:"title"`
+	Role        *string `json:"role,omitempty"`
+	Position    *int    `json:"position,omitempty"`
+}
+
+type PersonalSessionRecord struct {
+	UserAgent  *string    `json:"user_agent"`
+	IP         *string    `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+type PersonalIdentityRecord struct {
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PersonalAPIKeyRecord struct {
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
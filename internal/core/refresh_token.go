@@ -0,0 +1,23 @@
+package core
+
+import "time"
+
+type RefreshTokenModel struct {
+	Id        string
+	FamilyId  string
+	UserId    int
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	RotatedAt *time.Time
+	RevokedAt *time.Time
+}
+
+type RefreshToken struct {
+	Id        string
+	FamilyId  string
+	UserId    int
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	RotatedAt *time.Time
+	RevokedAt *time.Time
+}
@@ -8,7 +8,54 @@ const (
 	StudentRole RoleType = "student"
 )
 
+// RoleModel is either a built-in role, which has no institution, or a role defined by an institution.
 type RoleModel struct {
-	Id   int
-	Name string
+	Id            int
+	Name          string
+	InstitutionId *int
+	Permissions   []string
+}
+
+// Role is a role an institution defines on top of the built-in ones, like a teaching assistant. It is
+// not given to users as such but assigned to them in particular classrooms.
+type Role struct {
+	Id            int
+	InstitutionId int
+	Name          string
+	Permissions   []string
+}
+
+type RoleRequest struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+type RoleResponse struct {
+	Id          int      `json:"id"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+type ClassroomRoleModel struct {
+	ClassroomId int
+	UserId      int
+	RoleId      int
+	RoleName    string
+}
+
+type ClassroomRole struct {
+	ClassroomId int
+	UserId      int
+	RoleId      int
+	RoleName    string
+}
+
+type AssignClassroomRoleRequest struct {
+	RoleId int `json:"role_id"`
+}
+
+type ClassroomRoleResponse struct {
+	UserId   int    `json:"user_id"`
+	RoleId   int    `json:"role_id"`
+	RoleName string `json:"role_name"`
 }
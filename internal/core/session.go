@@ -0,0 +1,40 @@
+package core
+
+import "time"
+
+// ClientInfo describes the device a request was made from.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+type SessionModel struct {
+	Id         string
+	UserId     int
+	UserAgent  *string
+	IP         *string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+}
+
+type Session struct {
+	Id         string
+	UserId     int
+	UserAgent  *string
+	IP         *string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+}
+
+type SessionResponse struct {
+	Id         string    `json:"id"`
+	UserAgent  *string   `json:"user_agent,omitempty"`
+	IP         *string   `json:"ip,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Current    bool      `json:"current"`
+}
@@ -0,0 +1,18 @@
+package core
+
+import "time"
+
+type ThrottleScope string
+
+const (
+	AccountThrottleScope ThrottleScope = "account"
+	IPThrottleScope      ThrottleScope = "ip"
+)
+
+type SigninThrottleModel struct {
+	Scope         string
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
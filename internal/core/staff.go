@@ -0,0 +1,43 @@
+package core
+
+type StaffRole string
+
+const (
+	StaffOwner     StaffRole = "owner"
+	StaffCoTeacher StaffRole = "co_teacher"
+	StaffAssistant StaffRole = "assistant"
+)
+
+type ClassroomStaffModel struct {
+	ClassroomId int
+	UserId      int
+	FullName    string
+	Email       string
+	Role        string
+}
+
+// ClassroomStaff is a teacher who runs the classroom. The owner is the teacher of the classroom, the
+// co-teachers and assistants share the teacher rights in it.
+type ClassroomStaff struct {
+	ClassroomId int
+	UserId      int
+	FullName    string
+	Email       string
+	Role        StaffRole
+}
+
+type AddClassroomStaffRequest struct {
+	UserId int       `json:"user_id"`
+	Role   StaffRole `json:"role"`
+}
+
+type TransferClassroomOwnershipRequest struct {
+	UserId int `json:"user_id"`
+}
+
+type ClassroomStaffResponse struct {
+	UserId   int       `json:"user_id"`
+	FullName string    `json:"full_name"`
+	Email    string    `json:"email"`
+	Role     StaffRole `json:"role"`
+}
@@ -1,9 +1,15 @@
 package core
 
 type TokenMetadata struct {
-	UserId  int
-	Role    string
-	Expires int64
+	UserId    int
+	Role      string
+	SessionId string
+	Expires   int64
+	// APIKeyId is set when the request is authenticated with an API key instead of a token.
+	APIKeyId int
+	Scopes   []string
+	// ImpersonatorId is the admin acting as the user when the token was issued for impersonation.
+	ImpersonatorId int
 }
 
 type TokenWithClaims struct {
@@ -13,11 +19,16 @@ type TokenWithClaims struct {
 }
 
 type RefreshTokenMetadata struct {
-	UserId  int
-	Expires int64
+	Id       string
+	FamilyId string
+	UserId   int
+	Expires  int64
 }
 
 type RefreshTokenWithClaims struct {
-	Token  string
-	UserId int
+	Token    string
+	Id       string
+	FamilyId string
+	UserId   int
+	Expires  int64
 }
@@ -1,5 +1,7 @@
 package core
 
+import "time"
+
 type UserModel struct {
 	Id            int
 	FullName      string
@@ -8,6 +10,9 @@ type UserModel struct {
 	PasswordHash  string
 	RoleId        int
 	InstitutionId *int
+	EmailVerified bool
+	DeactivatedAt *time.Time
+	DeletedAt     *time.Time
 }
 
 type User struct {
@@ -18,6 +23,15 @@ type User struct {
 	PasswordHash  string
 	Role          RoleType
 	InstitutionId *int
+	EmailVerified bool
+	DeactivatedAt *time.Time
+	DeletedAt     *time.Time
+}
+
+// Active tells whether the user can sign in. Deactivated and deleted users can not until an admin
+// reactivates them.
+func (u User) Active() bool {
+	return u.DeactivatedAt == nil && u.DeletedAt == nil
 }
 
 type UserProfile struct {
@@ -47,16 +61,18 @@ type UpdateUserProfile struct {
 }
 
 type UserSigninRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string     `json:"email"`
+	Password string     `json:"password"`
+	Client   ClientInfo `json:"-"`
 }
 
 type UserSignupRequest struct {
-	Email           string   `json:"email"`
-	Password        string   `json:"password"`
-	FullName        string   `json:"full_name"`
-	InstitutionName string   `json:"institution_name,omitempty"`
-	Role            RoleType `json:"role"`
+	Email           string     `json:"email"`
+	Password        string     `json:"password"`
+	FullName        string     `json:"full_name"`
+	InstitutionName string     `json:"institution_name,omitempty"`
+	Role            RoleType   `json:"role"`
+	Client          ClientInfo `json:"-"`
 }
 
 type UserAuthResponse struct {
@@ -67,6 +83,11 @@ type UserAuthResponse struct {
 }
 
 type UserTokenRefreshRequest struct {
+	RefreshToken string     `json:"refresh_token"`
+	Client       ClientInfo `json:"-"`
+}
+
+type UserLogoutRequest struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
@@ -75,40 +96,58 @@ type UserAuthRequest struct {
 }
 
 type StudentModel struct {
-	Id           int
-	FullName     string
-	Phone        *string
-	Email        string
-	ClassroomsId []int
+	Id            int
+	FullName      string
+	Phone         *string
+	Email         string
+	ClassroomsId  []int
+	DeactivatedAt *time.Time
+	DeletedAt     *time.Time
 }
 
 type Student struct {
-	Id           int
-	FullName     string
-	Phone        *string
-	Email        string
-	ClassroomsId []int
+	Id            int
+	FullName      string
+	Phone         *string
+	Email         string
+	ClassroomsId  []int
+	DeactivatedAt *time.Time
+	DeletedAt     *time.Time
+}
+
+func (s Student) Active() bool {
+	return s.DeactivatedAt == nil && s.DeletedAt == nil
 }
 
 type Teacher struct {
-	Id       int
-	FullName string
-	Phone    *string
-	Email    string
+	Id            int
+	FullName      string
+	Phone         *string
+	Email         string
+	DeactivatedAt *time.Time
+	DeletedAt     *time.Time
+}
+
+func (t Teacher) Active() bool {
+	return t.DeactivatedAt == nil && t.DeletedAt == nil
 }
 
 type StudentResponse struct {
-	Id           int     `json:"id"`
-	FullName     string  `json:"full_name"`
-	Phone        *string `json:"phone,omitempty"`
-	Email        string  `json:"email"`
-	ClassroomsId []int   `json:"classrooms_id,omitempty"`
+	Id            int        `json:"id"`
+	FullName      string     `json:"full_name"`
+	Phone         *string    `json:"phone,omitempty"`
+	Email         string     `json:"email"`
+	ClassroomsId  []int      `json:"classrooms_id,omitempty"`
+	DeactivatedAt *time.Time `json:"deactivated_at,omitempty"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
 }
 
 type ProfileResponse struct {
-	FullName string  `json:"full_name"`
-	Phone    *string `json:"phone,omitempty"`
-	Email    string  `json:"email"`
+	FullName      string  `json:"full_name"`
+	Phone         *string `json:"phone,omitempty"`
+	Email         string  `json:"email"`
+	EmailVerified bool    `json:"email_verified"`
+	PendingEmail  *string `json:"pending_email,omitempty"`
 }
 
 type UpdateProfileRequest struct {
@@ -127,10 +166,12 @@ type CreateStudentRequest struct {
 }
 
 type TeacherResponse struct {
-	Id       int     `json:"id"`
-	FullName string  `json:"full_name"`
-	Phone    *string `json:"phone,omitempty"`
-	Email    string  `json:"email"`
+	Id            int        `json:"id"`
+	FullName      string     `json:"full_name"`
+	Phone         *string    `json:"phone,omitempty"`
+	Email         string     `json:"email"`
+	DeactivatedAt *time.Time `json:"deactivated_at,omitempty"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
 }
 
 type CreateTeacherRequest struct {
@@ -0,0 +1,47 @@
+package core
+
+import "time"
+
+type WaitlistEntryModel struct {
+	ClassroomId int
+	StudentId   int
+	FullName    string
+	Email       string
+	Position    int
+	CreatedAt   time.Time
+}
+
+// WaitlistEntry is a student waiting for a seat in a full classroom. Students get seats in the order
+// of their positions.
+type WaitlistEntry struct {
+	ClassroomId int
+	StudentId   int
+	FullName    string
+	Email       string
+	Position    int
+	CreatedAt   time.Time
+}
+
+// Enrollment holds the students who got a seat in the classroom and the ones who were put on its
+// waitlist because the classroom was full.
+type Enrollment struct {
+	Enrolled   []int
+	Waitlisted []int
+}
+
+type ReorderWaitlistRequest struct {
+	StudentsId []int `json:"students_id"`
+}
+
+type WaitlistEntryResponse struct {
+	StudentId int       `json:"student_id"`
+	FullName  string    `json:"full_name"`
+	Email     string    `json:"email"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type EnrollStudentsResponse struct {
+	Enrolled   []int `json:"enrolled"`
+	Waitlisted []int `json:"waitlisted"`
+}
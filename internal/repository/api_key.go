@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/internal/repository/psql"
+	"github.com/migmatore/study-platform-api/pkg/logger"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+)
+
+type APIKeyRepo struct {
+	logger logger.Logger
+	pool   psql.AtomicPoolClient
+}
+
+func NewAPIKeyRepo(logger logger.Logger, pool psql.AtomicPoolClient) *APIKeyRepo {
+	return &APIKeyRepo{logger: logger, pool: pool}
+}
+
+func (r APIKeyRepo) Create(ctx context.Context, key core.APIKeyModel) (core.APIKeyModel, error) {
+	q := `INSERT INTO api_keys(user_id, institution_id, name, prefix, key_hash, scopes, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, user_id, institution_id, name, prefix, key_hash, scopes, expires_at, last_used_at,
+			    revoked_at, created_at`
+
+	return r.scan(r.pool.QueryRow(
+		ctx,
+		q,
+		key.UserId,
+		key.InstitutionId,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		key.Scopes,
+		key.ExpiresAt,
+	))
+}
+
+func (r APIKeyRepo) ById(ctx context.Context, id int) (core.APIKeyModel, error) {
+	q := `SELECT id, user_id, institution_id, name, prefix, key_hash, scopes, expires_at, last_used_at,
+       		revoked_at, created_at
+			FROM api_keys WHERE id = $1`
+
+	return r.scan(r.pool.QueryRow(ctx, q, id))
+}
+
+// Use marks the key as used now and returns it. Revoked keys are not found.
+func (r APIKeyRepo) Use(ctx context.Context, hash string) (core.APIKeyModel, error) {
+	q := `UPDATE api_keys SET last_used_at = now()
+			WHERE key_hash = $1 AND revoked_at IS NULL
+			RETURNING id, user_id, institution_id, name, prefix, key_hash, scopes, expires_at, last_used_at,
+			    revoked_at, created_at`
+
+	return r.scan(r.pool.QueryRow(ctx, q, hash))
+}
+
+// ActiveByUserId returns the personal keys of the user.
+func (r APIKeyRepo) ActiveByUserId(ctx context.Context, userId int) ([]core.APIKeyModel, error) {
+	q := `SELECT id, user_id, institution_id, name, prefix, key_hash, scopes, expires_at, last_used_at,
+       		revoked_at, created_at
+			FROM api_keys
+			WHERE user_id = $1 AND institution_id IS NULL AND revoked_at IS NULL
+			ORDER BY created_at DESC`
+
+	return r.list(ctx, q, userId)
+}
+
+func (r APIKeyRepo) ActiveByInstitutionId(ctx context.Context, institutionId int) ([]core.APIKeyModel, error) {
+	q := `SELECT id, user_id, institution_id, name, prefix, key_hash, scopes, expires_at, last_used_at,
+       		revoked_at, created_at
+			FROM api_keys
+			WHERE institution_id = $1 AND revoked_at IS NULL
+			ORDER BY created_at DESC`
+
+	return r.list(ctx, q, institutionId)
+}
+
+func (r APIKeyRepo) Revoke(ctx context.Context, id int) error {
+	q := `UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`
+
+	if _, err := r.pool.Exec(ctx, q, id); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r APIKeyRepo) list(ctx context.Context, q string, args ...interface{}) ([]core.APIKeyModel, error) {
+	rows, err := r.pool.Query(ctx, q, args...)
+	if err != nil {
+		r.logger.Errorf("Query error. %v", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	keys := make([]core.APIKeyModel, 0)
+
+	for rows.Next() {
+		key, err := r.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Errorf("Query error. %v", err)
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r APIKeyRepo) scan(row pgx.Row) (core.APIKeyModel, error) {
+	var key core.APIKeyModel
+
+	if err := row.Scan(
+		&key.Id,
+		&key.UserId,
+		&key.InstitutionId,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&key.Scopes,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+		&key.CreatedAt,
+	); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return core.APIKeyModel{}, apperrors.EntityNotFound
+			}
+
+			r.logger.Errorf("Error: %v", err)
+			return core.APIKeyModel{}, err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return core.APIKeyModel{}, err
+	}
+
+	return key, nil
+}
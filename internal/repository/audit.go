@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/internal/repository/psql"
+	"github.com/migmatore/study-platform-api/pkg/logger"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+)
+
+type AuditRepo struct {
+	logger logger.Logger
+	pool   psql.AtomicPoolClient
+}
+
+func NewAuditRepo(logger logger.Logger, pool psql.AtomicPoolClient) *AuditRepo {
+	return &AuditRepo{logger: logger, pool: pool}
+}
+
+func (r AuditRepo) Create(ctx context.Context, event core.AuditEventModel) error {
+	q := `INSERT INTO audit_events(actor_id, actor_role, impersonator_id, institution_id, action, entity_type,
+                         entity_id, before, after, request_id, ip)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	if _, err := r.pool.Exec(
+		ctx,
+		q,
+		event.ActorId,
+		event.ActorRole,
+		event.ImpersonatorId,
+		event.InstitutionId,
+		event.Action,
+		event.EntityType,
+		event.EntityId,
+		event.Before,
+		event.After,
+		event.RequestId,
+		event.IP,
+	); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// Search returns the latest events of the institution which match the filter.
+func (r AuditRepo) Search(ctx context.Context, filter core.AuditFilter) ([]core.AuditEventModel, error) {
+	q := `SELECT id, actor_id, actor_role, impersonator_id, institution_id, action, entity_type, entity_id,
+       			before, after, request_id, ip, created_at
+			FROM audit_events
+			WHERE institution_id = $1
+			  AND ($2::INT IS NULL OR actor_id = $2)
+			  AND ($3::VARCHAR IS NULL OR entity_type = $3)
+			  AND ($4::VARCHAR IS NULL OR entity_id = $4)
+			  AND ($5::TIMESTAMPTZ IS NULL OR created_at >= $5)
+			  AND ($6::TIMESTAMPTZ IS NULL OR created_at < $6)
+			ORDER BY created_at DESC, id DESC
+			LIMIT $7`
+
+	rows, err := r.pool.Query(
+		ctx,
+		q,
+		filter.InstitutionId,
+		filter.ActorId,
+		filter.EntityType,
+		filter.EntityId,
+		filter.From,
+		filter.To,
+		filter.Limit,
+	)
+	if err != nil {
+		r.logger.Errorf("Query error. %v", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	events := make([]core.AuditEventModel, 0)
+
+	for rows.Next() {
+		var e core.AuditEventModel
+
+		if err := rows.Scan(
+			&e.Id,
+			&e.ActorId,
+			&e.ActorRole,
+			&e.ImpersonatorId,
+			&e.InstitutionId,
+			&e.Action,
+			&e.EntityType,
+			&e.EntityId,
+			&e.Before,
+			&e.After,
+			&e.RequestId,
+			&e.IP,
+			&e.CreatedAt,
+		); err != nil {
+			r.logger.Errorf("Query error. %v", err)
+			return nil, err
+		}
+
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Errorf("Query error. %v", err)
+		return nil, err
+	}
+
+	return events, nil
+}
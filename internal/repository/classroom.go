@@ -2,12 +2,14 @@ package repository
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
 	"github.com/migmatore/study-platform-api/internal/core"
 	"github.com/migmatore/study-platform-api/internal/repository/psql"
 	"github.com/migmatore/study-platform-api/pkg/logger"
 	"github.com/migmatore/study-platform-api/pkg/utils"
-	"strings"
+	"time"
 )
 
 type ClassroomRepo struct {
@@ -20,8 +22,13 @@ func NewClassroomRepo(logger logger.Logger, pool psql.AtomicPoolClient) *Classro
 }
 
 func (r ClassroomRepo) Create(ctx context.Context, classroom core.ClassroomModel) (core.ClassroomModel, error) {
-	q := `INSERT INTO classrooms(title, description, teacher_id, max_students) VALUES($1, $2, $3, $4) 
-			RETURNING id, title, description, teacher_id, max_students`
+	q := `WITH c AS (
+				INSERT INTO classrooms(title, description, teacher_id, max_students) VALUES($1, $2, $3, $4)
+				RETURNING id, title, description, teacher_id, max_students, archived_at
+			), s AS (
+				INSERT INTO classroom_staff(classroom_id, user_id, role) SELECT id, teacher_id, 'owner' FROM c
+			)
+			SELECT id, title, description, teacher_id, max_students, archived_at FROM c`
 
 	newCLassroom := core.ClassroomModel{}
 
@@ -38,6 +45,7 @@ func (r ClassroomRepo) Create(ctx context.Context, classroom core.ClassroomModel
 		&newCLassroom.Description,
 		&newCLassroom.TeacherId,
 		&newCLassroom.MaxStudents,
+		&newCLassroom.ArchivedAt,
 	); err != nil {
 		if err := utils.ParsePgError(err); err != nil {
 			r.logger.Errorf("Error: %v", err)
@@ -51,24 +59,88 @@ func (r ClassroomRepo) Create(ctx context.Context, classroom core.ClassroomModel
 	return newCLassroom, nil
 }
 
-func (r ClassroomRepo) Delete(ctx context.Context, id int) error {
-	q := `DELETE FROM classrooms WHERE id = $1`
+// Archive makes the classroom read-only and hides it from the default lists. Archiving an archived
+// classroom keeps the time it was archived at.
+func (r ClassroomRepo) Archive(ctx context.Context, id int) (core.ClassroomModel, error) {
+	q := `UPDATE classrooms SET archived_at = COALESCE(archived_at, now()) WHERE id = $1
+			RETURNING id, title, description, teacher_id, max_students, archived_at`
 
-	if _, err := r.pool.Exec(ctx, q, id); err != nil {
+	return r.scan(r.pool.QueryRow(ctx, q, id))
+}
+
+func (r ClassroomRepo) Restore(ctx context.Context, id int) (core.ClassroomModel, error) {
+	q := `UPDATE classrooms SET archived_at = NULL WHERE id = $1
+			RETURNING id, title, description, teacher_id, max_students, archived_at`
+
+	return r.scan(r.pool.QueryRow(ctx, q, id))
+}
+
+// Purge deletes the classroom with its lessons and enrollments if it was archived before the time.
+// It reports whether the classroom was deleted.
+func (r ClassroomRepo) Purge(ctx context.Context, id int, archivedBefore time.Time) (bool, error) {
+	q := `DELETE FROM classrooms WHERE id = $1 AND archived_at <= $2`
+
+	tag, err := r.pool.Exec(ctx, q, id, archivedBefore)
+	if err != nil {
 		if err := utils.ParsePgError(err); err != nil {
 			r.logger.Errorf("Error: %v", err)
-			return err
+			return false, err
 		}
 
 		r.logger.Errorf("Query error. %v", err)
-		return err
+		return false, err
 	}
 
-	return nil
+	return tag.RowsAffected() > 0, nil
+}
+
+// Update changes the fields which are set and returns the updated classroom.
+func (r ClassroomRepo) Update(ctx context.Context, classroom core.UpdateClassroomModel) (core.ClassroomModel, error) {
+	updateQuery := psql.NewSQLUpdateBuilder("classrooms")
+
+	if classroom.Title != nil {
+		updateQuery.AddUpdateColumn("title", classroom.Title)
+	}
+
+	if classroom.Description != nil {
+		updateQuery.AddUpdateColumn("description", classroom.Description)
+	}
+
+	if classroom.MaxStudents != nil {
+		updateQuery.AddUpdateColumn("max_students", classroom.MaxStudents)
+	}
+
+	updateQuery.AddWhere("id", classroom.Id)
+	updateQuery.AddReturning("id", "title", "description", "teacher_id", "max_students", "archived_at")
+
+	var updated core.ClassroomModel
+
+	if err := r.pool.QueryRow(ctx, updateQuery.GetQuery(), updateQuery.GetValues()...).Scan(
+		&updated.Id,
+		&updated.Title,
+		&updated.Description,
+		&updated.TeacherId,
+		&updated.MaxStudents,
+		&updated.ArchivedAt,
+	); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return core.ClassroomModel{}, apperrors.EntityNotFound
+			}
+
+			r.logger.Errorf("Error: %v", err)
+			return core.ClassroomModel{}, err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return core.ClassroomModel{}, err
+	}
+
+	return updated, nil
 }
 
 func (r ClassroomRepo) ById(ctx context.Context, id int) (core.ClassroomModel, error) {
-	q := `SELECT id, title, description, teacher_id, max_students FROM classrooms WHERE id = $1`
+	q := `SELECT id, title, description, teacher_id, max_students, archived_at FROM classrooms WHERE id = $1`
 
 	var classroom core.ClassroomModel
 
@@ -78,8 +150,13 @@ func (r ClassroomRepo) ById(ctx context.Context, id int) (core.ClassroomModel, e
 		&classroom.Description,
 		&classroom.TeacherId,
 		&classroom.MaxStudents,
+		&classroom.ArchivedAt,
 	); err != nil {
 		if err := utils.ParsePgError(err); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return classroom, apperrors.EntityNotFound
+			}
+
 			r.logger.Errorf("Error: %v", err)
 			return classroom, err
 		}
@@ -91,8 +168,18 @@ func (r ClassroomRepo) ById(ctx context.Context, id int) (core.ClassroomModel, e
 	return classroom, nil
 }
 
+// ByIdForUpdate returns the classroom and locks it until the end of the transaction.
+func (r ClassroomRepo) ByIdForUpdate(ctx context.Context, id int) (core.ClassroomModel, error) {
+	q := `SELECT id, title, description, teacher_id, max_students, archived_at FROM classrooms WHERE id = $1
+			FOR UPDATE`
+
+	return r.scan(r.pool.QueryRow(ctx, q, id))
+}
+
+// TeacherClassrooms returns the classrooms the teacher is on the staff of.
 func (r ClassroomRepo) TeacherClassrooms(ctx context.Context, teacherId int) ([]core.ClassroomModel, error) {
-	q := `SELECT id, title, description, teacher_id, max_students FROM classrooms WHERE teacher_id = $1`
+	q := `SELECT c.id, c.title, c.description, c.teacher_id, c.max_students, c.archived_at FROM classroom_staff cs
+			JOIN classrooms c ON c.id = cs.classroom_id WHERE cs.user_id = $1`
 
 	classrooms := make([]core.ClassroomModel, 0)
 
@@ -113,6 +200,7 @@ func (r ClassroomRepo) TeacherClassrooms(ctx context.Context, teacherId int) ([]
 			&classroom.Description,
 			&classroom.TeacherId,
 			&classroom.MaxStudents,
+			&classroom.ArchivedAt,
 		)
 		if err != nil {
 			r.logger.Errorf("Query error. %v", err)
@@ -126,7 +214,7 @@ func (r ClassroomRepo) TeacherClassrooms(ctx context.Context, teacherId int) ([]
 }
 
 func (r ClassroomRepo) StudentClassrooms(ctx context.Context, studentId int) ([]core.ClassroomModel, error) {
-	q := `SELECT c.id, c.title, c.description, c.teacher_id, c.max_students FROM classroom_students 
+	q := `SELECT c.id, c.title, c.description, c.teacher_id, c.max_students, c.archived_at FROM classroom_students 
     	JOIN public.classrooms c ON c.id = classroom_students.classroom_id WHERE student_id = $1`
 
 	classrooms := make([]core.ClassroomModel, 0)
@@ -148,6 +236,80 @@ func (r ClassroomRepo) StudentClassrooms(ctx context.Context, studentId int) ([]
 			&classroom.Description,
 			&classroom.TeacherId,
 			&classroom.MaxStudents,
+			&classroom.ArchivedAt,
+		)
+		if err != nil {
+			r.logger.Errorf("Query error. %v", err)
+			return nil, err
+		}
+
+		classrooms = append(classrooms, classroom)
+	}
+
+	return classrooms, nil
+}
+
+func (r ClassroomRepo) InstitutionClassrooms(ctx context.Context, institutionId int) ([]core.ClassroomModel, error) {
+	q := `SELECT c.id, c.title, c.description, c.teacher_id, c.max_students, c.archived_at FROM classrooms c
+    	JOIN users u ON u.id = c.teacher_id WHERE u.institution_id = $1`
+
+	classrooms := make([]core.ClassroomModel, 0)
+
+	rows, err := r.pool.Query(ctx, q, institutionId)
+	if err != nil {
+		r.logger.Errorf("Query error. %v", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		classroom := core.ClassroomModel{}
+
+		err := rows.Scan(
+			&classroom.Id,
+			&classroom.Title,
+			&classroom.Description,
+			&classroom.TeacherId,
+			&classroom.MaxStudents,
+			&classroom.ArchivedAt,
+		)
+		if err != nil {
+			r.logger.Errorf("Query error. %v", err)
+			return nil, err
+		}
+
+		classrooms = append(classrooms, classroom)
+	}
+
+	return classrooms, nil
+}
+
+// AssignedClassrooms returns the classrooms the user has an institution defined role in.
+func (r ClassroomRepo) AssignedClassrooms(ctx context.Context, userId int) ([]core.ClassroomModel, error) {
+	q := `SELECT c.id, c.title, c.description, c.teacher_id, c.max_students, c.archived_at FROM classrooms c
+    	JOIN classroom_roles cr ON cr.classroom_id = c.id WHERE cr.user_id = $1`
+
+	classrooms := make([]core.ClassroomModel, 0)
+
+	rows, err := r.pool.Query(ctx, q, userId)
+	if err != nil {
+		r.logger.Errorf("Query error. %v", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		classroom := core.ClassroomModel{}
+
+		err := rows.Scan(
+			&classroom.Id,
+			&classroom.Title,
+			&classroom.Description,
+			&classroom.TeacherId,
+			&classroom.MaxStudents,
+			&classroom.ArchivedAt,
 		)
 		if err != nil {
 			r.logger.Errorf("Query error. %v", err)
@@ -179,7 +341,8 @@ func (r ClassroomRepo) IsIn(ctx context.Context, classroomId, studentId int) (bo
 }
 
 func (r ClassroomRepo) Students(ctx context.Context, classroomId int) ([]core.UserModel, error) {
-	q := `SELECT u.id, u.full_name, u.phone, u.email, u.password_hash, u.role_id, u.institution_id FROM classroom_students 
+	q := `SELECT u.id, u.full_name, u.phone, u.email, u.password_hash, u.role_id, u.institution_id, u.deactivated_at,
+			u.deleted_at FROM classroom_students 
     	JOIN public.users u on u.id = classroom_students.student_id WHERE classroom_id = $1`
 
 	users := make([]core.UserModel, 0)
@@ -203,6 +366,8 @@ func (r ClassroomRepo) Students(ctx context.Context, classroomId int) ([]core.Us
 			&user.PasswordHash,
 			&user.RoleId,
 			&user.InstitutionId,
+			&user.DeactivatedAt,
+			&user.DeletedAt,
 		)
 		if err != nil {
 			r.logger.Errorf("Query error. %v", err)
@@ -216,10 +381,11 @@ func (r ClassroomRepo) Students(ctx context.Context, classroomId int) ([]core.Us
 }
 
 func (r ClassroomRepo) StudentsByClassroomsId(ctx context.Context, ids []int) ([]core.StudentModel, error) {
-	q := `select u.id, u.full_name, u.phone, u.email, array_agg(c.id) classrooms from classroom_students 
+	q := `select u.id, u.full_name, u.phone, u.email, array_agg(c.id) classrooms, u.deactivated_at, u.deleted_at
+			from classroom_students 
     		join public.users u on u.id = classroom_students.student_id
 			join public.classrooms c on c.id = classroom_students.classroom_id
-			where classroom_id = any ($1) group by u.id, u.full_name, u.phone, u.email;`
+			where classroom_id = any ($1) group by u.id, u.full_name, u.phone, u.email, u.deactivated_at, u.deleted_at;`
 
 	students := make([]core.StudentModel, 0)
 
@@ -240,6 +406,8 @@ func (r ClassroomRepo) StudentsByClassroomsId(ctx context.Context, ids []int) ([
 			&student.Phone,
 			&student.Email,
 			&student.ClassroomsId,
+			&student.DeactivatedAt,
+			&student.DeletedAt,
 		)
 		if err != nil {
 			r.logger.Errorf("Query error. %v", err)
@@ -252,29 +420,222 @@ func (r ClassroomRepo) StudentsByClassroomsId(ctx context.Context, ids []int) ([
 	return students, nil
 }
 
-func (r ClassroomRepo) AddStudent(ctx context.Context, studentId int, classroomsId []int) error {
-	var q strings.Builder
+// OccupiedSeats counts the students of the classroom who take seats in it. Deactivated, deleted and
+// erased students stay enrolled, but their seats are given to the waitlist.
+func (r ClassroomRepo) OccupiedSeats(ctx context.Context, classroomId int) (int, error) {
+	q := `SELECT COUNT(*) FROM classroom_students cs JOIN users u ON u.id = cs.student_id
+			WHERE cs.classroom_id = $1 AND u.deactivated_at IS NULL AND u.deleted_at IS NULL AND u.erased_at IS NULL`
+
+	var occupied int
+
+	if err := r.pool.QueryRow(ctx, q, classroomId).Scan(&occupied); err != nil {
+		r.logger.Errorf("Query error. %v", err)
+		return 0, err
+	}
+
+	return occupied, nil
+}
+
+// EnrollStudents adds the students to the classroom. Students who are already in it are skipped.
+// The number of students is checked by ClassroomService.Enroll.
+func (r ClassroomRepo) EnrollStudents(ctx context.Context, classroomId int, studentsId []int) error {
+	q := `INSERT INTO classroom_students(classroom_id, student_id) SELECT $1, unnest($2::INT[])
+			ON CONFLICT (classroom_id, student_id) DO NOTHING`
+
+	return r.exec(ctx, q, classroomId, studentsId)
+}
+
+func (r ClassroomRepo) RemoveStudents(ctx context.Context, classroomId int, studentsId []int) error {
+	q := `DELETE FROM classroom_students WHERE classroom_id = $1 AND student_id = ANY($2::INT[])`
+
+	return r.exec(ctx, q, classroomId, studentsId)
+}
+
+func (r ClassroomRepo) Waitlist(ctx context.Context, classroomId int) ([]core.WaitlistEntryModel, error) {
+	q := `SELECT w.classroom_id, w.student_id, u.full_name, u.email, w.position, w.created_at
+			FROM classroom_waitlist w JOIN users u ON u.id = w.student_id
+			WHERE w.classroom_id = $1 ORDER BY w.position, w.id`
+
+	rows, err := r.pool.Query(ctx, q, classroomId)
+	if err != nil {
+		r.logger.Errorf("Query error. %v", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	waitlist := make([]core.WaitlistEntryModel, 0)
+
+	for rows.Next() {
+		var entry core.WaitlistEntryModel
+
+		if err := rows.Scan(
+			&entry.ClassroomId,
+			&entry.StudentId,
+			&entry.FullName,
+			&entry.Email,
+			&entry.Position,
+			&entry.CreatedAt,
+		); err != nil {
+			r.logger.Errorf("Query error. %v", err)
+			return nil, err
+		}
+
+		waitlist = append(waitlist, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Errorf("Query error. %v", err)
+		return nil, err
+	}
+
+	return waitlist, nil
+}
+
+// AddToWaitlist puts the students at the end of the waitlist in the given order. Students who are
+// already on it keep their positions.
+func (r ClassroomRepo) AddToWaitlist(ctx context.Context, classroomId int, studentsId []int) error {
+	q := `INSERT INTO classroom_waitlist(classroom_id, student_id, position)
+			SELECT $1, s.id, COALESCE((SELECT max(position) FROM classroom_waitlist WHERE classroom_id = $1), 0) + s.n
+			FROM unnest($2::INT[]) WITH ORDINALITY AS s(id, n)
+			ON CONFLICT (classroom_id, student_id) DO NOTHING`
+
+	return r.exec(ctx, q, classroomId, studentsId)
+}
+
+func (r ClassroomRepo) RemoveFromWaitlist(ctx context.Context, classroomId int, studentsId []int) error {
+	q := `DELETE FROM classroom_waitlist WHERE classroom_id = $1 AND student_id = ANY($2::INT[])`
+
+	return r.exec(ctx, q, classroomId, studentsId)
+}
+
+// ReorderWaitlist numbers the waitlisted students in the given order, starting from one.
+func (r ClassroomRepo) ReorderWaitlist(ctx context.Context, classroomId int, studentsId []int) error {
+	q := `UPDATE classroom_waitlist w SET position = s.n
+			FROM unnest($2::INT[]) WITH ORDINALITY AS s(id, n)
+			WHERE w.classroom_id = $1 AND w.student_id = s.id`
+
+	return r.exec(ctx, q, classroomId, studentsId)
+}
+
+func (r ClassroomRepo) IsStaff(ctx context.Context, classroomId, userId int) (bool, error) {
+	q := `SELECT EXISTS(SELECT * FROM classroom_staff WHERE classroom_id = $1 AND user_id = $2)`
+
+	var isStaff bool
+
+	if err := r.pool.QueryRow(ctx, q, classroomId, userId).Scan(&isStaff); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return false, err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return false, err
+	}
+
+	return isStaff, nil
+}
+
+func (r ClassroomRepo) Staff(ctx context.Context, classroomId int) ([]core.ClassroomStaffModel, error) {
+	q := `SELECT cs.classroom_id, cs.user_id, u.full_name, u.email, cs.role FROM classroom_staff cs
+			JOIN users u ON u.id = cs.user_id WHERE cs.classroom_id = $1 ORDER BY cs.id`
+
+	rows, err := r.pool.Query(ctx, q, classroomId)
+	if err != nil {
+		r.logger.Errorf("Query error. %v", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	staff := make([]core.ClassroomStaffModel, 0)
+
+	for rows.Next() {
+		var member core.ClassroomStaffModel
+
+		if err := rows.Scan(
+			&member.ClassroomId,
+			&member.UserId,
+			&member.FullName,
+			&member.Email,
+			&member.Role,
+		); err != nil {
+			r.logger.Errorf("Query error. %v", err)
+			return nil, err
+		}
+
+		staff = append(staff, member)
+	}
 
-	q.WriteString("INSERT INTO classroom_students(classroom_id, student_id) VALUES ")
+	if err := rows.Err(); err != nil {
+		r.logger.Errorf("Query error. %v", err)
+		return nil, err
+	}
 
-	data := make([]interface{}, 0, len(classroomsId)*2)
+	return staff, nil
+}
+
+// AddStaff adds the user to the staff of the classroom, or changes the role the user has there.
+func (r ClassroomRepo) AddStaff(ctx context.Context, classroomId, userId int, role string) error {
+	q := `INSERT INTO classroom_staff(classroom_id, user_id, role) VALUES ($1, $2, $3)
+			ON CONFLICT (classroom_id, user_id) DO UPDATE SET role = excluded.role`
 
-	var argNum int
+	return r.exec(ctx, q, classroomId, userId, role)
+}
 
-	for i, id := range classroomsId {
-		q.WriteString(fmt.Sprintf("($%d, $%d)", argNum+1, argNum+2))
+func (r ClassroomRepo) RemoveStaff(ctx context.Context, classroomId, userId int) error {
+	q := `DELETE FROM classroom_staff WHERE classroom_id = $1 AND user_id = $2`
 
-		argNum += 2
+	return r.exec(ctx, q, classroomId, userId)
+}
 
-		if i != len(classroomsId)-1 {
-			q.WriteString(",")
+// SetOwner makes the teacher the owner of the classroom. It has to run in a transaction, the previous
+// owner is left on the staff as a co-teacher.
+func (r ClassroomRepo) SetOwner(ctx context.Context, classroomId, teacherId int) error {
+	if err := r.exec(
+		ctx,
+		`UPDATE classroom_staff SET role = 'co_teacher' WHERE classroom_id = $1 AND role = 'owner'`,
+		classroomId,
+	); err != nil {
+		return err
+	}
+
+	if err := r.AddStaff(ctx, classroomId, teacherId, string(core.StaffOwner)); err != nil {
+		return err
+	}
+
+	return r.exec(ctx, `UPDATE classrooms SET teacher_id = $2 WHERE id = $1`, classroomId, teacherId)
+}
+
+func (r ClassroomRepo) scan(row pgx.Row) (core.ClassroomModel, error) {
+	var classroom core.ClassroomModel
+
+	if err := row.Scan(
+		&classroom.Id,
+		&classroom.Title,
+		&classroom.Description,
+		&classroom.TeacherId,
+		&classroom.MaxStudents,
+		&classroom.ArchivedAt,
+	); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return core.ClassroomModel{}, apperrors.EntityNotFound
+			}
+
+			r.logger.Errorf("Error: %v", err)
+			return core.ClassroomModel{}, err
 		}
 
-		data = append(data, id)
-		data = append(data, studentId)
+		r.logger.Errorf("Query error. %v", err)
+		return core.ClassroomModel{}, err
 	}
 
-	if _, err := r.pool.Exec(ctx, q.String(), data...); err != nil {
+	return classroom, nil
+}
+
+func (r ClassroomRepo) exec(ctx context.Context, q string, args ...interface{}) error {
+	if _, err := r.pool.Exec(ctx, q, args...); err != nil {
 		if err := utils.ParsePgError(err); err != nil {
 			r.logger.Errorf("Error: %v", err)
 			return err
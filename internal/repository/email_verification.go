@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/internal/repository/psql"
+	"github.com/migmatore/study-platform-api/pkg/logger"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+)
+
+type EmailVerificationRepo struct {
+	logger logger.Logger
+	pool   psql.AtomicPoolClient
+}
+
+func NewEmailVerificationRepo(logger logger.Logger, pool psql.AtomicPoolClient) *EmailVerificationRepo {
+	return &EmailVerificationRepo{logger: logger, pool: pool}
+}
+
+func (r EmailVerificationRepo) Create(ctx context.Context, token core.EmailVerificationTokenModel) error {
+	q := `INSERT INTO email_verification_tokens(user_id, email, token_hash, expires_at) VALUES ($1, $2, $3, $4)`
+
+	if _, err := r.pool.Exec(ctx, q, token.UserId, token.Email, token.TokenHash, token.ExpiresAt); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r EmailVerificationRepo) ByHashForUpdate(
+	ctx context.Context,
+	hash string,
+) (core.EmailVerificationTokenModel, error) {
+	q := `SELECT id, user_id, email, token_hash, expires_at, used_at FROM email_verification_tokens 
+			WHERE token_hash = $1 FOR UPDATE`
+
+	var t core.EmailVerificationTokenModel
+
+	if err := r.pool.QueryRow(ctx, q, hash).Scan(
+		&t.Id,
+		&t.UserId,
+		&t.Email,
+		&t.TokenHash,
+		&t.ExpiresAt,
+		&t.UsedAt,
+	); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return core.EmailVerificationTokenModel{}, apperrors.EntityNotFound
+			}
+
+			r.logger.Errorf("Error: %v", err)
+			return core.EmailVerificationTokenModel{}, err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return core.EmailVerificationTokenModel{}, err
+	}
+
+	return t, nil
+}
+
+func (r EmailVerificationRepo) MarkUsed(ctx context.Context, id int) error {
+	q := `UPDATE email_verification_tokens SET used_at = now() WHERE id = $1`
+
+	if _, err := r.pool.Exec(ctx, q, id); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// InvalidateByUserId marks every unused token of the user as used.
+func (r EmailVerificationRepo) InvalidateByUserId(ctx context.Context, userId int) error {
+	q := `UPDATE email_verification_tokens SET used_at = now() WHERE user_id = $1 AND used_at IS NULL`
+
+	if _, err := r.pool.Exec(ctx, q, userId); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,343 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/internal/repository/psql"
+	"github.com/migmatore/study-platform-api/pkg/logger"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+	"time"
+)
+
+const exportJobColumns = `id, institution_id, requested_by, status, progress, error, file_name, expires_at, created_at,
+			finished_at`
+
+type ExportRepo struct {
+	logger logger.Logger
+	pool   psql.AtomicPoolClient
+}
+
+func NewExportRepo(logger logger.Logger, pool psql.AtomicPoolClient) *ExportRepo {
+	return &ExportRepo{logger: logger, pool: pool}
+}
+
+func (r ExportRepo) Create(ctx context.Context, institutionId, requestedBy int) (core.ExportJobModel, error) {
+	q := `INSERT INTO export_jobs(institution_id, requested_by) VALUES ($1, $2) RETURNING ` + exportJobColumns
+
+	return r.scan(r.pool.QueryRow(ctx, q, institutionId, requestedBy))
+}
+
+func (r ExportRepo) ById(ctx context.Context, id int) (core.ExportJobModel, error) {
+	q := `SELECT ` + exportJobColumns + ` FROM export_jobs WHERE id = $1`
+
+	return r.scan(r.pool.QueryRow(ctx, q, id))
+}
+
+func (r ExportRepo) ByTokenHash(ctx context.Context, hash string) (core.ExportJobModel, error) {
+	q := `SELECT ` + exportJobColumns + ` FROM export_jobs WHERE token_hash = $1`
+
+	return r.scan(r.pool.QueryRow(ctx, q, hash))
+}
+
+func (r ExportRepo) ByInstitutionId(ctx context.Context, institutionId int) ([]core.ExportJobModel, error) {
+	q := `SELECT ` + exportJobColumns + ` FROM export_jobs WHERE institution_id = $1 ORDER BY id DESC`
+
+	jobs := make([]core.ExportJobModel, 0)
+
+	if err := r.collect(ctx, q, func(rows pgx.Rows) error {
+		job, err := r.scan(rows)
+		if err != nil {
+			return err
+		}
+
+		jobs = append(jobs, job)
+
+		return nil
+	}, institutionId); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// InProgress reports whether the institution has an export which is not finished and was started
+// after the time. Older ones were interrupted by a restart and are not waited for.
+func (r ExportRepo) InProgress(ctx context.Context, institutionId int, startedAfter time.Time) (bool, error) {
+	q := `SELECT EXISTS(SELECT * FROM export_jobs WHERE institution_id = $1 AND status IN ('pending', 'running')
+			AND created_at > $2)`
+
+	var inProgress bool
+
+	if err := r.pool.QueryRow(ctx, q, institutionId, startedAfter).Scan(&inProgress); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return false, err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return false, err
+	}
+
+	return inProgress, nil
+}
+
+func (r ExportRepo) SetProgress(ctx context.Context, id int, status string, progress int) error {
+	q := `UPDATE export_jobs SET status = $2, progress = $3 WHERE id = $1`
+
+	return r.exec(ctx, q, id, status, progress)
+}
+
+func (r ExportRepo) Finish(ctx context.Context, id int, fileName string, expiresAt time.Time) error {
+	q := `UPDATE export_jobs SET status = 'done', progress = 100, file_name = $2, expires_at = $3, finished_at = now()
+			WHERE id = $1`
+
+	return r.exec(ctx, q, id, fileName, expiresAt)
+}
+
+func (r ExportRepo) Fail(ctx context.Context, id int, message string) error {
+	q := `UPDATE export_jobs SET status = 'failed', error = $2, finished_at = now() WHERE id = $1`
+
+	return r.exec(ctx, q, id, message)
+}
+
+// SetTokenHash replaces the download token of the export, links issued before stop working.
+func (r ExportRepo) SetTokenHash(ctx context.Context, id int, hash string) error {
+	q := `UPDATE export_jobs SET token_hash = $2 WHERE id = $1`
+
+	return r.exec(ctx, q, id, hash)
+}
+
+// Expired returns the exports which still have a file, but expired before the time.
+func (r ExportRepo) Expired(ctx context.Context, before time.Time) ([]core.ExportJobModel, error) {
+	q := `SELECT ` + exportJobColumns + ` FROM export_jobs WHERE file_name IS NOT NULL AND expires_at < $1`
+
+	jobs := make([]core.ExportJobModel, 0)
+
+	if err := r.collect(ctx, q, func(rows pgx.Rows) error {
+		job, err := r.scan(rows)
+		if err != nil {
+			return err
+		}
+
+		jobs = append(jobs, job)
+
+		return nil
+	}, before); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// ClearFile forgets the file of the export after it was deleted.
+func (r ExportRepo) ClearFile(ctx context.Context, id int) error {
+	q := `UPDATE export_jobs SET file_name = NULL, token_hash = NULL WHERE id = $1`
+
+	return r.exec(ctx, q, id)
+}
+
+func (r ExportRepo) Users(ctx context.Context, institutionId int) ([]core.ExportUserRecord, error) {
+	q := `SELECT u.id, u.full_name, u.email, u.phone, r.name, u.email_verified FROM users u
+			JOIN roles r ON r.id = u.role_id WHERE u.institution_id = $1 ORDER BY u.id`
+
+	users := make([]core.ExportUserRecord, 0)
+
+	if err := r.collect(ctx, q, func(rows pgx.Rows) error {
+		var user core.ExportUserRecord
+
+		if err := rows.Scan(
+			&user.Id,
+			&user.FullName,
+			&user.Email,
+			&user.Phone,
+			&user.Role,
+			&user.EmailVerified,
+		); err != nil {
+			return err
+		}
+
+		users = append(users, user)
+
+		return nil
+	}, institutionId); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// Classrooms returns the classrooms owned by the teachers of the institution, archived ones included.
+func (r ExportRepo) Classrooms(ctx context.Context, institutionId int) ([]core.ExportClassroomRecord, error) {
+	q := `SELECT c.id, c.title, c.description, c.teacher_id, c.max_students, c.archived_at FROM classrooms c
+			JOIN users u ON u.id = c.teacher_id WHERE u.institution_id = $1 ORDER BY c.id`
+
+	classrooms := make([]core.ExportClassroomRecord, 0)
+
+	if err := r.collect(ctx, q, func(rows pgx.Rows) error {
+		var classroom core.ExportClassroomRecord
+
+		if err := rows.Scan(
+			&classroom.Id,
+			&classroom.Title,
+			&classroom.Description,
+			&classroom.TeacherId,
+			&classroom.MaxStudents,
+			&classroom.ArchivedAt,
+		); err != nil {
+			return err
+		}
+
+		classrooms = append(classrooms, classroom)
+
+		return nil
+	}, institutionId); err != nil {
+		return nil, err
+	}
+
+	return classrooms, nil
+}
+
+func (r ExportRepo) Staff(ctx context.Context, institutionId int) ([]core.ExportStaffRecord, error) {
+	q := `SELECT cs.classroom_id, cs.user_id, cs.role FROM classroom_staff cs
+			JOIN classrooms c ON c.id = cs.classroom_id JOIN users u ON u.id = c.teacher_id
+			WHERE u.institution_id = $1 ORDER BY cs.classroom_id, cs.id`
+
+	staff := make([]core.ExportStaffRecord, 0)
+
+	if err := r.collect(ctx, q, func(rows pgx.Rows) error {
+		var member core.ExportStaffRecord
+
+		if err := rows.Scan(&member.ClassroomId, &member.UserId, &member.Role); err != nil {
+			return err
+		}
+
+		staff = append(staff, member)
+
+		return nil
+	}, institutionId); err != nil {
+		return nil, err
+	}
+
+	return staff, nil
+}
+
+func (r ExportRepo) Enrollments(ctx context.Context, institutionId int) ([]core.ExportEnrollmentRecord, error) {
+	q := `SELECT cs.classroom_id, cs.student_id FROM classroom_students cs
+			JOIN classrooms c ON c.id = cs.classroom_id JOIN users u ON u.id = c.teacher_id
+			WHERE u.institution_id = $1 ORDER BY cs.classroom_id, cs.id`
+
+	enrollments := make([]core.ExportEnrollmentRecord, 0)
+
+	if err := r.collect(ctx, q, func(rows pgx.Rows) error {
+		var enrollment core.ExportEnrollmentRecord
+
+		if err := rows.Scan(&enrollment.ClassroomId, &enrollment.StudentId); err != nil {
+			return err
+		}
+
+		enrollments = append(enrollments, enrollment)
+
+		return nil
+	}, institutionId); err != nil {
+		return nil, err
+	}
+
+	return enrollments, nil
+}
+
+func (r ExportRepo) Lessons(ctx context.Context, institutionId int) ([]core.ExportLessonRecord, error) {
+	q := `SELECT l.id, l.classroom_id, l.title, COALESCE(l.active, FALSE), l.content FROM lessons l
+			JOIN classrooms c ON c.id = l.classroom_id JOIN users u ON u.id = c.teacher_id
+			WHERE u.institution_id = $1 ORDER BY l.classroom_id, l.id`
+
+	lessons := make([]core.ExportLessonRecord, 0)
+
+	if err := r.collect(ctx, q, func(rows pgx.Rows) error {
+		var lesson core.ExportLessonRecord
+
+		if err := rows.Scan(&lesson.Id, &lesson.ClassroomId, &lesson.Title, &lesson.Active, &lesson.Content); err != nil {
+			return err
+		}
+
+		lessons = append(lessons, lesson)
+
+		return nil
+	}, institutionId); err != nil {
+		return nil, err
+	}
+
+	return lessons, nil
+}
+
+func (r ExportRepo) scan(row pgx.Row) (core.ExportJobModel, error) {
+	var job core.ExportJobModel
+
+	if err := row.Scan(
+		&job.Id,
+		&job.InstitutionId,
+		&job.RequestedBy,
+		&job.Status,
+		&job.Progress,
+		&job.Error,
+		&job.FileName,
+		&job.ExpiresAt,
+		&job.CreatedAt,
+		&job.FinishedAt,
+	); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return core.ExportJobModel{}, apperrors.EntityNotFound
+			}
+
+			r.logger.Errorf("Error: %v", err)
+			return core.ExportJobModel{}, err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return core.ExportJobModel{}, err
+	}
+
+	return job, nil
+}
+
+// collect runs the query and passes every row to the function.
+func (r ExportRepo) collect(ctx context.Context, q string, scan func(rows pgx.Rows) error, args ...interface{}) error {
+	rows, err := r.pool.Query(ctx, q, args...)
+	if err != nil {
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			r.logger.Errorf("Query error. %v", err)
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r ExportRepo) exec(ctx context.Context, q string, args ...interface{}) error {
+	if _, err := r.pool.Exec(ctx, q, args...); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/internal/repository/psql"
+	"github.com/migmatore/study-platform-api/pkg/logger"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+)
+
+type ImpersonationRepo struct {
+	logger logger.Logger
+	pool   psql.AtomicPoolClient
+}
+
+func NewImpersonationRepo(logger logger.Logger, pool psql.AtomicPoolClient) *ImpersonationRepo {
+	return &ImpersonationRepo{logger: logger, pool: pool}
+}
+
+func (r ImpersonationRepo) Create(ctx context.Context, event core.ImpersonationEventModel) error {
+	q := `INSERT INTO impersonation_audit(admin_id, user_id, event, method, path, ip, user_agent)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	if _, err := r.pool.Exec(
+		ctx,
+		q,
+		event.AdminId,
+		event.UserId,
+		event.Event,
+		event.Method,
+		event.Path,
+		event.IP,
+		event.UserAgent,
+	); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
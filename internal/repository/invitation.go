@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/internal/repository/psql"
+	"github.com/migmatore/study-platform-api/pkg/logger"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+)
+
+type InvitationRepo struct {
+	logger logger.Logger
+	pool   psql.AtomicPoolClient
+}
+
+func NewInvitationRepo(logger logger.Logger, pool psql.AtomicPoolClient) *InvitationRepo {
+	return &InvitationRepo{logger: logger, pool: pool}
+}
+
+func (r InvitationRepo) Create(ctx context.Context, inv core.InvitationModel) (core.InvitationModel, error) {
+	q := `INSERT INTO invitations(token_hash, classroom_id, created_by, email, max_uses, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id, token_hash, classroom_id, created_by, email, max_uses, uses, expires_at, revoked_at, created_at`
+
+	return r.scan(r.pool.QueryRow(
+		ctx,
+		q,
+		inv.TokenHash,
+		inv.ClassroomId,
+		inv.CreatedBy,
+		inv.Email,
+		inv.MaxUses,
+		inv.ExpiresAt,
+	))
+}
+
+func (r InvitationRepo) ById(ctx context.Context, id int) (core.InvitationModel, error) {
+	q := `SELECT id, token_hash, classroom_id, created_by, email, max_uses, uses, expires_at, revoked_at, created_at
+			FROM invitations WHERE id = $1`
+
+	return r.scan(r.pool.QueryRow(ctx, q, id))
+}
+
+func (r InvitationRepo) ByHashForUpdate(ctx context.Context, hash string) (core.InvitationModel, error) {
+	q := `SELECT id, token_hash, classroom_id, created_by, email, max_uses, uses, expires_at, revoked_at, created_at
+			FROM invitations WHERE token_hash = $1 FOR UPDATE`
+
+	return r.scan(r.pool.QueryRow(ctx, q, hash))
+}
+
+// ActiveByClassroomId returns the invitations of the classroom that can still be used.
+func (r InvitationRepo) ActiveByClassroomId(ctx context.Context, classroomId int) ([]core.InvitationModel, error) {
+	q := `SELECT id, token_hash, classroom_id, created_by, email, max_uses, uses, expires_at, revoked_at, created_at
+			FROM invitations
+			WHERE classroom_id = $1 AND revoked_at IS NULL AND expires_at > now()
+			  AND (max_uses IS NULL OR uses < max_uses)
+			ORDER BY created_at DESC`
+
+	rows, err := r.pool.Query(ctx, q, classroomId)
+	if err != nil {
+		r.logger.Errorf("Query error. %v", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	invitations := make([]core.InvitationModel, 0)
+
+	for rows.Next() {
+		inv, err := r.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		invitations = append(invitations, inv)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Errorf("Query error. %v", err)
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+func (r InvitationRepo) IncrementUses(ctx context.Context, id int) error {
+	q := `UPDATE invitations SET uses = uses + 1 WHERE id = $1`
+
+	return r.exec(ctx, q, id)
+}
+
+func (r InvitationRepo) Revoke(ctx context.Context, id int) error {
+	q := `UPDATE invitations SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`
+
+	return r.exec(ctx, q, id)
+}
+
+func (r InvitationRepo) scan(row pgx.Row) (core.InvitationModel, error) {
+	var inv core.InvitationModel
+
+	if err := row.Scan(
+		&inv.Id,
+		&inv.TokenHash,
+		&inv.ClassroomId,
+		&inv.CreatedBy,
+		&inv.Email,
+		&inv.MaxUses,
+		&inv.Uses,
+		&inv.ExpiresAt,
+		&inv.RevokedAt,
+		&inv.CreatedAt,
+	); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return core.InvitationModel{}, apperrors.EntityNotFound
+			}
+
+			r.logger.Errorf("Error: %v", err)
+			return core.InvitationModel{}, err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return core.InvitationModel{}, err
+	}
+
+	return inv, nil
+}
+
+func (r InvitationRepo) exec(ctx context.Context, q string, args ...interface{}) error {
+	if _, err := r.pool.Exec(ctx, q, args...); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
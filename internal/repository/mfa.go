@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/internal/repository/psql"
+	"github.com/migmatore/study-platform-api/pkg/logger"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+)
+
+type MFARepo struct {
+	logger logger.Logger
+	pool   psql.AtomicPoolClient
+}
+
+func NewMFARepo(logger logger.Logger, pool psql.AtomicPoolClient) *MFARepo {
+	return &MFARepo{logger: logger, pool: pool}
+}
+
+func (r MFARepo) ByUserId(ctx context.Context, userId int) (core.MFAModel, error) {
+	q := `SELECT user_id, secret, confirmed_at, last_used_step FROM user_mfa WHERE user_id = $1`
+
+	return r.scan(r.pool.QueryRow(ctx, q, userId))
+}
+
+func (r MFARepo) ByUserIdForUpdate(ctx context.Context, userId int) (core.MFAModel, error) {
+	q := `SELECT user_id, secret, confirmed_at, last_used_step FROM user_mfa WHERE user_id = $1 FOR UPDATE`
+
+	return r.scan(r.pool.QueryRow(ctx, q, userId))
+}
+
+func (r MFARepo) scan(row pgx.Row) (core.MFAModel, error) {
+	var m core.MFAModel
+
+	if err := row.Scan(&m.UserId, &m.Secret, &m.ConfirmedAt, &m.LastUsedStep); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return core.MFAModel{}, apperrors.EntityNotFound
+			}
+
+			r.logger.Errorf("Error: %v", err)
+			return core.MFAModel{}, err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return core.MFAModel{}, err
+	}
+
+	return m, nil
+}
+
+// Upsert stores a new unconfirmed secret for the user, replacing a previous unfinished enrollment.
+func (r MFARepo) Upsert(ctx context.Context, userId int, secret string) error {
+	q := `INSERT INTO user_mfa(user_id, secret) VALUES ($1, $2)
+			ON CONFLICT (user_id) DO UPDATE
+			SET secret = excluded.secret, confirmed_at = NULL, last_used_step = 0, created_at = now()`
+
+	return r.exec(ctx, q, userId, secret)
+}
+
+func (r MFARepo) Confirm(ctx context.Context, userId int, step int64) error {
+	q := `UPDATE user_mfa SET confirmed_at = now(), last_used_step = $2 WHERE user_id = $1`
+
+	return r.exec(ctx, q, userId, step)
+}
+
+func (r MFARepo) SetLastUsedStep(ctx context.Context, userId int, step int64) error {
+	q := `UPDATE user_mfa SET last_used_step = $2 WHERE user_id = $1`
+
+	return r.exec(ctx, q, userId, step)
+}
+
+// Delete removes the secret together with the recovery codes of the user.
+func (r MFARepo) Delete(ctx context.Context, userId int) error {
+	q := `WITH codes AS (DELETE FROM mfa_recovery_codes WHERE user_id = $1)
+			DELETE FROM user_mfa WHERE user_id = $1`
+
+	return r.exec(ctx, q, userId)
+}
+
+// ReplaceRecoveryCodes drops the previous recovery codes of the user and stores the new ones.
+func (r MFARepo) ReplaceRecoveryCodes(ctx context.Context, userId int, hashes []string) error {
+	q := `WITH codes AS (DELETE FROM mfa_recovery_codes WHERE user_id = $1)
+			INSERT INTO mfa_recovery_codes(user_id, code_hash) SELECT $1, unnest($2::varchar[])`
+
+	return r.exec(ctx, q, userId, hashes)
+}
+
+// UseRecoveryCode marks the code as used. It reports false if there is no such unused code.
+func (r MFARepo) UseRecoveryCode(ctx context.Context, userId int, hash string) (bool, error) {
+	q := `UPDATE mfa_recovery_codes SET used_at = now() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	tag, err := r.pool.Exec(ctx, q, userId, hash)
+	if err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return false, err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r MFARepo) CreateChallenge(ctx context.Context, challenge core.MFAChallengeModel) error {
+	q := `INSERT INTO mfa_challenges(user_id, token_hash, expires_at) VALUES ($1, $2, $3)`
+
+	return r.exec(ctx, q, challenge.UserId, challenge.TokenHash, challenge.ExpiresAt)
+}
+
+func (r MFARepo) ChallengeByHashForUpdate(ctx context.Context, hash string) (core.MFAChallengeModel, error) {
+	q := `SELECT id, user_id, token_hash, attempts, expires_at, used_at FROM mfa_challenges
+			WHERE token_hash = $1 FOR UPDATE`
+
+	var c core.MFAChallengeModel
+
+	if err := r.pool.QueryRow(ctx, q, hash).Scan(
+		&c.Id,
+		&c.UserId,
+		&c.TokenHash,
+		&c.Attempts,
+		&c.ExpiresAt,
+		&c.UsedAt,
+	); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return core.MFAChallengeModel{}, apperrors.EntityNotFound
+			}
+
+			r.logger.Errorf("Error: %v", err)
+			return core.MFAChallengeModel{}, err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return core.MFAChallengeModel{}, err
+	}
+
+	return c, nil
+}
+
+func (r MFARepo) IncrementChallengeAttempts(ctx context.Context, id int) error {
+	q := `UPDATE mfa_challenges SET attempts = attempts + 1 WHERE id = $1`
+
+	return r.exec(ctx, q, id)
+}
+
+func (r MFARepo) MarkChallengeUsed(ctx context.Context, id int) error {
+	q := `UPDATE mfa_challenges SET used_at = now() WHERE id = $1`
+
+	return r.exec(ctx, q, id)
+}
+
+func (r MFARepo) exec(ctx context.Context, q string, args ...interface{}) error {
+	if _, err := r.pool.Exec(ctx, q, args...); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/internal/repository/psql"
+	"github.com/migmatore/study-platform-api/pkg/logger"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+)
+
+type OIDCRepo struct {
+	logger logger.Logger
+	pool   psql.AtomicPoolClient
+}
+
+func NewOIDCRepo(logger logger.Logger, pool psql.AtomicPoolClient) *OIDCRepo {
+	return &OIDCRepo{logger: logger, pool: pool}
+}
+
+func (r OIDCRepo) ProviderByInstitutionId(ctx context.Context, institutionId int) (core.OIDCProviderModel, error) {
+	q := `SELECT institution_id, issuer, client_id, client_secret, scopes, role_claim, role_mapping,
+       		default_role, enabled
+			FROM institution_oidc_providers WHERE institution_id = $1`
+
+	var p core.OIDCProviderModel
+
+	if err := r.pool.QueryRow(ctx, q, institutionId).Scan(
+		&p.InstitutionId,
+		&p.Issuer,
+		&p.ClientId,
+		&p.ClientSecret,
+		&p.Scopes,
+		&p.RoleClaim,
+		&p.RoleMapping,
+		&p.DefaultRole,
+		&p.Enabled,
+	); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return core.OIDCProviderModel{}, apperrors.EntityNotFound
+			}
+
+			r.logger.Errorf("Error: %v", err)
+			return core.OIDCProviderModel{}, err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return core.OIDCProviderModel{}, err
+	}
+
+	return p, nil
+}
+
+func (r OIDCRepo) SaveProvider(ctx context.Context, p core.OIDCProviderModel) error {
+	q := `INSERT INTO institution_oidc_providers(institution_id, issuer, client_id, client_secret, scopes,
+                                       role_claim, role_mapping, default_role, enabled)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT (institution_id) DO UPDATE
+			SET issuer = excluded.issuer,
+			    client_id = excluded.client_id,
+			    client_secret = excluded.client_secret,
+			    scopes = excluded.scopes,
+			    role_claim = excluded.role_claim,
+			    role_mapping = excluded.role_mapping,
+			    default_role = excluded.default_role,
+			    enabled = excluded.enabled,
+			    updated_at = now()`
+
+	if _, err := r.pool.Exec(
+		ctx,
+		q,
+		p.InstitutionId,
+		p.Issuer,
+		p.ClientId,
+		p.ClientSecret,
+		p.Scopes,
+		p.RoleClaim,
+		p.RoleMapping,
+		p.DefaultRole,
+		p.Enabled,
+	); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r OIDCRepo) CreateState(ctx context.Context, state core.OIDCStateModel) error {
+	q := `INSERT INTO oidc_states(state_hash, institution_id, nonce, code_verifier, expires_at)
+			VALUES ($1, $2, $3, $4, $5)`
+
+	if _, err := r.pool.Exec(
+		ctx,
+		q,
+		state.StateHash,
+		state.InstitutionId,
+		state.Nonce,
+		state.CodeVerifier,
+		state.ExpiresAt,
+	); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// UseState marks the unused state as used and returns it, so a state can not be replayed.
+func (r OIDCRepo) UseState(ctx context.Context, hash string) (core.OIDCStateModel, error) {
+	q := `UPDATE oidc_states SET used_at = now()
+			WHERE state_hash = $1 AND used_at IS NULL
+			RETURNING id, state_hash, institution_id, nonce, code_verifier, expires_at, used_at`
+
+	var s core.OIDCStateModel
+
+	if err := r.pool.QueryRow(ctx, q, hash).Scan(
+		&s.Id,
+		&s.StateHash,
+		&s.InstitutionId,
+		&s.Nonce,
+		&s.CodeVerifier,
+		&s.ExpiresAt,
+		&s.UsedAt,
+	); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return core.OIDCStateModel{}, apperrors.EntityNotFound
+			}
+
+			r.logger.Errorf("Error: %v", err)
+			return core.OIDCStateModel{}, err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return core.OIDCStateModel{}, err
+	}
+
+	return s, nil
+}
+
+func (r OIDCRepo) UserIdByIdentity(ctx context.Context, issuer string, subject string) (int, error) {
+	q := `SELECT user_id FROM user_identities WHERE issuer = $1 AND subject = $2`
+
+	var userId int
+
+	if err := r.pool.QueryRow(ctx, q, issuer, subject).Scan(&userId); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return 0, apperrors.EntityNotFound
+			}
+
+			r.logger.Errorf("Error: %v", err)
+			return 0, err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return 0, err
+	}
+
+	return userId, nil
+}
+
+func (r OIDCRepo) LinkIdentity(ctx context.Context, userId int, issuer string, subject string) error {
+	q := `INSERT INTO user_identities(user_id, issuer, subject) VALUES ($1, $2, $3)`
+
+	if _, err := r.pool.Exec(ctx, q, userId, issuer, subject); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/internal/repository/psql"
+	"github.com/migmatore/study-platform-api/pkg/logger"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+)
+
+type PasswordResetRepo struct {
+	logger logger.Logger
+	pool   psql.AtomicPoolClient
+}
+
+func NewPasswordResetRepo(logger logger.Logger, pool psql.AtomicPoolClient) *PasswordResetRepo {
+	return &PasswordResetRepo{logger: logger, pool: pool}
+}
+
+func (r PasswordResetRepo) Create(ctx context.Context, token core.PasswordResetTokenModel) error {
+	q := `INSERT INTO password_reset_tokens(user_id, token_hash, expires_at) VALUES ($1, $2, $3)`
+
+	if _, err := r.pool.Exec(ctx, q, token.UserId, token.TokenHash, token.ExpiresAt); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r PasswordResetRepo) ByHashForUpdate(ctx context.Context, hash string) (core.PasswordResetTokenModel, error) {
+	q := `SELECT id, user_id, token_hash, expires_at, used_at FROM password_reset_tokens 
+			WHERE token_hash = $1 FOR UPDATE`
+
+	var t core.PasswordResetTokenModel
+
+	if err := r.pool.QueryRow(ctx, q, hash).Scan(
+		&t.Id,
+		&t.UserId,
+		&t.TokenHash,
+		&t.ExpiresAt,
+		&t.UsedAt,
+	); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return core.PasswordResetTokenModel{}, apperrors.EntityNotFound
+			}
+
+			r.logger.Errorf("Error: %v", err)
+			return core.PasswordResetTokenModel{}, err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return core.PasswordResetTokenModel{}, err
+	}
+
+	return t, nil
+}
+
+func (r PasswordResetRepo) MarkUsed(ctx context.Context, id int) error {
+	q := `UPDATE password_reset_tokens SET used_at = now() WHERE id = $1`
+
+	if _, err := r.pool.Exec(ctx, q, id); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// InvalidateByUserId marks every unused token of the user as used.
+func (r PasswordResetRepo) InvalidateByUserId(ctx context.Context, userId int) error {
+	q := `UPDATE password_reset_tokens SET used_at = now() WHERE user_id = $1 AND used_at IS NULL`
+
+	if _, err := r.pool.Exec(ctx, q, userId); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
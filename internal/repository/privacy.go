@@ -0,0 +1,353 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/internal/repository/psql"
+	"github.com/migmatore/study-platform-api/pkg/logger"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+)
+
+const erasureRequestColumns = `e.id, e.user_id, u.full_name, u.email, e.requested_by, e.reason, e.status, e.reviewed_by,
+			e.reviewed_at, e.created_at`
+
+type PrivacyRepo struct {
+	logger logger.Logger
+	pool   psql.AtomicPoolClient
+}
+
+func NewPrivacyRepo(logger logger.Logger, pool psql.AtomicPoolClient) *PrivacyRepo {
+	return &PrivacyRepo{logger: logger, pool: pool}
+}
+
+func (r PrivacyRepo) CreateErasure(
+	ctx context.Context,
+	userId int,
+	requestedBy int,
+	reason *string,
+) (core.ErasureRequestModel, error) {
+	q := `WITH e AS (
+				INSERT INTO erasure_requests(user_id, requested_by, reason) VALUES ($1, $2, $3) RETURNING *
+			)
+			SELECT ` + erasureRequestColumns + ` FROM e JOIN users u ON u.id = e.user_id`
+
+	return r.scanErasure(r.pool.QueryRow(ctx, q, userId, requestedBy, reason))
+}
+
+func (r PrivacyRepo) ErasureById(ctx context.Context, id int) (core.ErasureRequestModel, error) {
+	q := `SELECT ` + erasureRequestColumns + ` FROM erasure_requests e JOIN users u ON u.id = e.user_id
+			WHERE e.id = $1`
+
+	return r.scanErasure(r.pool.QueryRow(ctx, q, id))
+}
+
+// ErasureByIdForUpdate locks the request until the end of the transaction, so it is reviewed once.
+func (r PrivacyRepo) ErasureByIdForUpdate(ctx context.Context, id int) (core.ErasureRequestModel, error) {
+	q := `SELECT ` + erasureRequestColumns + ` FROM erasure_requests e JOIN users u ON u.id = e.user_id
+			WHERE e.id = $1 FOR UPDATE OF e`
+
+	return r.scanErasure(r.pool.QueryRow(ctx, q, id))
+}
+
+func (r PrivacyRepo) HasPendingErasure(ctx context.Context, userId int) (bool, error) {
+	q := `SELECT EXISTS(SELECT * FROM erasure_requests WHERE user_id = $1 AND status = 'pending')`
+
+	var exists bool
+
+	if err := r.pool.QueryRow(ctx, q, userId).Scan(&exists); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return false, err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func (r PrivacyRepo) IsErased(ctx context.Context, userId int) (bool, error) {
+	q := `SELECT erased_at IS NOT NULL FROM users WHERE id = $1`
+
+	var erased bool
+
+	if err := r.pool.QueryRow(ctx, q, userId).Scan(&erased); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return false, apperrors.EntityNotFound
+			}
+
+			r.logger.Errorf("Error: %v", err)
+			return false, err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return false, err
+	}
+
+	return erased, nil
+}
+
+func (r PrivacyRepo) ErasuresByInstitutionId(ctx context.Context, institutionId int) ([]core.ErasureRequestModel, error) {
+	q := `SELECT ` + erasureRequestColumns + ` FROM erasure_requests e JOIN users u ON u.id = e.user_id
+			WHERE u.institution_id = $1 ORDER BY e.id DESC`
+
+	requests := make([]core.ErasureRequestModel, 0)
+
+	if err := r.collect(ctx, q, func(rows pgx.Rows) error {
+		request, err := r.scanErasure(rows)
+		if err != nil {
+			return err
+		}
+
+		requests = append(requests, request)
+
+		return nil
+	}, institutionId); err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+func (r PrivacyRepo) ReviewErasure(ctx context.Context, id int, status string, reviewedBy int) error {
+	q := `UPDATE erasure_requests SET status = $2, reviewed_by = $3, reviewed_at = now() WHERE id = $1`
+
+	return r.exec(ctx, q, id, status, reviewedBy)
+}
+
+// Anonymize replaces the personal fields of the user and removes everything used to sign in as the
+// user. Enrollments, classroom roles and the content the user created are kept, so the classrooms and
+// their statistics stay intact.
+func (r PrivacyRepo) Anonymize(ctx context.Context, userId int, email string, passwordHash string) error {
+	queries := []string{
+		`UPDATE users SET full_name = $2, email = $3, phone = NULL, password_hash = $4, email_verified = FALSE,
+			erased_at = now() WHERE id = $1`,
+		`DELETE FROM refresh_tokens WHERE user_id = $1`,
+		`DELETE FROM sessions WHERE user_id = $1`,
+		`DELETE FROM password_reset_tokens WHERE user_id = $1`,
+		`DELETE FROM email_verification_tokens WHERE user_id = $1`,
+		`DELETE FROM user_mfa WHERE user_id = $1`,
+		`DELETE FROM mfa_recovery_codes WHERE user_id = $1`,
+		`DELETE FROM mfa_challenges WHERE user_id = $1`,
+		`DELETE FROM user_identities WHERE user_id = $1`,
+		`DELETE FROM classroom_waitlist WHERE student_id = $1`,
+		`UPDATE api_keys SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`,
+	}
+
+	for i, q := range queries {
+		args := []interface{}{userId}
+
+		if i == 0 {
+			args = append(args, core.ErasedUserName, email, passwordHash)
+		}
+
+		if err := r.exec(ctx, q, args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r PrivacyRepo) Enrollments(ctx context.Context, userId int) ([]core.PersonalClassroomRecord, error) {
+	q := `SELECT c.id, c.title, NULL::VARCHAR, NULL::INT FROM classroom_students cs
+			JOIN classrooms c ON c.id = cs.classroom_id WHERE cs.student_id = $1 ORDER BY c.id`
+
+	return r.classrooms(ctx, q, userId)
+}
+
+func (r PrivacyRepo) Waitlist(ctx context.Context, userId int) ([]core.PersonalClassroomRecord, error) {
+	q := `SELECT c.id, c.title, NULL::VARCHAR, (SELECT COUNT(*)::INT FROM classroom_waitlist o
+			WHERE o.classroom_id = w.classroom_id AND o.position <= w.position)
+			FROM classroom_waitlist w JOIN classrooms c ON c.id = w.classroom_id WHERE w.student_id = $1 ORDER BY c.id`
+
+	return r.classrooms(ctx, q, userId)
+}
+
+// ClassroomRoles returns the staff roles and the institution defined roles of the user.
+func (r PrivacyRepo) ClassroomRoles(ctx context.Context, userId int) ([]core.PersonalClassroomRecord, error) {
+	q := `SELECT c.id, c.title, s.role::VARCHAR, NULL::INT FROM classroom_staff s
+			JOIN classrooms c ON c.id = s.classroom_id WHERE s.user_id = $1
+			UNION ALL
+			SELECT c.id, c.title, ro.name, NULL::INT FROM classroom_roles cr
+			JOIN classrooms c ON c.id = cr.classroom_id JOIN roles ro ON ro.id = cr.role_id WHERE cr.user_id = $1
+			ORDER BY 1`
+
+	return r.classrooms(ctx, q, userId)
+}
+
+func (r PrivacyRepo) Sessions(ctx context.Context, userId int) ([]core.PersonalSessionRecord, error) {
+	q := `SELECT user_agent, ip, created_at, last_used_at, revoked_at FROM sessions WHERE user_id = $1
+			ORDER BY created_at`
+
+	sessions := make([]core.PersonalSessionRecord, 0)
+
+	if err := r.collect(ctx, q, func(rows pgx.Rows) error {
+		var session core.PersonalSessionRecord
+
+		if err := rows.Scan(
+			&session.UserAgent,
+			&session.IP,
+			&session.CreatedAt,
+			&session.LastUsedAt,
+			&session.RevokedAt,
+		); err != nil {
+			return err
+		}
+
+		sessions = append(sessions, session)
+
+		return nil
+	}, userId); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (r PrivacyRepo) Identities(ctx context.Context, userId int) ([]core.PersonalIdentityRecord, error) {
+	q := `SELECT issuer, subject, created_at FROM user_identities WHERE user_id = $1 ORDER BY id`
+
+	identities := make([]core.PersonalIdentityRecord, 0)
+
+	if err := r.collect(ctx, q, func(rows pgx.Rows) error {
+		var identity core.PersonalIdentityRecord
+
+		if err := rows.Scan(&identity.Issuer, &identity.Subject, &identity.CreatedAt); err != nil {
+			return err
+		}
+
+		identities = append(identities, identity)
+
+		return nil
+	}, userId); err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+func (r PrivacyRepo) APIKeys(ctx context.Context, userId int) ([]core.PersonalAPIKeyRecord, error) {
+	q := `SELECT name, prefix, scopes, last_used_at, revoked_at, created_at FROM api_keys WHERE user_id = $1
+			ORDER BY id`
+
+	keys := make([]core.PersonalAPIKeyRecord, 0)
+
+	if err := r.collect(ctx, q, func(rows pgx.Rows) error {
+		var key core.PersonalAPIKeyRecord
+
+		if err := rows.Scan(
+			&key.Name,
+			&key.Prefix,
+			&key.Scopes,
+			&key.LastUsedAt,
+			&key.RevokedAt,
+			&key.CreatedAt,
+		); err != nil {
+			return err
+		}
+
+		keys = append(keys, key)
+
+		return nil
+	}, userId); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r PrivacyRepo) classrooms(ctx context.Context, q string, userId int) ([]core.PersonalClassroomRecord, error) {
+	classrooms := make([]core.PersonalClassroomRecord, 0)
+
+	if err := r.collect(ctx, q, func(rows pgx.Rows) error {
+		var classroom core.PersonalClassroomRecord
+
+		if err := rows.Scan(&classroom.ClassroomId, &classroom.Title, &classroom.Role, &classroom.Position); err != nil {
+			return err
+		}
+
+		classrooms = append(classrooms, classroom)
+
+		return nil
+	}, userId); err != nil {
+		return nil, err
+	}
+
+	return classrooms, nil
+}
+
+func (r PrivacyRepo) scanErasure(row pgx.Row) (core.ErasureRequestModel, error) {
+	var request core.ErasureRequestModel
+
+	if err := row.Scan(
+		&request.Id,
+		&request.UserId,
+		&request.FullName,
+		&request.Email,
+		&request.RequestedBy,
+		&request.Reason,
+		&request.Status,
+		&request.ReviewedBy,
+		&request.ReviewedAt,
+		&request.CreatedAt,
+	); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return core.ErasureRequestModel{}, apperrors.EntityNotFound
+			}
+
+			r.logger.Errorf("Error: %v", err)
+			return core.ErasureRequestModel{}, err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return core.ErasureRequestModel{}, err
+	}
+
+	return request, nil
+}
+
+// collect runs the query and passes every row to the function.
+func (r PrivacyRepo) collect(ctx context.Context, q string, scan func(rows pgx.Rows) error, args ...interface{}) error {
+	rows, err := r.pool.Query(ctx, q, args...)
+	if err != nil {
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			r.logger.Errorf("Query error. %v", err)
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r PrivacyRepo) exec(ctx context.Context, q string, args ...interface{}) error {
+	if _, err := r.pool.Exec(ctx, q, args...); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
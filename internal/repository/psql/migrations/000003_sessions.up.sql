@@ -0,0 +1,27 @@
+CREATE TABLE sessions
+(
+    id           UUID PRIMARY KEY,
+    user_id      INT         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    user_agent   VARCHAR(500),
+    ip           VARCHAR(45),
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at   TIMESTAMPTZ NOT NULL,
+    revoked_at   TIMESTAMPTZ
+);
+
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
+
+INSERT INTO sessions(id, user_id, created_at, last_used_at, expires_at, revoked_at)
+SELECT family_id,
+       min(user_id),
+       min(created_at),
+       max(created_at),
+       max(expires_at),
+       CASE WHEN bool_and(revoked_at IS NOT NULL) THEN max(revoked_at) END
+FROM refresh_tokens
+GROUP BY family_id;
+
+ALTER TABLE refresh_tokens
+    ADD CONSTRAINT refresh_tokens_family_id_fkey FOREIGN KEY (family_id) REFERENCES sessions (id) ON DELETE CASCADE
+        DEFERRABLE INITIALLY DEFERRED;
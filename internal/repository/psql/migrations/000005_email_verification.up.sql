@@ -0,0 +1,19 @@
+ALTER TABLE users
+    ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Accounts created before verification was introduced are trusted.
+UPDATE users
+SET email_verified = TRUE;
+
+CREATE TABLE email_verification_tokens
+(
+    id         INT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    user_id    INT         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    email      VARCHAR(50) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX email_verification_tokens_user_id_idx ON email_verification_tokens (user_id);
@@ -0,0 +1,9 @@
+CREATE TABLE signin_throttles
+(
+    scope           VARCHAR(16)  NOT NULL,
+    key             VARCHAR(255) NOT NULL,
+    failures        INT          NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    locked_until    TIMESTAMPTZ,
+    PRIMARY KEY (scope, key)
+);
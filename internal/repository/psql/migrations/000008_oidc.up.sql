@@ -0,0 +1,38 @@
+CREATE TABLE institution_oidc_providers
+(
+    institution_id INT PRIMARY KEY REFERENCES institutions (id) ON DELETE CASCADE,
+    issuer         VARCHAR(255) NOT NULL,
+    client_id      VARCHAR(255) NOT NULL,
+    client_secret  VARCHAR(255) NOT NULL,
+    scopes         VARCHAR(255) NOT NULL DEFAULT 'openid email profile',
+    role_claim     VARCHAR(100) NOT NULL DEFAULT 'roles',
+    role_mapping   JSONB        NOT NULL DEFAULT '{}',
+    default_role   VARCHAR(50),
+    enabled        BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at     TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE TABLE oidc_states
+(
+    id             INT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    state_hash     VARCHAR(64)  NOT NULL UNIQUE,
+    institution_id INT          NOT NULL REFERENCES institutions (id) ON DELETE CASCADE,
+    nonce          VARCHAR(100) NOT NULL,
+    code_verifier  VARCHAR(100) NOT NULL,
+    expires_at     TIMESTAMPTZ  NOT NULL,
+    used_at        TIMESTAMPTZ,
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE TABLE user_identities
+(
+    id         INT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    user_id    INT          NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    issuer     VARCHAR(255) NOT NULL,
+    subject    VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    UNIQUE (issuer, subject)
+);
+
+CREATE INDEX user_identities_user_id_idx ON user_identities (user_id);
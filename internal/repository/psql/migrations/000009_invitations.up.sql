@@ -0,0 +1,15 @@
+CREATE TABLE invitations
+(
+    id           INT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    token_hash   VARCHAR(64) NOT NULL UNIQUE,
+    classroom_id INT         NOT NULL REFERENCES classrooms (id) ON DELETE CASCADE,
+    created_by   INT         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    email        VARCHAR(50),
+    max_uses     INT,
+    uses         INT         NOT NULL DEFAULT 0,
+    expires_at   TIMESTAMPTZ NOT NULL,
+    revoked_at   TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX invitations_classroom_id_idx ON invitations (classroom_id);
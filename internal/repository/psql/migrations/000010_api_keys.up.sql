@@ -0,0 +1,19 @@
+-- A personal key acts as its owner. An institution key is managed by the admins of the institution
+-- and acts as the admin who created it.
+CREATE TABLE api_keys
+(
+    id             INT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    user_id        INT          NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    institution_id INT REFERENCES institutions (id) ON DELETE CASCADE,
+    name           VARCHAR(100) NOT NULL,
+    prefix         VARCHAR(16)  NOT NULL,
+    key_hash       VARCHAR(64)  NOT NULL UNIQUE,
+    scopes         TEXT[]       NOT NULL DEFAULT '{}',
+    expires_at     TIMESTAMPTZ,
+    last_used_at   TIMESTAMPTZ,
+    revoked_at     TIMESTAMPTZ,
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE INDEX api_keys_user_id_idx ON api_keys (user_id);
+CREATE INDEX api_keys_institution_id_idx ON api_keys (institution_id);
@@ -0,0 +1,16 @@
+-- Every impersonation and every request made with an impersonation token. The rows outlive the users.
+CREATE TABLE impersonation_audit
+(
+    id         BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    admin_id   INT         NOT NULL,
+    user_id    INT         NOT NULL,
+    event      VARCHAR(20) NOT NULL,
+    method     VARCHAR(10),
+    path       TEXT,
+    ip         VARCHAR(45),
+    user_agent TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX impersonation_audit_admin_id_idx ON impersonation_audit (admin_id, created_at);
+CREATE INDEX impersonation_audit_user_id_idx ON impersonation_audit (user_id, created_at);
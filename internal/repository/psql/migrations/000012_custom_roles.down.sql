@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS classroom_roles;
+
+DELETE FROM roles WHERE institution_id IS NOT NULL;
+
+DROP INDEX IF EXISTS roles_institution_name_key;
+DROP INDEX IF EXISTS roles_builtin_name_key;
+
+ALTER TABLE roles
+    DROP COLUMN permissions,
+    DROP COLUMN institution_id,
+    ADD CONSTRAINT roles_name_key UNIQUE (name);
@@ -0,0 +1,19 @@
+ALTER TABLE roles
+    ADD COLUMN institution_id INT REFERENCES institutions (id) ON DELETE CASCADE,
+    ADD COLUMN permissions    TEXT[] NOT NULL DEFAULT '{}',
+    DROP CONSTRAINT roles_name_key;
+
+-- The built-in roles have no institution, every institution can name its own roles freely.
+CREATE UNIQUE INDEX roles_builtin_name_key ON roles (name) WHERE institution_id IS NULL;
+CREATE UNIQUE INDEX roles_institution_name_key ON roles (institution_id, name) WHERE institution_id IS NOT NULL;
+
+CREATE TABLE classroom_roles
+(
+    id           INT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    classroom_id INT NOT NULL REFERENCES classrooms (id) ON DELETE CASCADE,
+    user_id      INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role_id      INT NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    UNIQUE (classroom_id, user_id)
+);
+
+CREATE INDEX classroom_roles_user_id_idx ON classroom_roles (user_id);
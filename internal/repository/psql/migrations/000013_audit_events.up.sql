@@ -0,0 +1,40 @@
+CREATE TABLE audit_events
+(
+    id              BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    -- The users are not referenced so that the events outlive them.
+    actor_id        INT          NOT NULL,
+    actor_role      VARCHAR(50)  NOT NULL,
+    impersonator_id INT,
+    institution_id  INT,
+    action          VARCHAR(10)  NOT NULL,
+    entity_type     VARCHAR(50)  NOT NULL,
+    entity_id       VARCHAR(100) NOT NULL,
+    before          JSONB,
+    after           JSONB,
+    request_id      VARCHAR(100),
+    ip              VARCHAR(45),
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE INDEX audit_events_institution_id_created_at_idx ON audit_events (institution_id, created_at);
+CREATE INDEX audit_events_entity_idx ON audit_events (entity_type, entity_id);
+CREATE INDEX audit_events_actor_id_idx ON audit_events (actor_id);
+
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS
+$$
+BEGIN
+    RAISE EXCEPTION 'audit events can not be changed';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_no_update_delete
+    BEFORE UPDATE OR DELETE
+    ON audit_events
+    FOR EACH ROW
+EXECUTE FUNCTION audit_events_append_only();
+
+CREATE TRIGGER audit_events_no_truncate
+    BEFORE TRUNCATE
+    ON audit_events
+    FOR EACH STATEMENT
+EXECUTE FUNCTION audit_events_append_only();
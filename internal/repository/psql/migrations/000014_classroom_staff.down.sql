@@ -0,0 +1 @@
+DROP TABLE IF EXISTS classroom_staff;
@@ -0,0 +1,16 @@
+CREATE TABLE classroom_staff
+(
+    id           INT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    classroom_id INT         NOT NULL REFERENCES classrooms (id) ON DELETE CASCADE,
+    user_id      INT         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role         VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'co_teacher', 'assistant')),
+    UNIQUE (classroom_id, user_id)
+);
+
+-- classrooms.teacher_id stays the owner, the table adds the rest of the staff.
+CREATE UNIQUE INDEX classroom_staff_owner_key ON classroom_staff (classroom_id) WHERE role = 'owner';
+CREATE INDEX classroom_staff_user_id_idx ON classroom_staff (user_id);
+
+INSERT INTO classroom_staff(classroom_id, user_id, role)
+SELECT id, teacher_id, 'owner'
+FROM classrooms;
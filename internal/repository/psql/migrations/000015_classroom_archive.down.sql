@@ -0,0 +1,2 @@
+ALTER TABLE classrooms
+    DROP COLUMN IF EXISTS archived_at;
@@ -0,0 +1,2 @@
+ALTER TABLE classrooms
+    ADD COLUMN archived_at TIMESTAMPTZ;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS classroom_students_student_id_idx;
+
+ALTER TABLE classroom_students
+    DROP CONSTRAINT IF EXISTS classroom_students_classroom_id_student_id_key;
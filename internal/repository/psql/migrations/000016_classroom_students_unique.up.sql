@@ -0,0 +1,11 @@
+DELETE
+FROM classroom_students a
+    USING classroom_students b
+WHERE a.classroom_id = b.classroom_id
+  AND a.student_id = b.student_id
+  AND a.id > b.id;
+
+ALTER TABLE classroom_students
+    ADD CONSTRAINT classroom_students_classroom_id_student_id_key UNIQUE (classroom_id, student_id);
+
+CREATE INDEX classroom_students_student_id_idx ON classroom_students (student_id);
@@ -0,0 +1,13 @@
+CREATE TABLE classroom_waitlist
+(
+    id           INT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    classroom_id INT         NOT NULL REFERENCES classrooms (id) ON DELETE CASCADE,
+    student_id   INT         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    position     INT         NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (classroom_id, student_id)
+);
+
+-- Positions only give the order, they may have gaps after students leave the waitlist.
+CREATE INDEX classroom_waitlist_classroom_id_position_idx ON classroom_waitlist (classroom_id, position);
+CREATE INDEX classroom_waitlist_student_id_idx ON classroom_waitlist (student_id);
@@ -0,0 +1,16 @@
+CREATE TABLE export_jobs
+(
+    id             INT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    institution_id INT         NOT NULL REFERENCES institutions (id) ON DELETE CASCADE,
+    requested_by   INT         REFERENCES users (id) ON DELETE SET NULL,
+    status         VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'done', 'failed')),
+    progress       INT         NOT NULL DEFAULT 0,
+    error          TEXT,
+    file_name      VARCHAR(100),
+    token_hash     VARCHAR(64) UNIQUE,
+    expires_at     TIMESTAMPTZ,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at    TIMESTAMPTZ
+);
+
+CREATE INDEX export_jobs_institution_id_idx ON export_jobs (institution_id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS erasure_requests;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS erased_at;
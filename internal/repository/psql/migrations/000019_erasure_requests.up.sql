@@ -0,0 +1,17 @@
+ALTER TABLE users
+    ADD COLUMN erased_at TIMESTAMPTZ;
+
+-- Requests to erase the personal data of a user. An admin of the institution approves or rejects them.
+CREATE TABLE erasure_requests
+(
+    id           INT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    user_id      INT         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    requested_by INT REFERENCES users (id) ON DELETE SET NULL,
+    reason       VARCHAR(1000),
+    status       VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    reviewed_by  INT REFERENCES users (id) ON DELETE SET NULL,
+    reviewed_at  TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX erasure_requests_pending_key ON erasure_requests (user_id) WHERE status = 'pending';
@@ -0,0 +1,6 @@
+-- Deactivated users can not sign in until an admin reactivates them. Deleted users are kept with their
+-- history and can be restored the same way.
+ALTER TABLE users
+    ADD COLUMN deactivated_at TIMESTAMPTZ;
+ALTER TABLE users
+    ADD COLUMN deleted_at TIMESTAMPTZ;
@@ -0,0 +1 @@
+-- The removed personal data cannot be brought back.
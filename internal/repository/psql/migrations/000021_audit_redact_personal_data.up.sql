@@ -0,0 +1,18 @@
+-- The audit events about users used to record their names, emails and phones, and the events about
+-- revoked sessions their IP addresses and user agents, which then outlived the erasure of the users.
+-- The events record ids only now, the personal data already recorded is removed.
+ALTER TABLE audit_events
+    DISABLE TRIGGER audit_events_no_update_delete;
+
+UPDATE audit_events
+SET before = before - ARRAY ['full_name', 'email', 'phone', 'pending_email'],
+    after  = after - ARRAY ['full_name', 'email', 'phone', 'pending_email']
+WHERE entity_type IN ('student', 'teacher', 'user', 'enrollment', 'waitlist', 'classroom_staff', 'invitation');
+
+UPDATE audit_events
+SET before = before - ARRAY ['ip', 'user_agent'],
+    after  = after - ARRAY ['ip', 'user_agent']
+WHERE entity_type = 'session';
+
+ALTER TABLE audit_events
+    ENABLE TRIGGER audit_events_no_update_delete;
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/internal/repository/psql"
+	"github.com/migmatore/study-platform-api/pkg/logger"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+)
+
+type RefreshTokenRepo struct {
+	logger logger.Logger
+	pool   psql.AtomicPoolClient
+}
+
+func NewRefreshTokenRepo(logger logger.Logger, pool psql.AtomicPoolClient) *RefreshTokenRepo {
+	return &RefreshTokenRepo{logger: logger, pool: pool}
+}
+
+func (r RefreshTokenRepo) Create(ctx context.Context, token core.RefreshTokenModel) error {
+	q := `INSERT INTO refresh_tokens(id, family_id, user_id, expires_at) VALUES ($1, $2, $3, $4)`
+
+	if _, err := r.pool.Exec(ctx, q, token.Id, token.FamilyId, token.UserId, token.ExpiresAt); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// ByIdForUpdate returns the token and locks its row until the end of the current transaction,
+// so that two concurrent refreshes with the same token can not both rotate it.
+func (r RefreshTokenRepo) ByIdForUpdate(ctx context.Context, id string) (core.RefreshTokenModel, error) {
+	q := `SELECT id, family_id, user_id, expires_at, created_at, rotated_at, revoked_at 
+			FROM refresh_tokens WHERE id = $1 FOR UPDATE`
+
+	var t core.RefreshTokenModel
+
+	if err := r.pool.QueryRow(ctx, q, id).Scan(
+		&t.Id,
+		&t.FamilyId,
+		&t.UserId,
+		&t.ExpiresAt,
+		&t.CreatedAt,
+		&t.RotatedAt,
+		&t.RevokedAt,
+	); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return core.RefreshTokenModel{}, apperrors.EntityNotFound
+			}
+
+			r.logger.Errorf("Error: %v", err)
+			return core.RefreshTokenModel{}, err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return core.RefreshTokenModel{}, err
+	}
+
+	return t, nil
+}
+
+func (r RefreshTokenRepo) Rotate(ctx context.Context, id string) error {
+	q := `UPDATE refresh_tokens SET rotated_at = now() WHERE id = $1`
+
+	if _, err := r.pool.Exec(ctx, q, id); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
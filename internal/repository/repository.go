@@ -6,22 +6,48 @@ import (
 )
 
 type Repository struct {
-	Logger      logger.Logger
-	Transaction *TransactionRepo
-	User        *UserRepo
-	Role        *RoleRepo
-	Institution *InstitutionRepo
-	Classroom   *ClassroomRepo
-	Lesson      *LessonRepo
+	Logger            logger.Logger
+	Transaction       *TransactionRepo
+	User              *UserRepo
+	Role              *RoleRepo
+	Institution       *InstitutionRepo
+	Classroom         *ClassroomRepo
+	Lesson            *LessonRepo
+	RefreshToken      *RefreshTokenRepo
+	Session           *SessionRepo
+	PasswordReset     *PasswordResetRepo
+	EmailVerification *EmailVerificationRepo
+	MFA               *MFARepo
+	SigninThrottle    *SigninThrottleRepo
+	OIDC              *OIDCRepo
+	Invitation        *InvitationRepo
+	APIKey            *APIKeyRepo
+	Impersonation     *ImpersonationRepo
+	Audit             *AuditRepo
+	Export            *ExportRepo
+	Privacy           *PrivacyRepo
 }
 
 func New(logger logger.Logger, pool psql.AtomicPoolClient) *Repository {
 	return &Repository{
-		Transaction: NewTransactor(pool),
-		User:        NewUserRepo(logger, pool),
-		Role:        NewRoleRepo(logger, pool),
-		Institution: NewInstitutionRepo(logger, pool),
-		Classroom:   NewClassroomRepo(logger, pool),
-		Lesson:      NewLessonRepo(logger, pool),
+		Transaction:       NewTransactor(pool),
+		User:              NewUserRepo(logger, pool),
+		Role:              NewRoleRepo(logger, pool),
+		Institution:       NewInstitutionRepo(logger, pool),
+		Classroom:         NewClassroomRepo(logger, pool),
+		Lesson:            NewLessonRepo(logger, pool),
+		RefreshToken:      NewRefreshTokenRepo(logger, pool),
+		Session:           NewSessionRepo(logger, pool),
+		PasswordReset:     NewPasswordResetRepo(logger, pool),
+		EmailVerification: NewEmailVerificationRepo(logger, pool),
+		MFA:               NewMFARepo(logger, pool),
+		SigninThrottle:    NewSigninThrottleRepo(logger, pool),
+		OIDC:              NewOIDCRepo(logger, pool),
+		Invitation:        NewInvitationRepo(logger, pool),
+		APIKey:            NewAPIKeyRepo(logger, pool),
+		Impersonation:     NewImpersonationRepo(logger, pool),
+		Audit:             NewAuditRepo(logger, pool),
+		Export:            NewExportRepo(logger, pool),
+		Privacy:           NewPrivacyRepo(logger, pool),
 	}
 }
@@ -2,6 +2,9 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
 	"github.com/migmatore/study-platform-api/internal/core"
 	"github.com/migmatore/study-platform-api/internal/repository/psql"
 	"github.com/migmatore/study-platform-api/pkg/logger"
@@ -17,38 +20,182 @@ func NewRoleRepo(logger logger.Logger, pool psql.AtomicPoolClient) *RoleRepo {
 	return &RoleRepo{logger: logger, pool: pool}
 }
 
+// ByName returns the built-in role with the name.
 func (r RoleRepo) ByName(ctx context.Context, name string) (core.RoleModel, error) {
-	q := `SELECT id, name FROM roles WHERE name = $1`
+	q := `SELECT id, name, institution_id, permissions FROM roles WHERE name = $1 AND institution_id IS NULL`
 
-	var role core.RoleModel
+	return r.scan(r.pool.QueryRow(ctx, q, name))
+}
+
+func (r RoleRepo) ById(ctx context.Context, id int) (core.RoleModel, error) {
+	q := `SELECT id, name, institution_id, permissions FROM roles WHERE id = $1`
+
+	return r.scan(r.pool.QueryRow(ctx, q, id))
+}
+
+// InstitutionRoleByName returns the role the institution defined with the name.
+func (r RoleRepo) InstitutionRoleByName(ctx context.Context, institutionId int, name string) (core.RoleModel, error) {
+	q := `SELECT id, name, institution_id, permissions FROM roles WHERE institution_id = $1 AND name = $2`
+
+	return r.scan(r.pool.QueryRow(ctx, q, institutionId, name))
+}
+
+func (r RoleRepo) ByInstitutionId(ctx context.Context, institutionId int) ([]core.RoleModel, error) {
+	q := `SELECT id, name, institution_id, permissions FROM roles WHERE institution_id = $1 ORDER BY name`
+
+	rows, err := r.pool.Query(ctx, q, institutionId)
+	if err != nil {
+		r.logger.Errorf("Query error. %v", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	roles := make([]core.RoleModel, 0)
+
+	for rows.Next() {
+		role, err := r.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Errorf("Query error. %v", err)
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (r RoleRepo) Create(ctx context.Context, role core.RoleModel) (core.RoleModel, error) {
+	q := `INSERT INTO roles(name, institution_id, permissions) VALUES ($1, $2, $3)
+			RETURNING id, name, institution_id, permissions`
+
+	return r.scan(r.pool.QueryRow(ctx, q, role.Name, role.InstitutionId, role.Permissions))
+}
+
+func (r RoleRepo) Update(ctx context.Context, role core.RoleModel) (core.RoleModel, error) {
+	q := `UPDATE roles SET name = $2, permissions = $3 WHERE id = $1 AND institution_id IS NOT NULL
+			RETURNING id, name, institution_id, permissions`
+
+	return r.scan(r.pool.QueryRow(ctx, q, role.Id, role.Name, role.Permissions))
+}
+
+func (r RoleRepo) Delete(ctx context.Context, id int) error {
+	q := `DELETE FROM roles WHERE id = $1 AND institution_id IS NOT NULL`
+
+	return r.exec(ctx, q, id)
+}
+
+// Assign gives the user the role in the classroom, replacing the role the user had there.
+func (r RoleRepo) Assign(ctx context.Context, classroomId, userId, roleId int) error {
+	q := `INSERT INTO classroom_roles(classroom_id, user_id, role_id) VALUES ($1, $2, $3)
+			ON CONFLICT (classroom_id, user_id) DO UPDATE SET role_id = excluded.role_id`
+
+	return r.exec(ctx, q, classroomId, userId, roleId)
+}
+
+func (r RoleRepo) Unassign(ctx context.Context, classroomId, userId int) error {
+	q := `DELETE FROM classroom_roles WHERE classroom_id = $1 AND user_id = $2`
+
+	return r.exec(ctx, q, classroomId, userId)
+}
+
+func (r RoleRepo) Assignments(ctx context.Context, classroomId int) ([]core.ClassroomRoleModel, error) {
+	q := `SELECT cr.classroom_id, cr.user_id, cr.role_id, r.name
+			FROM classroom_roles cr
+			JOIN roles r ON r.id = cr.role_id
+			WHERE cr.classroom_id = $1
+			ORDER BY cr.user_id`
+
+	rows, err := r.pool.Query(ctx, q, classroomId)
+	if err != nil {
+		r.logger.Errorf("Query error. %v", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	assignments := make([]core.ClassroomRoleModel, 0)
+
+	for rows.Next() {
+		var a core.ClassroomRoleModel
+
+		if err := rows.Scan(&a.ClassroomId, &a.UserId, &a.RoleId, &a.RoleName); err != nil {
+			r.logger.Errorf("Query error. %v", err)
+			return nil, err
+		}
+
+		assignments = append(assignments, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Errorf("Query error. %v", err)
+		return nil, err
+	}
+
+	return assignments, nil
+}
+
+// AssignedPermissions returns the permissions of the role the user has in the classroom, or none.
+func (r RoleRepo) AssignedPermissions(ctx context.Context, classroomId, userId int) ([]string, error) {
+	q := `SELECT r.permissions
+			FROM classroom_roles cr
+			JOIN roles r ON r.id = cr.role_id
+			WHERE cr.classroom_id = $1 AND cr.user_id = $2`
+
+	var permissions []string
+
+	if err := r.pool.QueryRow(ctx, q, classroomId, userId).Scan(&permissions); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
 
-	if err := r.pool.QueryRow(ctx, q, name).Scan(&role.Id, &role.Name); err != nil {
 		if err := utils.ParsePgError(err); err != nil {
 			r.logger.Errorf("Error: %v", err)
-			return role, err
+			return nil, err
 		}
 
 		r.logger.Errorf("Query error. %v", err)
-		return role, err
+		return nil, err
 	}
 
-	return role, nil
+	return permissions, nil
 }
 
-func (r RoleRepo) ById(ctx context.Context, id int) (core.RoleModel, error) {
-	q := `SELECT id, name FROM roles WHERE id = $1`
-
+func (r RoleRepo) scan(row pgx.Row) (core.RoleModel, error) {
 	var role core.RoleModel
 
-	if err := r.pool.QueryRow(ctx, q, id).Scan(&role.Id, &role.Name); err != nil {
+	if err := row.Scan(&role.Id, &role.Name, &role.InstitutionId, &role.Permissions); err != nil {
 		if err := utils.ParsePgError(err); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return core.RoleModel{}, apperrors.EntityNotFound
+			}
+
 			r.logger.Errorf("Error: %v", err)
-			return role, err
+			return core.RoleModel{}, err
 		}
 
 		r.logger.Errorf("Query error. %v", err)
-		return role, err
+		return core.RoleModel{}, err
 	}
 
 	return role, nil
 }
+
+func (r RoleRepo) exec(ctx context.Context, q string, args ...interface{}) error {
+	if _, err := r.pool.Exec(ctx, q, args...); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
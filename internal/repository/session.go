@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/internal/repository/psql"
+	"github.com/migmatore/study-platform-api/pkg/logger"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+)
+
+type SessionRepo struct {
+	logger logger.Logger
+	pool   psql.AtomicPoolClient
+}
+
+func NewSessionRepo(logger logger.Logger, pool psql.AtomicPoolClient) *SessionRepo {
+	return &SessionRepo{logger: logger, pool: pool}
+}
+
+func (r SessionRepo) Create(ctx context.Context, session core.SessionModel) error {
+	q := `INSERT INTO sessions(id, user_id, user_agent, ip, expires_at) VALUES ($1, $2, $3, $4, $5)`
+
+	if _, err := r.pool.Exec(
+		ctx,
+		q,
+		session.Id,
+		session.UserId,
+		session.UserAgent,
+		session.IP,
+		session.ExpiresAt,
+	); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r SessionRepo) ById(ctx context.Context, id string) (core.SessionModel, error) {
+	q := `SELECT id, user_id, user_agent, ip, created_at, last_used_at, expires_at, revoked_at 
+			FROM sessions WHERE id = $1`
+
+	var s core.SessionModel
+
+	if err := r.pool.QueryRow(ctx, q, id).Scan(
+		&s.Id,
+		&s.UserId,
+		&s.UserAgent,
+		&s.IP,
+		&s.CreatedAt,
+		&s.LastUsedAt,
+		&s.ExpiresAt,
+		&s.RevokedAt,
+	); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return core.SessionModel{}, apperrors.EntityNotFound
+			}
+
+			r.logger.Errorf("Error: %v", err)
+			return core.SessionModel{}, err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return core.SessionModel{}, err
+	}
+
+	return s, nil
+}
+
+func (r SessionRepo) ActiveByUserId(ctx context.Context, userId int) ([]core.SessionModel, error) {
+	q := `SELECT id, user_id, user_agent, ip, created_at, last_used_at, expires_at, revoked_at FROM sessions 
+			WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > now() ORDER BY last_used_at DESC`
+
+	sessions := make([]core.SessionModel, 0)
+
+	rows, err := r.pool.Query(ctx, q, userId)
+	if err != nil {
+		r.logger.Errorf("Query error. %v", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		s := core.SessionModel{}
+
+		err := rows.Scan(
+			&s.Id,
+			&s.UserId,
+			&s.UserAgent,
+			&s.IP,
+			&s.CreatedAt,
+			&s.LastUsedAt,
+			&s.ExpiresAt,
+			&s.RevokedAt,
+		)
+		if err != nil {
+			r.logger.Errorf("Query error. %v", err)
+			return nil, err
+		}
+
+		sessions = append(sessions, s)
+	}
+
+	return sessions, nil
+}
+
+func (r SessionRepo) Touch(ctx context.Context, session core.SessionModel) error {
+	q := `UPDATE sessions SET user_agent = $2, ip = $3, expires_at = $4, last_used_at = now() WHERE id = $1`
+
+	if _, err := r.pool.Exec(ctx, q, session.Id, session.UserAgent, session.IP, session.ExpiresAt); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// Revoke revokes the session together with every refresh token issued for it.
+func (r SessionRepo) Revoke(ctx context.Context, id string) error {
+	q := `WITH revoked AS (
+			UPDATE sessions SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL
+		  )
+		  UPDATE refresh_tokens SET revoked_at = now() WHERE family_id = $1 AND revoked_at IS NULL`
+
+	if _, err := r.pool.Exec(ctx, q, id); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// RevokeByUserId revokes every session of the user together with their refresh tokens.
+func (r SessionRepo) RevokeByUserId(ctx context.Context, userId int) error {
+	q := `WITH revoked AS (
+			UPDATE sessions SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL
+		  )
+		  UPDATE refresh_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`
+
+	if _, err := r.pool.Exec(ctx, q, userId); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
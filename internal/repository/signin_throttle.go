@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/internal/repository/psql"
+	"github.com/migmatore/study-platform-api/pkg/logger"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+	"time"
+)
+
+type SigninThrottleRepo struct {
+	logger logger.Logger
+	pool   psql.AtomicPoolClient
+}
+
+func NewSigninThrottleRepo(logger logger.Logger, pool psql.AtomicPoolClient) *SigninThrottleRepo {
+	return &SigninThrottleRepo{logger: logger, pool: pool}
+}
+
+func (r SigninThrottleRepo) Get(ctx context.Context, scope string, key string) (core.SigninThrottleModel, error) {
+	q := `SELECT scope, key, failures, last_failure_at, locked_until FROM signin_throttles 
+			WHERE scope = $1 AND key = $2`
+
+	var t core.SigninThrottleModel
+
+	if err := r.pool.QueryRow(ctx, q, scope, key).Scan(
+		&t.Scope,
+		&t.Key,
+		&t.Failures,
+		&t.LastFailureAt,
+		&t.LockedUntil,
+	); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return core.SigninThrottleModel{}, apperrors.EntityNotFound
+			}
+
+			r.logger.Errorf("Error: %v", err)
+			return core.SigninThrottleModel{}, err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return core.SigninThrottleModel{}, err
+	}
+
+	return t, nil
+}
+
+// RecordFailure counts a failed attempt and returns the number of failures in a row. The counter starts
+// over if the previous failure happened before the window.
+func (r SigninThrottleRepo) RecordFailure(
+	ctx context.Context,
+	scope string,
+	key string,
+	window time.Duration,
+) (int, error) {
+	q := `INSERT INTO signin_throttles(scope, key, failures, last_failure_at) VALUES ($1, $2, 1, now())
+			ON CONFLICT (scope, key) DO UPDATE
+			SET failures = CASE
+			        WHEN signin_throttles.last_failure_at < now() - $3::interval THEN 1
+			        ELSE signin_throttles.failures + 1
+			    END,
+			    last_failure_at = now()
+			RETURNING failures`
+
+	var failures int
+
+	if err := r.pool.QueryRow(ctx, q, scope, key, window).Scan(&failures); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return 0, err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return 0, err
+	}
+
+	return failures, nil
+}
+
+func (r SigninThrottleRepo) Lock(ctx context.Context, scope string, key string, until time.Time) error {
+	q := `UPDATE signin_throttles SET locked_until = $3 WHERE scope = $1 AND key = $2`
+
+	if _, err := r.pool.Exec(ctx, q, scope, key, until); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r SigninThrottleRepo) Reset(ctx context.Context, scope string, key string) error {
+	q := `DELETE FROM signin_throttles WHERE scope = $1 AND key = $2`
+
+	if _, err := r.pool.Exec(ctx, q, scope, key); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
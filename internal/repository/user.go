@@ -54,9 +54,10 @@ func (r UserRepo) IsExistById(ctx context.Context, id int) (bool, error) {
 }
 
 func (r UserRepo) Create(ctx context.Context, user core.UserModel) (core.UserModel, error) {
-	q := `INSERT INTO users(full_name, phone, email, password_hash, role_id, institution_id) 
-		  VALUES ($1, $2, $3, $4, $5, $6)
-          RETURNING id, full_name, phone, email, password_hash, role_id, institution_id`
+	q := `INSERT INTO users(full_name, phone, email, password_hash, role_id, institution_id, email_verified) 
+		  VALUES ($1, $2, $3, $4, $5, $6, $7)
+          RETURNING id, full_name, phone, email, password_hash, role_id, institution_id, email_verified, deactivated_at,
+                    deleted_at`
 
 	var u core.UserModel
 
@@ -69,6 +70,7 @@ func (r UserRepo) Create(ctx context.Context, user core.UserModel) (core.UserMod
 		user.PasswordHash,
 		user.RoleId,
 		user.InstitutionId,
+		user.EmailVerified,
 	).Scan(
 		&u.Id,
 		&u.FullName,
@@ -77,6 +79,9 @@ func (r UserRepo) Create(ctx context.Context, user core.UserModel) (core.UserMod
 		&u.PasswordHash,
 		&u.RoleId,
 		&u.InstitutionId,
+		&u.EmailVerified,
+		&u.DeactivatedAt,
+		&u.DeletedAt,
 	); err != nil {
 		if err := utils.ParsePgError(err); err != nil {
 			r.logger.Errorf("Error: %v", err)
@@ -91,7 +96,8 @@ func (r UserRepo) Create(ctx context.Context, user core.UserModel) (core.UserMod
 }
 
 func (r UserRepo) ByEmail(ctx context.Context, email string) (core.UserModel, error) {
-	q := `SELECT id, full_name, phone, email, password_hash, role_id, institution_id FROM users WHERE email = $1`
+	q := `SELECT id, full_name, phone, email, password_hash, role_id, institution_id, email_verified, deactivated_at,
+			deleted_at FROM users WHERE email = $1`
 
 	var u core.UserModel
 
@@ -103,6 +109,9 @@ func (r UserRepo) ByEmail(ctx context.Context, email string) (core.UserModel, er
 		&u.PasswordHash,
 		&u.RoleId,
 		&u.InstitutionId,
+		&u.EmailVerified,
+		&u.DeactivatedAt,
+		&u.DeletedAt,
 	); err != nil {
 		if err := utils.ParsePgError(err); err != nil {
 			r.logger.Errorf("Error: %v", err)
@@ -117,7 +126,8 @@ func (r UserRepo) ByEmail(ctx context.Context, email string) (core.UserModel, er
 }
 
 func (r UserRepo) ById(ctx context.Context, id int) (core.UserModel, error) {
-	q := `SELECT id, full_name, phone, email, password_hash, role_id, institution_id FROM users WHERE id = $1`
+	q := `SELECT id, full_name, phone, email, password_hash, role_id, institution_id, email_verified, deactivated_at,
+			deleted_at FROM users WHERE id = $1`
 
 	var u core.UserModel
 
@@ -129,6 +139,9 @@ func (r UserRepo) ById(ctx context.Context, id int) (core.UserModel, error) {
 		&u.PasswordHash,
 		&u.RoleId,
 		&u.InstitutionId,
+		&u.EmailVerified,
+		&u.DeactivatedAt,
+		&u.DeletedAt,
 	); err != nil {
 		if err := utils.ParsePgError(err); err != nil {
 			r.logger.Errorf("Error: %v", err)
@@ -188,7 +201,8 @@ func (r UserRepo) UpdateProfile(
 }
 
 func (r UserRepo) ByInstitutionId(ctx context.Context, institutionId int) ([]core.UserModel, error) {
-	q := `SELECT id, full_name, phone, email, password_hash, role_id, institution_id  FROM users WHERE institution_id = $1`
+	q := `SELECT id, full_name, phone, email, password_hash, role_id, institution_id, email_verified, deactivated_at,
+			deleted_at FROM users WHERE institution_id = $1`
 
 	users := make([]core.UserModel, 0)
 
@@ -211,6 +225,9 @@ func (r UserRepo) ByInstitutionId(ctx context.Context, institutionId int) ([]cor
 			&user.PasswordHash,
 			&user.RoleId,
 			&user.InstitutionId,
+			&user.EmailVerified,
+			&user.DeactivatedAt,
+			&user.DeletedAt,
 		)
 		if err != nil {
 			r.logger.Errorf("Query error. %v", err)
@@ -223,10 +240,59 @@ func (r UserRepo) ByInstitutionId(ctx context.Context, institutionId int) ([]cor
 	return users, nil
 }
 
+// Delete marks the user as deleted, revokes the sessions of the user and takes the user off the
+// waitlists. Enrollments, classrooms and the rest of the history are kept, so the user can be restored
+// with Reactivate. PrivacyRepo.Anonymize erases the personal data.
 func (r UserRepo) Delete(ctx context.Context, id int) error {
-	q := `DELETE FROM users WHERE id = $1`
+	queries := []string{
+		`UPDATE users SET deleted_at = COALESCE(deleted_at, now()) WHERE id = $1`,
+		`UPDATE sessions SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`,
+		`UPDATE refresh_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`,
+		`DELETE FROM classroom_waitlist WHERE student_id = $1`,
+	}
+
+	for _, q := range queries {
+		if err := r.exec(ctx, q, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Deactivate suspends the user. Deactivating a deactivated user keeps the time it was deactivated at.
+func (r UserRepo) Deactivate(ctx context.Context, id int) error {
+	q := `UPDATE users SET deactivated_at = COALESCE(deactivated_at, now()) WHERE id = $1`
+
+	return r.exec(ctx, q, id)
+}
+
+// Reactivate lifts both the deactivation and the deletion of the user.
+func (r UserRepo) Reactivate(ctx context.Context, id int) error {
+	q := `UPDATE users SET deactivated_at = NULL, deleted_at = NULL WHERE id = $1`
+
+	return r.exec(ctx, q, id)
+}
+
+// VerifyEmail sets the confirmed email of the user and marks it as verified.
+func (r UserRepo) VerifyEmail(ctx context.Context, userId int, email string) error {
+	q := `UPDATE users SET email = $2, email_verified = TRUE WHERE id = $1`
+
+	if _, err := r.pool.Exec(ctx, q, userId, email); err != nil {
+		if err := utils.ParsePgError(err); err != nil {
+			r.logger.Errorf("Error: %v", err)
+			return err
+		}
+
+		r.logger.Errorf("Query error. %v", err)
+		return err
+	}
+
+	return nil
+}
 
-	if _, err := r.pool.Exec(ctx, q, id); err != nil {
+func (r UserRepo) exec(ctx context.Context, q string, args ...interface{}) error {
+	if _, err := r.pool.Exec(ctx, q, args...); err != nil {
 		if err := utils.ParsePgError(err); err != nil {
 			r.logger.Errorf("Error: %v", err)
 			return err
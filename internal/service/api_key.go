@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+	"time"
+)
+
+const (
+	// apiKeyPrefix makes the keys easy to recognize, for example by secret scanners.
+	apiKeyPrefix = "spk_"
+	// apiKeyDisplayLength is how many leading characters of a key are kept to tell keys apart.
+	apiKeyDisplayLength = 12
+)
+
+type APIKeyRepo interface {
+	Create(ctx context.Context, key core.APIKeyModel) (core.APIKeyModel, error)
+	ById(ctx context.Context, id int) (core.APIKeyModel, error)
+	Use(ctx context.Context, hash string) (core.APIKeyModel, error)
+	ActiveByUserId(ctx context.Context, userId int) ([]core.APIKeyModel, error)
+	ActiveByInstitutionId(ctx context.Context, institutionId int) ([]core.APIKeyModel, error)
+	Revoke(ctx context.Context, id int) error
+}
+
+type APIKeyService struct {
+	apiKeyRepo APIKeyRepo
+}
+
+func NewAPIKeyService(apiKeyRepo APIKeyRepo) *APIKeyService {
+	return &APIKeyService{apiKeyRepo: apiKeyRepo}
+}
+
+// Create issues a new key and returns it with the key itself, which is not stored.
+func (s APIKeyService) Create(ctx context.Context, key core.APIKey) (core.APIKey, string, error) {
+	token, err := utils.RandomToken(32)
+	if err != nil {
+		return core.APIKey{}, "", err
+	}
+
+	token = apiKeyPrefix + token
+
+	model, err := s.apiKeyRepo.Create(ctx, core.APIKeyModel{
+		UserId:        key.UserId,
+		InstitutionId: key.InstitutionId,
+		Name:          key.Name,
+		Prefix:        token[:apiKeyDisplayLength],
+		KeyHash:       utils.HashToken(token),
+		Scopes:        key.Scopes,
+		ExpiresAt:     key.ExpiresAt,
+	})
+	if err != nil {
+		return core.APIKey{}, "", err
+	}
+
+	return apiKeyFromModel(model), token, nil
+}
+
+// Authenticate returns the active key and records that it was used.
+func (s APIKeyService) Authenticate(ctx context.Context, token string) (core.APIKey, error) {
+	model, err := s.apiKeyRepo.Use(ctx, utils.HashToken(token))
+	if err != nil {
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return core.APIKey{}, apperrors.InvalidToken
+		}
+
+		return core.APIKey{}, err
+	}
+
+	if model.ExpiresAt != nil && time.Now().After(*model.ExpiresAt) {
+		return core.APIKey{}, apperrors.ExpiredToken
+	}
+
+	return apiKeyFromModel(model), nil
+}
+
+func (s APIKeyService) ById(ctx context.Context, id int) (core.APIKey, error) {
+	model, err := s.apiKeyRepo.ById(ctx, id)
+	if err != nil {
+		return core.APIKey{}, err
+	}
+
+	return apiKeyFromModel(model), nil
+}
+
+func (s APIKeyService) ActiveByUserId(ctx context.Context, userId int) ([]core.APIKey, error) {
+	models, err := s.apiKeyRepo.ActiveByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return apiKeysFromModels(models), nil
+}
+
+func (s APIKeyService) ActiveByInstitutionId(ctx context.Context, institutionId int) ([]core.APIKey, error) {
+	models, err := s.apiKeyRepo.ActiveByInstitutionId(ctx, institutionId)
+	if err != nil {
+		return nil, err
+	}
+
+	return apiKeysFromModels(models), nil
+}
+
+func (s APIKeyService) Revoke(ctx context.Context, id int) error {
+	return s.apiKeyRepo.Revoke(ctx, id)
+}
+
+func apiKeysFromModels(models []core.APIKeyModel) []core.APIKey {
+	keys := make([]core.APIKey, 0, len(models))
+
+	for _, model := range models {
+		keys = append(keys, apiKeyFromModel(model))
+	}
+
+	return keys
+}
+
+func apiKeyFromModel(model core.APIKeyModel) core.APIKey {
+	return core.APIKey{
+		Id:            model.Id,
+		UserId:        model.UserId,
+		InstitutionId: model.InstitutionId,
+		Name:          model.Name,
+		Prefix:        model.Prefix,
+		Scopes:        model.Scopes,
+		ExpiresAt:     model.ExpiresAt,
+		LastUsedAt:    model.LastUsedAt,
+		RevokedAt:     model.RevokedAt,
+		CreatedAt:     model.CreatedAt,
+	}
+}
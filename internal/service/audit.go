@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+	"reflect"
+)
+
+type AuditRepo interface {
+	Create(ctx context.Context, event core.AuditEventModel) error
+	Search(ctx context.Context, filter core.AuditFilter) ([]core.AuditEventModel, error)
+}
+
+type AuditUserRepo interface {
+	ById(ctx context.Context, id int) (core.UserModel, error)
+}
+
+type AuditService struct {
+	auditRepo AuditRepo
+	userRepo  AuditUserRepo
+}
+
+func NewAuditService(auditRepo AuditRepo, userRepo AuditUserRepo) *AuditService {
+	return &AuditService{auditRepo: auditRepo, userRepo: userRepo}
+}
+
+// Record stores the change made by the actor. The request id and the ip address are taken from the
+// context.
+func (s AuditService) Record(ctx context.Context, actor core.TokenMetadata, event core.AuditEvent) error {
+	user, err := s.userRepo.ById(ctx, actor.UserId)
+	if err != nil {
+		return err
+	}
+
+	before, after, err := auditDiff(event.Before, event.After)
+	if err != nil {
+		return err
+	}
+
+	info := utils.RequestInfoFromContext(ctx)
+
+	var impersonatorId *int
+	if actor.ImpersonatorId != 0 {
+		impersonatorId = &actor.ImpersonatorId
+	}
+
+	return s.auditRepo.Create(ctx, core.AuditEventModel{
+		ActorId:        actor.UserId,
+		ActorRole:      actor.Role,
+		ImpersonatorId: impersonatorId,
+		InstitutionId:  user.InstitutionId,
+		Action:         string(event.Action),
+		EntityType:     event.EntityType,
+		EntityId:       event.EntityId,
+		Before:         before,
+		After:          after,
+		RequestId:      optionalString(info.Id),
+		IP:             optionalString(info.Client.IP),
+	})
+}
+
+func (s AuditService) Search(ctx context.Context, filter core.AuditFilter) ([]core.AuditRecord, error) {
+	models, err := s.auditRepo.Search(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]core.AuditRecord, 0, len(models))
+
+	for _, model := range models {
+		records = append(records, core.AuditRecord{
+			Id:             model.Id,
+			ActorId:        model.ActorId,
+			ActorRole:      model.ActorRole,
+			ImpersonatorId: model.ImpersonatorId,
+			InstitutionId:  model.InstitutionId,
+			Action:         core.AuditAction(model.Action),
+			EntityType:     model.EntityType,
+			EntityId:       model.EntityId,
+			Before:         model.Before,
+			After:          model.After,
+			RequestId:      model.RequestId,
+			IP:             model.IP,
+			CreatedAt:      model.CreatedAt,
+		})
+	}
+
+	return records, nil
+}
+
+// auditDiff encodes the states of the entity. When there are both, only the fields which changed
+// are kept.
+func auditDiff(before interface{}, after interface{}) ([]byte, []byte, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if beforeFields != nil && afterFields != nil {
+		for field, value := range beforeFields {
+			if other, ok := afterFields[field]; ok && reflect.DeepEqual(value, other) {
+				delete(beforeFields, field)
+				delete(afterFields, field)
+			}
+		}
+	}
+
+	beforeJSON, err := marshalAuditFields(beforeFields)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	afterJSON, err := marshalAuditFields(afterFields)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return beforeJSON, afterJSON, nil
+}
+
+func auditFields(state interface{}) (map[string]interface{}, error) {
+	if state == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]interface{})
+
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+func marshalAuditFields(fields map[string]interface{}) ([]byte, error) {
+	if fields == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(fields)
+}
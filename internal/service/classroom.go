@@ -2,20 +2,41 @@ package service
 
 import (
 	"context"
-	"errors"
+	"github.com/migmatore/study-platform-api/config"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
 	"github.com/migmatore/study-platform-api/internal/core"
+	"time"
 )
 
+const defaultClassroomRetentionDays = 365
+
 type ClassroomRepo interface {
 	Create(ctx context.Context, classroom core.ClassroomModel) (core.ClassroomModel, error)
-	Delete(ctx context.Context, id int) error
+	Update(ctx context.Context, classroom core.UpdateClassroomModel) (core.ClassroomModel, error)
+	Archive(ctx context.Context, id int) (core.ClassroomModel, error)
+	Restore(ctx context.Context, id int) (core.ClassroomModel, error)
+	Purge(ctx context.Context, id int, archivedBefore time.Time) (bool, error)
 	TeacherClassrooms(ctx context.Context, teacherId int) ([]core.ClassroomModel, error)
 	StudentClassrooms(ctx context.Context, studentId int) ([]core.ClassroomModel, error)
+	InstitutionClassrooms(ctx context.Context, institutionId int) ([]core.ClassroomModel, error)
+	AssignedClassrooms(ctx context.Context, userId int) ([]core.ClassroomModel, error)
 	ById(ctx context.Context, id int) (core.ClassroomModel, error)
+	ByIdForUpdate(ctx context.Context, id int) (core.ClassroomModel, error)
 	IsIn(ctx context.Context, classroomId, studentId int) (bool, error)
 	Students(ctx context.Context, classroomId int) ([]core.UserModel, error)
+	OccupiedSeats(ctx context.Context, classroomId int) (int, error)
 	StudentsByClassroomsId(ctx context.Context, ids []int) ([]core.StudentModel, error)
-	AddStudent(ctx context.Context, studentId int, classroomsId []int) error
+	EnrollStudents(ctx context.Context, classroomId int, studentsId []int) error
+	RemoveStudents(ctx context.Context, classroomId int, studentsId []int) error
+	Waitlist(ctx context.Context, classroomId int) ([]core.WaitlistEntryModel, error)
+	AddToWaitlist(ctx context.Context, classroomId int, studentsId []int) error
+	RemoveFromWaitlist(ctx context.Context, classroomId int, studentsId []int) error
+	ReorderWaitlist(ctx context.Context, classroomId int, studentsId []int) error
+	IsStaff(ctx context.Context, classroomId, userId int) (bool, error)
+	Staff(ctx context.Context, classroomId int) ([]core.ClassroomStaffModel, error)
+	AddStaff(ctx context.Context, classroomId, userId int, role string) error
+	RemoveStaff(ctx context.Context, classroomId, userId int) error
+	SetOwner(ctx context.Context, classroomId, teacherId int) error
 }
 
 type ClassroomTeacherUserRepo interface {
@@ -23,12 +44,17 @@ type ClassroomTeacherUserRepo interface {
 }
 
 type ClassroomService struct {
+	config        *config.Config
 	classroomRepo ClassroomRepo
 	teacherRepo   ClassroomTeacherUserRepo
 }
 
-func NewClassroomService(classroomRepo ClassroomRepo, teacherRepo ClassroomTeacherUserRepo) *ClassroomService {
-	return &ClassroomService{classroomRepo: classroomRepo, teacherRepo: teacherRepo}
+func NewClassroomService(
+	config *config.Config,
+	classroomRepo ClassroomRepo,
+	teacherRepo ClassroomTeacherUserRepo,
+) *ClassroomService {
+	return &ClassroomService{config: config, classroomRepo: classroomRepo, teacherRepo: teacherRepo}
 }
 
 func (s ClassroomService) Create(ctx context.Context, classroom core.Classroom) (core.Classroom, error) {
@@ -52,8 +78,84 @@ func (s ClassroomService) Create(ctx context.Context, classroom core.Classroom)
 	}, nil
 }
 
-func (s ClassroomService) Delete(ctx context.Context, id int) error {
-	return s.classroomRepo.Delete(ctx, id)
+func (s ClassroomService) Archive(ctx context.Context, id int) (core.Classroom, error) {
+	model, err := s.classroomRepo.Archive(ctx, id)
+	if err != nil {
+		return core.Classroom{}, err
+	}
+
+	return classroomFromModel(model), nil
+}
+
+func (s ClassroomService) Restore(ctx context.Context, id int) (core.Classroom, error) {
+	model, err := s.classroomRepo.Restore(ctx, id)
+	if err != nil {
+		return core.Classroom{}, err
+	}
+
+	return classroomFromModel(model), nil
+}
+
+// Purge deletes the archived classroom for good, together with its lessons and enrollments. It is
+// only possible once the classroom has been archived for the retention period.
+func (s ClassroomService) Purge(ctx context.Context, classroom core.Classroom) error {
+	if classroom.ArchivedAt == nil {
+		return apperrors.ClassroomNotArchived
+	}
+
+	retentionDays := s.config.Server.ClassroomRetentionDays
+	if retentionDays <= 0 {
+		retentionDays = defaultClassroomRetentionDays
+	}
+
+	archivedBefore := time.Now().AddDate(0, 0, -retentionDays)
+
+	if classroom.ArchivedAt.After(archivedBefore) {
+		return apperrors.RetentionPeriodNotOver
+	}
+
+	purged, err := s.classroomRepo.Purge(ctx, classroom.Id, archivedBefore)
+	if err != nil {
+		return err
+	}
+
+	// The classroom was restored in the meantime.
+	if !purged {
+		return apperrors.RetentionPeriodNotOver
+	}
+
+	return nil
+}
+
+func (s ClassroomService) Update(ctx context.Context, classroom core.UpdateClassroom) (core.Classroom, error) {
+	classroomModel, err := s.classroomRepo.Update(ctx, core.UpdateClassroomModel{
+		Id:          classroom.Id,
+		Title:       classroom.Title,
+		Description: classroom.Description,
+		MaxStudents: classroom.MaxStudents,
+	})
+	if err != nil {
+		return core.Classroom{}, err
+	}
+
+	return core.Classroom{
+		Id:          classroomModel.Id,
+		Title:       classroomModel.Title,
+		Description: classroomModel.Description,
+		TeacherId:   classroomModel.TeacherId,
+		MaxStudents: classroomModel.MaxStudents,
+		ArchivedAt:  classroomModel.ArchivedAt,
+	}, nil
+}
+
+// ByIdForUpdate returns the classroom and locks it until the end of the transaction it is called within.
+func (s ClassroomService) ByIdForUpdate(ctx context.Context, id int) (core.Classroom, error) {
+	classroomModel, err := s.classroomRepo.ByIdForUpdate(ctx, id)
+	if err != nil {
+		return core.Classroom{}, err
+	}
+
+	return classroomFromModel(classroomModel), nil
 }
 
 func (s ClassroomService) ById(ctx context.Context, id int) (core.Classroom, error) {
@@ -68,16 +170,99 @@ func (s ClassroomService) ById(ctx context.Context, id int) (core.Classroom, err
 		Description: classroomModel.Description,
 		TeacherId:   classroomModel.TeacherId,
 		MaxStudents: classroomModel.MaxStudents,
+		ArchivedAt:  classroomModel.ArchivedAt,
 	}, nil
 }
 
+func (s ClassroomService) InstitutionClassrooms(ctx context.Context, institutionId int) ([]core.Classroom, error) {
+	classroomsModel, err := s.classroomRepo.InstitutionClassrooms(ctx, institutionId)
+	if err != nil {
+		return nil, err
+	}
+
+	classrooms := make([]core.Classroom, 0, len(classroomsModel))
+
+	for _, model := range classroomsModel {
+		classrooms = append(classrooms, core.Classroom{
+			Id:          model.Id,
+			Title:       model.Title,
+			Description: model.Description,
+			TeacherId:   model.TeacherId,
+			MaxStudents: model.MaxStudents,
+			ArchivedAt:  model.ArchivedAt,
+		})
+	}
+
+	return classrooms, nil
+}
+
+func (s ClassroomService) AssignedClassrooms(ctx context.Context, userId int) ([]core.Classroom, error) {
+	classroomsModel, err := s.classroomRepo.AssignedClassrooms(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	classrooms := make([]core.Classroom, 0, len(classroomsModel))
+
+	for _, model := range classroomsModel {
+		classrooms = append(classrooms, core.Classroom{
+			Id:          model.Id,
+			Title:       model.Title,
+			Description: model.Description,
+			TeacherId:   model.TeacherId,
+			MaxStudents: model.MaxStudents,
+			ArchivedAt:  model.ArchivedAt,
+		})
+	}
+
+	return classrooms, nil
+}
+
+func (s ClassroomService) RemoveStudents(ctx context.Context, classroomId int, studentsId []int) error {
+	return s.classroomRepo.RemoveStudents(ctx, classroomId, studentsId)
+}
+
+func (s ClassroomService) RemoveFromWaitlist(ctx context.Context, classroomId int, studentsId []int) error {
+	return s.classroomRepo.RemoveFromWaitlist(ctx, classroomId, studentsId)
+}
+
+// IsBelongs reports whether the teacher is on the staff of the classroom.
 func (s ClassroomService) IsBelongs(ctx context.Context, classroomId, teacherId int) (bool, error) {
-	classroom, err := s.classroomRepo.ById(ctx, classroomId)
+	return s.classroomRepo.IsStaff(ctx, classroomId, teacherId)
+}
+
+func (s ClassroomService) Staff(ctx context.Context, classroomId int) ([]core.ClassroomStaff, error) {
+	models, err := s.classroomRepo.Staff(ctx, classroomId)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	return classroom.TeacherId == teacherId, nil
+	staff := make([]core.ClassroomStaff, 0, len(models))
+
+	for _, model := range models {
+		staff = append(staff, core.ClassroomStaff{
+			ClassroomId: model.ClassroomId,
+			UserId:      model.UserId,
+			FullName:    model.FullName,
+			Email:       model.Email,
+			Role:        core.StaffRole(model.Role),
+		})
+	}
+
+	return staff, nil
+}
+
+func (s ClassroomService) AddStaff(ctx context.Context, classroomId, userId int, role core.StaffRole) error {
+	return s.classroomRepo.AddStaff(ctx, classroomId, userId, string(role))
+}
+
+func (s ClassroomService) RemoveStaff(ctx context.Context, classroomId, userId int) error {
+	return s.classroomRepo.RemoveStaff(ctx, classroomId, userId)
+}
+
+// SetOwner transfers the classroom to the teacher. It has to be called within a transaction.
+func (s ClassroomService) SetOwner(ctx context.Context, classroomId, teacherId int) error {
+	return s.classroomRepo.SetOwner(ctx, classroomId, teacherId)
 }
 
 func (s ClassroomService) IsIn(ctx context.Context, classroomId, studentId int) (bool, error) {
@@ -94,20 +279,308 @@ func (s ClassroomService) Students(ctx context.Context, classroomId int) ([]core
 
 	for _, user := range usersModel {
 		students = append(students, core.Student{
-			Id:       user.Id,
-			FullName: user.FullName,
-			Phone:    user.Phone,
-			Email:    user.Email,
+			Id:            user.Id,
+			FullName:      user.FullName,
+			Phone:         user.Phone,
+			Email:         user.Email,
+			DeactivatedAt: user.DeactivatedAt,
+			DeletedAt:     user.DeletedAt,
 		})
 	}
 
 	return students, nil
 }
 
-func (s ClassroomService) AddStudent(ctx context.Context, studentId int, classroomsId []int) error {
-	if len(classroomsId) == 0 {
-		return errors.New("classrooms id can not be empty")
+// Enroll adds the students to the classroom while it has free seats and puts the rest at the end of
+// its waitlist. Students who are already in the classroom or on the waitlist are skipped. It is the
+// only way students get into classrooms, so the number of students is checked in one place. It has
+// to be called within a transaction: the classroom stays locked until the transaction ends, so
+// concurrent enrollments into the same classroom are checked one after another.
+func (s ClassroomService) Enroll(ctx context.Context, classroomId int, studentsId []int) (core.Enrollment, error) {
+	classroom, err := s.classroomRepo.ByIdForUpdate(ctx, classroomId)
+	if err != nil {
+		return core.Enrollment{}, err
+	}
+
+	if classroom.ArchivedAt != nil {
+		return core.Enrollment{}, apperrors.ClassroomArchived
 	}
 
-	return s.classroomRepo.AddStudent(ctx, studentId, classroomsId)
+	students, err := s.classroomRepo.Students(ctx, classroomId)
+	if err != nil {
+		return core.Enrollment{}, err
+	}
+
+	occupied, err := s.classroomRepo.OccupiedSeats(ctx, classroomId)
+	if err != nil {
+		return core.Enrollment{}, err
+	}
+
+	waitlist, err := s.classroomRepo.Waitlist(ctx, classroomId)
+	if err != nil {
+		return core.Enrollment{}, err
+	}
+
+	enrolled := make(map[int]bool, len(students)+len(studentsId))
+
+	for _, student := range students {
+		enrolled[student.Id] = true
+	}
+
+	waitlisted := make(map[int]bool, len(waitlist))
+
+	for _, entry := range waitlist {
+		waitlisted[entry.StudentId] = true
+	}
+
+	enrollment := core.Enrollment{Enrolled: make([]int, 0, len(studentsId)), Waitlisted: make([]int, 0)}
+	freeSeats := classroom.MaxStudents - occupied
+
+	for _, id := range studentsId {
+		if enrolled[id] {
+			continue
+		}
+
+		if len(enrollment.Enrolled) < freeSeats {
+			enrolled[id] = true
+			enrollment.Enrolled = append(enrollment.Enrolled, id)
+
+			continue
+		}
+
+		if !waitlisted[id] {
+			waitlisted[id] = true
+			enrollment.Waitlisted = append(enrollment.Waitlisted, id)
+		}
+	}
+
+	if len(enrollment.Enrolled) > 0 {
+		if err := s.enroll(ctx, classroomId, enrollment.Enrolled); err != nil {
+			return core.Enrollment{}, err
+		}
+	}
+
+	if len(enrollment.Waitlisted) > 0 {
+		if err := s.classroomRepo.AddToWaitlist(ctx, classroomId, enrollment.Waitlisted); err != nil {
+			return core.Enrollment{}, err
+		}
+	}
+
+	return enrollment, nil
+}
+
+func (s ClassroomService) Waitlist(ctx context.Context, classroomId int) ([]core.WaitlistEntry, error) {
+	models, err := s.classroomRepo.Waitlist(ctx, classroomId)
+	if err != nil {
+		return nil, err
+	}
+
+	waitlist := make([]core.WaitlistEntry, 0, len(models))
+
+	for _, model := range models {
+		waitlist = append(waitlist, waitlistEntryFromModel(model))
+	}
+
+	return waitlist, nil
+}
+
+// ReorderWaitlist changes the order of the waitlist. The order has to list every waitlisted student
+// exactly once. It has to be called within a transaction, see Enroll.
+func (s ClassroomService) ReorderWaitlist(ctx context.Context, classroomId int, studentsId []int) error {
+	if _, err := s.classroomRepo.ByIdForUpdate(ctx, classroomId); err != nil {
+		return err
+	}
+
+	waitlist, err := s.classroomRepo.Waitlist(ctx, classroomId)
+	if err != nil {
+		return err
+	}
+
+	if len(studentsId) != len(waitlist) {
+		return apperrors.InvalidWaitlistOrder
+	}
+
+	listed := make(map[int]bool, len(studentsId))
+
+	for _, id := range studentsId {
+		listed[id] = true
+	}
+
+	for _, entry := range waitlist {
+		if !listed[entry.StudentId] {
+			return apperrors.InvalidWaitlistOrder
+		}
+	}
+
+	return s.classroomRepo.ReorderWaitlist(ctx, classroomId, studentsId)
+}
+
+// Promote gives the waitlisted student a free seat in the classroom regardless of the position on
+// the waitlist. It has to be called within a transaction, see Enroll.
+func (s ClassroomService) Promote(ctx context.Context, classroomId, studentId int) (core.WaitlistEntry, error) {
+	classroom, err := s.classroomRepo.ByIdForUpdate(ctx, classroomId)
+	if err != nil {
+		return core.WaitlistEntry{}, err
+	}
+
+	if classroom.ArchivedAt != nil {
+		return core.WaitlistEntry{}, apperrors.ClassroomArchived
+	}
+
+	waitlist, err := s.classroomRepo.Waitlist(ctx, classroomId)
+	if err != nil {
+		return core.WaitlistEntry{}, err
+	}
+
+	for _, entry := range waitlist {
+		if entry.StudentId != studentId {
+			continue
+		}
+
+		occupied, err := s.classroomRepo.OccupiedSeats(ctx, classroomId)
+		if err != nil {
+			return core.WaitlistEntry{}, err
+		}
+
+		if occupied >= classroom.MaxStudents {
+			return core.WaitlistEntry{}, apperrors.NumberOfStudentsExceeded
+		}
+
+		if err := s.enroll(ctx, classroomId, []int{studentId}); err != nil {
+			return core.WaitlistEntry{}, err
+		}
+
+		return waitlistEntryFromModel(entry), nil
+	}
+
+	return core.WaitlistEntry{}, apperrors.EntityNotFound
+}
+
+// PromoteWaitlisted fills the free seats of the classroom with the students from the head of its
+// waitlist and returns the promoted ones. It has to be called within a transaction, see Enroll.
+func (s ClassroomService) PromoteWaitlisted(ctx context.Context, classroomId int) ([]core.WaitlistEntry, error) {
+	classroom, err := s.classroomRepo.ByIdForUpdate(ctx, classroomId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nobody joins an archived classroom, the waitlist waits until it is restored.
+	if classroom.ArchivedAt != nil {
+		return nil, nil
+	}
+
+	occupied, err := s.classroomRepo.OccupiedSeats(ctx, classroomId)
+	if err != nil {
+		return nil, err
+	}
+
+	freeSeats := classroom.MaxStudents - occupied
+	if freeSeats <= 0 {
+		return nil, nil
+	}
+
+	waitlist, err := s.classroomRepo.Waitlist(ctx, classroomId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(waitlist) > freeSeats {
+		waitlist = waitlist[:freeSeats]
+	}
+
+	promoted := make([]core.WaitlistEntry, 0, len(waitlist))
+	studentsId := make([]int, 0, len(waitlist))
+
+	for _, entry := range waitlist {
+		promoted = append(promoted, waitlistEntryFromModel(entry))
+		studentsId = append(studentsId, entry.StudentId)
+	}
+
+	if len(studentsId) > 0 {
+		if err := s.enroll(ctx, classroomId, studentsId); err != nil {
+			return nil, err
+		}
+	}
+
+	return promoted, nil
+}
+
+// Readmit gives the reactivated student the seat in the classroom back. When the waitlist took the
+// seat meanwhile, the student is moved to the end of the waitlist instead and true is returned. It has
+// to be called within a transaction, see Enroll.
+func (s ClassroomService) Readmit(ctx context.Context, classroomId, studentId int) (bool, error) {
+	classroom, err := s.classroomRepo.ByIdForUpdate(ctx, classroomId)
+	if err != nil {
+		return false, err
+	}
+
+	occupied, err := s.classroomRepo.OccupiedSeats(ctx, classroomId)
+	if err != nil {
+		return false, err
+	}
+
+	if occupied <= classroom.MaxStudents {
+		return false, nil
+	}
+
+	if err := s.classroomRepo.RemoveStudents(ctx, classroomId, []int{studentId}); err != nil {
+		return false, err
+	}
+
+	if err := s.classroomRepo.AddToWaitlist(ctx, classroomId, []int{studentId}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// OccupiedSeats counts the students who take seats in the classroom, inactive students do not.
+func (s ClassroomService) OccupiedSeats(ctx context.Context, classroomId int) (int, error) {
+	return s.classroomRepo.OccupiedSeats(ctx, classroomId)
+}
+
+func (s ClassroomService) StudentClassrooms(ctx context.Context, studentId int) ([]core.Classroom, error) {
+	classroomsModel, err := s.classroomRepo.StudentClassrooms(ctx, studentId)
+	if err != nil {
+		return nil, err
+	}
+
+	classrooms := make([]core.Classroom, 0, len(classroomsModel))
+
+	for _, model := range classroomsModel {
+		classrooms = append(classrooms, classroomFromModel(model))
+	}
+
+	return classrooms, nil
+}
+
+// enroll adds the students to the classroom and takes them off its waitlist.
+func (s ClassroomService) enroll(ctx context.Context, classroomId int, studentsId []int) error {
+	if err := s.classroomRepo.EnrollStudents(ctx, classroomId, studentsId); err != nil {
+		return err
+	}
+
+	return s.classroomRepo.RemoveFromWaitlist(ctx, classroomId, studentsId)
+}
+
+func classroomFromModel(model core.ClassroomModel) core.Classroom {
+	return core.Classroom{
+		Id:          model.Id,
+		Title:       model.Title,
+		Description: model.Description,
+		TeacherId:   model.TeacherId,
+		MaxStudents: model.MaxStudents,
+		ArchivedAt:  model.ArchivedAt,
+	}
+}
+
+func waitlistEntryFromModel(model core.WaitlistEntryModel) core.WaitlistEntry {
+	return core.WaitlistEntry{
+		ClassroomId: model.ClassroomId,
+		StudentId:   model.StudentId,
+		FullName:    model.FullName,
+		Email:       model.Email,
+		Position:    model.Position,
+		CreatedAt:   model.CreatedAt,
+	}
 }
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/migmatore/study-platform-api/config"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+	"time"
+)
+
+const defaultEmailVerificationExpHour = 48
+
+type EmailVerificationRepo interface {
+	Create(ctx context.Context, token core.EmailVerificationTokenModel) error
+	ByHashForUpdate(ctx context.Context, hash string) (core.EmailVerificationTokenModel, error)
+	MarkUsed(ctx context.Context, id int) error
+	InvalidateByUserId(ctx context.Context, userId int) error
+}
+
+type EmailVerificationService struct {
+	config                *config.Config
+	emailVerificationRepo EmailVerificationRepo
+}
+
+func NewEmailVerificationService(
+	config *config.Config,
+	emailVerificationRepo EmailVerificationRepo,
+) *EmailVerificationService {
+	return &EmailVerificationService{config: config, emailVerificationRepo: emailVerificationRepo}
+}
+
+// Create issues a token confirming that the user owns the email. Previously issued tokens stop working.
+func (s EmailVerificationService) Create(ctx context.Context, userId int, email string) (string, error) {
+	token, err := utils.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	expHour := s.config.Server.EmailVerificationExpHour
+	if expHour <= 0 {
+		expHour = defaultEmailVerificationExpHour
+	}
+
+	if err := s.emailVerificationRepo.InvalidateByUserId(ctx, userId); err != nil {
+		return "", err
+	}
+
+	if err := s.emailVerificationRepo.Create(ctx, core.EmailVerificationTokenModel{
+		UserId:    userId,
+		Email:     email,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(time.Hour * time.Duration(expHour)),
+	}); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Consume marks the token as used and returns the user and the email it confirms.
+func (s EmailVerificationService) Consume(ctx context.Context, token string) (core.EmailVerification, error) {
+	model, err := s.emailVerificationRepo.ByHashForUpdate(ctx, utils.HashToken(token))
+	if err != nil {
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return core.EmailVerification{}, apperrors.InvalidToken
+		}
+
+		return core.EmailVerification{}, err
+	}
+
+	if model.UsedAt != nil {
+		return core.EmailVerification{}, apperrors.InvalidToken
+	}
+
+	if time.Now().After(model.ExpiresAt) {
+		return core.EmailVerification{}, apperrors.ExpiredToken
+	}
+
+	if err := s.emailVerificationRepo.MarkUsed(ctx, model.Id); err != nil {
+		return core.EmailVerification{}, err
+	}
+
+	return core.EmailVerification{
+		UserId: model.UserId,
+		Email:  model.Email,
+	}, nil
+}
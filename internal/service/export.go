@@ -0,0 +1,472 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/migmatore/study-platform-api/config"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultExportExpHour = 24
+	// exportTimeout bounds a single export. Jobs which are not finished by then are considered
+	// interrupted, so they do not block new exports forever.
+	exportTimeout = 30 * time.Minute
+)
+
+type ExportRepo interface {
+	Create(ctx context.Context, institutionId, requestedBy int) (core.ExportJobModel, error)
+	ById(ctx context.Context, id int) (core.ExportJobModel, error)
+	ByTokenHash(ctx context.Context, hash string) (core.ExportJobModel, error)
+	ByInstitutionId(ctx context.Context, institutionId int) ([]core.ExportJobModel, error)
+	InProgress(ctx context.Context, institutionId int, startedAfter time.Time) (bool, error)
+	SetProgress(ctx context.Context, id int, status string, progress int) error
+	Finish(ctx context.Context, id int, fileName string, expiresAt time.Time) error
+	Fail(ctx context.Context, id int, message string) error
+	SetTokenHash(ctx context.Context, id int, hash string) error
+	Expired(ctx context.Context, before time.Time) ([]core.ExportJobModel, error)
+	ClearFile(ctx context.Context, id int) error
+	Users(ctx context.Context, institutionId int) ([]core.ExportUserRecord, error)
+	Classrooms(ctx context.Context, institutionId int) ([]core.ExportClassroomRecord, error)
+	Staff(ctx context.Context, institutionId int) ([]core.ExportStaffRecord, error)
+	Enrollments(ctx context.Context, institutionId int) ([]core.ExportEnrollmentRecord, error)
+	Lessons(ctx context.Context, institutionId int) ([]core.ExportLessonRecord, error)
+}
+
+// exportDataset is a pair of files in the archive. load returns the records for the JSON file and
+// the same records as rows of the CSV file, the header first.
+type exportDataset struct {
+	name string
+	load func(ctx context.Context, institutionId int) (interface{}, [][]string, error)
+}
+
+type ExportService struct {
+	config     *config.Config
+	exportRepo ExportRepo
+}
+
+func NewExportService(config *config.Config, exportRepo ExportRepo) *ExportService {
+	return &ExportService{config: config, exportRepo: exportRepo}
+}
+
+// Create adds a pending export of the institution. Only one export of an institution runs at a time.
+func (s ExportService) Create(ctx context.Context, institutionId, requestedBy int) (core.ExportJob, error) {
+	inProgress, err := s.exportRepo.InProgress(ctx, institutionId, time.Now().Add(-exportTimeout))
+	if err != nil {
+		return core.ExportJob{}, err
+	}
+
+	if inProgress {
+		return core.ExportJob{}, apperrors.ExportInProgress
+	}
+
+	model, err := s.exportRepo.Create(ctx, institutionId, requestedBy)
+	if err != nil {
+		return core.ExportJob{}, err
+	}
+
+	return exportJobFromModel(model), nil
+}
+
+// Start builds the archive of the job in the background. The job has to be committed before, the
+// export runs outside the request and its transaction.
+func (s ExportService) Start(job core.ExportJob) {
+	go s.run(job)
+}
+
+func (s ExportService) ById(ctx context.Context, id int) (core.ExportJob, error) {
+	model, err := s.exportRepo.ById(ctx, id)
+	if err != nil {
+		return core.ExportJob{}, err
+	}
+
+	return exportJobFromModel(model), nil
+}
+
+func (s ExportService) InstitutionJobs(ctx context.Context, institutionId int) ([]core.ExportJob, error) {
+	models, err := s.exportRepo.ByInstitutionId(ctx, institutionId)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]core.ExportJob, 0, len(models))
+
+	for _, model := range models {
+		jobs = append(jobs, exportJobFromModel(model))
+	}
+
+	return jobs, nil
+}
+
+// IssueLink returns a new download token of the finished job. It works until the job expires, links
+// issued before stop working.
+func (s ExportService) IssueLink(ctx context.Context, job core.ExportJob) (core.ExportLink, error) {
+	if job.Status != core.ExportDone || job.FileName == nil || job.ExpiresAt == nil || job.ExpiresAt.Before(time.Now()) {
+		return core.ExportLink{}, apperrors.ExportNotReady
+	}
+
+	token, err := utils.RandomToken(32)
+	if err != nil {
+		return core.ExportLink{}, err
+	}
+
+	if err := s.exportRepo.SetTokenHash(ctx, job.Id, utils.HashToken(token)); err != nil {
+		return core.ExportLink{}, err
+	}
+
+	return core.ExportLink{Token: token, ExpiresAt: *job.ExpiresAt}, nil
+}
+
+// ByToken returns the file the download token was issued for.
+func (s ExportService) ByToken(ctx context.Context, token string) (core.ExportFile, error) {
+	model, err := s.exportRepo.ByTokenHash(ctx, utils.HashToken(token))
+	if err != nil {
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return core.ExportFile{}, apperrors.InvalidToken
+		}
+
+		return core.ExportFile{}, err
+	}
+
+	if model.FileName == nil || model.ExpiresAt == nil {
+		return core.ExportFile{}, apperrors.InvalidToken
+	}
+
+	if model.ExpiresAt.Before(time.Now()) {
+		return core.ExportFile{}, apperrors.ExpiredToken
+	}
+
+	path := filepath.Join(s.dir(), *model.FileName)
+
+	// The directory may have been cleaned up outside the application.
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return core.ExportFile{}, apperrors.EntityNotFound
+		}
+
+		return core.ExportFile{}, err
+	}
+
+	return core.ExportFile{Path: path, Name: *model.FileName}, nil
+}
+
+func (s ExportService) run(job core.ExportJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	// There is no scheduler, so the files of expired exports are removed whenever a new one starts.
+	_ = s.removeExpired(ctx)
+
+	fileName := fmt.Sprintf("institution-%d-export-%d.zip", job.InstitutionId, job.Id)
+	path := filepath.Join(s.dir(), fileName)
+
+	if err := s.write(ctx, job, path); err != nil {
+		_ = os.Remove(path)
+
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = errors.New("the export took too long")
+		}
+
+		// The context may be over already, the failure has to be saved anyway.
+		_ = s.exportRepo.Fail(context.Background(), job.Id, err.Error())
+
+		return
+	}
+
+	expHour := s.config.Server.ExportExpHour
+	if expHour <= 0 {
+		expHour = defaultExportExpHour
+	}
+
+	if err := s.exportRepo.Finish(ctx, job.Id, fileName, time.Now().Add(time.Hour*time.Duration(expHour))); err != nil {
+		_ = os.Remove(path)
+		_ = s.exportRepo.Fail(context.Background(), job.Id, err.Error())
+	}
+}
+
+// write saves every dataset of the institution as a CSV and a JSON file in the archive at the path.
+func (s ExportService) write(ctx context.Context, job core.ExportJob, path string) error {
+	if err := s.exportRepo.SetProgress(ctx, job.Id, string(core.ExportRunning), 0); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	archive := zip.NewWriter(f)
+
+	datasets := []exportDataset{
+		{name: "users", load: s.users},
+		{name: "classrooms", load: s.classrooms},
+		{name: "classroom_staff", load: s.staff},
+		{name: "enrollments", load: s.enrollments},
+		{name: "lessons", load: s.lessons},
+	}
+
+	for i, dataset := range datasets {
+		records, table, err := dataset.load(ctx, job.InstitutionId)
+		if err != nil {
+			return err
+		}
+
+		if err := writeExportJSON(archive, dataset.name+".json", records); err != nil {
+			return err
+		}
+
+		if err := writeExportCSV(archive, dataset.name+".csv", table); err != nil {
+			return err
+		}
+
+		// The last percent is left for closing the archive.
+		progress := (i + 1) * 99 / len(datasets)
+
+		if err := s.exportRepo.SetProgress(ctx, job.Id, string(core.ExportRunning), progress); err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+func (s ExportService) removeExpired(ctx context.Context) error {
+	jobs, err := s.exportRepo.Expired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		if err := os.Remove(filepath.Join(s.dir(), *job.FileName)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		if err := s.exportRepo.ClearFile(ctx, job.Id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s ExportService) dir() string {
+	if s.config.Server.ExportDir != "" {
+		return s.config.Server.ExportDir
+	}
+
+	return filepath.Join(os.TempDir(), "study-platform-exports")
+}
+
+func (s ExportService) users(ctx context.Context, institutionId int) (interface{}, [][]string, error) {
+	users, err := s.exportRepo.Users(ctx, institutionId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	table := [][]string{{"id", "full_name", "email", "phone", "role", "email_verified"}}
+
+	for _, u := range users {
+		table = append(table, []string{
+			strconv.Itoa(u.Id),
+			u.FullName,
+			u.Email,
+			exportString(u.Phone),
+			u.Role,
+			strconv.FormatBool(u.EmailVerified),
+		})
+	}
+
+	return users, table, nil
+}
+
+func (s ExportService) classrooms(ctx context.Context, institutionId int) (interface{}, [][]string, error) {
+	classrooms, err := s.exportRepo.Classrooms(ctx, institutionId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	table := [][]string{{"id", "title", "description", "teacher_id", "max_students", "archived_at"}}
+
+	for _, c := range classrooms {
+		table = append(table, []string{
+			strconv.Itoa(c.Id),
+			c.Title,
+			exportString(c.Description),
+			strconv.Itoa(c.TeacherId),
+			strconv.Itoa(c.MaxStudents),
+			exportTime(c.ArchivedAt),
+		})
+	}
+
+	return classrooms, table, nil
+}
+
+func (s ExportService) staff(ctx context.Context, institutionId int) (interface{}, [][]string, error) {
+	staff, err := s.exportRepo.Staff(ctx, institutionId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	table := [][]string{{"classroom_id", "user_id", "role"}}
+
+	for _, m := range staff {
+		table = append(table, []string{strconv.Itoa(m.ClassroomId), strconv.Itoa(m.UserId), m.Role})
+	}
+
+	return staff, table, nil
+}
+
+func (s ExportService) enrollments(ctx context.Context, institutionId int) (interface{}, [][]string, error) {
+	enrollments, err := s.exportRepo.Enrollments(ctx, institutionId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	table := [][]string{{"classroom_id", "student_id"}}
+
+	for _, e := range enrollments {
+		table = append(table, []string{strconv.Itoa(e.ClassroomId), strconv.Itoa(e.StudentId)})
+	}
+
+	return enrollments, table, nil
+}
+
+// lessons puts the content of a lesson in a single CSV cell as JSON, it has no flat form.
+func (s ExportService) lessons(ctx context.Context, institutionId int) (interface{}, [][]string, error) {
+	lessons, err := s.exportRepo.Lessons(ctx, institutionId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	table := [][]string{{"id", "classroom_id", "title", "active", "content"}}
+
+	for _, l := range lessons {
+		content := ""
+
+		if l.Content != nil {
+			b, err := json.Marshal(l.Content)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			content = string(b)
+		}
+
+		table = append(table, []string{
+			strconv.Itoa(l.Id),
+			strconv.Itoa(l.ClassroomId),
+			l.Title,
+			strconv.FormatBool(l.Active),
+			content,
+		})
+	}
+
+	return lessons, table, nil
+}
+
+func writeExportJSON(archive *zip.Writer, name string, records interface{}) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(records)
+}
+
+// writeExportCSV starts the file with a byte order mark, so spreadsheet apps read it as UTF-8. Cells
+// which spreadsheet apps would run as formulas are escaped.
+func writeExportCSV(archive *zip.Writer, name string, table [][]string) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, "\xef\xbb\xbf"); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+
+	for _, record := range table {
+		escaped := make([]string, len(record))
+
+		for i, value := range record {
+			escaped[i] = csvCell(value)
+		}
+
+		if err := cw.Write(escaped); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// csvCell prefixes the values starting like a formula with an apostrophe, which spreadsheet apps
+// take as a mark of text. Otherwise a name like "=HYPERLINK(...)" entered by a user would be run
+// by whoever opens the export.
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
+
+func exportString(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
+
+func exportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+func exportJobFromModel(model core.ExportJobModel) core.ExportJob {
+	return core.ExportJob{
+		Id:            model.Id,
+		InstitutionId: model.InstitutionId,
+		RequestedBy:   model.RequestedBy,
+		Status:        core.ExportStatus(model.Status),
+		Progress:      model.Progress,
+		Error:         model.Error,
+		FileName:      model.FileName,
+		ExpiresAt:     model.ExpiresAt,
+		CreatedAt:     model.CreatedAt,
+		FinishedAt:    model.FinishedAt,
+	}
+}
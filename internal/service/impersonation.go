@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"github.com/migmatore/study-platform-api/internal/core"
+)
+
+type ImpersonationRepo interface {
+	Create(ctx context.Context, event core.ImpersonationEventModel) error
+}
+
+type ImpersonationService struct {
+	impersonationRepo ImpersonationRepo
+}
+
+func NewImpersonationService(impersonationRepo ImpersonationRepo) *ImpersonationService {
+	return &ImpersonationService{impersonationRepo: impersonationRepo}
+}
+
+func (s ImpersonationService) Record(ctx context.Context, event core.ImpersonationEvent) error {
+	return s.impersonationRepo.Create(ctx, core.ImpersonationEventModel{
+		AdminId:   event.AdminId,
+		UserId:    event.UserId,
+		Event:     string(event.Event),
+		Method:    optionalString(event.Method),
+		Path:      optionalString(event.Path),
+		IP:        optionalString(event.Client.IP),
+		UserAgent: optionalString(event.Client.UserAgent),
+	})
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/xlsx"
+	"path/filepath"
+	"strings"
+)
+
+// importColumns maps the accepted header names to the columns of the import file.
+var importColumns = map[string]string{
+	"full_name":     "full_name",
+	"name":          "full_name",
+	"email":         "email",
+	"e_mail":        "email",
+	"phone":         "phone",
+	"role":          "role",
+	"classrooms":    "classrooms",
+	"classroom":     "classrooms",
+	"classrooms_id": "classrooms",
+}
+
+var requiredImportColumns = []string{"full_name", "email", "role"}
+
+// importMaxColumns limits the width of an XLSX file, the accounts need only a few columns.
+const importMaxColumns = 256
+
+type ImportService struct{}
+
+func NewImportService() *ImportService {
+	return &ImportService{}
+}
+
+// Rows reads the accounts from the CSV or XLSX file. The first row is the header, the columns are
+// found by their names, so their order does not matter. Empty rows are skipped. An XLSX file with more
+// than maxRows rows below the header is rejected before its rows are unpacked, since a few bytes of
+// it can describe a huge table. A CSV file takes no more memory than its size.
+func (s ImportService) Rows(file core.ImportFile, maxRows int) ([]core.ImportRow, error) {
+	var (
+		table [][]string
+		err   error
+	)
+
+	switch strings.ToLower(filepath.Ext(file.Name)) {
+	case ".csv":
+		table, err = readCSV(file.Content)
+	case ".xlsx":
+		table, err = xlsx.ReadRows(bytes.NewReader(file.Content), int64(len(file.Content)), maxRows+1, importMaxColumns)
+		if errors.Is(err, xlsx.ErrInvalidFile) || errors.Is(err, xlsx.ErrTooLarge) {
+			err = fmt.Errorf("%w: %v", apperrors.InvalidImportFile, err)
+		}
+	default:
+		err = fmt.Errorf("%w: only csv and xlsx files are supported", apperrors.InvalidImportFile)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(table) == 0 {
+		return nil, fmt.Errorf("%w: the file is empty", apperrors.InvalidImportFile)
+	}
+
+	columns := make(map[string]int)
+
+	for i, name := range table[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		name = strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+
+		if column, ok := importColumns[name]; ok {
+			columns[column] = i
+		}
+	}
+
+	for _, column := range requiredImportColumns {
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("%w: the %s column is missing", apperrors.InvalidImportFile, column)
+		}
+	}
+
+	cell := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+
+		return strings.TrimSpace(record[i])
+	}
+
+	rows := make([]core.ImportRow, 0, len(table)-1)
+
+	for i, record := range table[1:] {
+		row := core.ImportRow{
+			Line:       i + 2,
+			FullName:   cell(record, "full_name"),
+			Email:      cell(record, "email"),
+			Phone:      cell(record, "phone"),
+			Role:       cell(record, "role"),
+			Classrooms: cell(record, "classrooms"),
+		}
+
+		if row.FullName == "" && row.Email == "" && row.Phone == "" && row.Role == "" && row.Classrooms == "" {
+			continue
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// readCSV reads comma or semicolon separated values, spreadsheet apps use the latter in many locales.
+func readCSV(content []byte) ([][]string, error) {
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+
+	header, _, _ := bytes.Cut(content, []byte("\n"))
+
+	r := csv.NewReader(bytes.NewReader(content))
+	r.FieldsPerRecord = -1
+
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		r.Comma = ';'
+	}
+
+	table, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", apperrors.InvalidImportFile, err)
+	}
+
+	return table, nil
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"github.com/migmatore/study-platform-api/config"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+	"strings"
+	"time"
+)
+
+const (
+	defaultInvitationExpHour = 7 * 24
+	maxJoinCodeExpHour       = 30 * 24
+	// joinCodeAlphabet has no characters that are easy to confuse, like 0 and O or 1 and I.
+	joinCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	joinCodeLength   = 10
+)
+
+type InvitationRepo interface {
+	Create(ctx context.Context, inv core.InvitationModel) (core.InvitationModel, error)
+	ById(ctx context.Context, id int) (core.InvitationModel, error)
+	ByHashForUpdate(ctx context.Context, hash string) (core.InvitationModel, error)
+	ActiveByClassroomId(ctx context.Context, classroomId int) ([]core.InvitationModel, error)
+	IncrementUses(ctx context.Context, id int) error
+	Revoke(ctx context.Context, id int) error
+}
+
+type InvitationService struct {
+	config         *config.Config
+	invitationRepo InvitationRepo
+}
+
+func NewInvitationService(config *config.Config, invitationRepo InvitationRepo) *InvitationService {
+	return &InvitationService{config: config, invitationRepo: invitationRepo}
+}
+
+// CreateInvitation issues a single-use invitation for the email and returns it with its token.
+func (s InvitationService) CreateInvitation(
+	ctx context.Context,
+	classroomId int,
+	createdBy int,
+	email string,
+) (core.Invitation, string, error) {
+	token, err := utils.RandomToken(32)
+	if err != nil {
+		return core.Invitation{}, "", err
+	}
+
+	expHour := s.config.Server.InvitationExpHour
+	if expHour <= 0 {
+		expHour = defaultInvitationExpHour
+	}
+
+	maxUses := 1
+
+	inv, err := s.invitationRepo.Create(ctx, core.InvitationModel{
+		TokenHash:   utils.HashToken(token),
+		ClassroomId: classroomId,
+		CreatedBy:   createdBy,
+		Email:       &email,
+		MaxUses:     &maxUses,
+		ExpiresAt:   time.Now().Add(time.Hour * time.Duration(expHour)),
+	})
+	if err != nil {
+		return core.Invitation{}, "", err
+	}
+
+	return invitationFromModel(inv), token, nil
+}
+
+// CreateJoinCode issues a code the whole class can sign up with. Without maxUses the number of uses
+// is limited only by the size of the classroom.
+func (s InvitationService) CreateJoinCode(
+	ctx context.Context,
+	classroomId int,
+	createdBy int,
+	expHour int,
+	maxUses *int,
+) (core.Invitation, string, error) {
+	if expHour <= 0 {
+		expHour = s.config.Server.InvitationExpHour
+	}
+
+	if expHour <= 0 {
+		expHour = defaultInvitationExpHour
+	}
+
+	if expHour > maxJoinCodeExpHour {
+		expHour = maxJoinCodeExpHour
+	}
+
+	code, err := generateJoinCode()
+	if err != nil {
+		return core.Invitation{}, "", err
+	}
+
+	inv, err := s.invitationRepo.Create(ctx, core.InvitationModel{
+		TokenHash:   utils.HashToken(normalizeJoinCode(code)),
+		ClassroomId: classroomId,
+		CreatedBy:   createdBy,
+		MaxUses:     maxUses,
+		ExpiresAt:   time.Now().Add(time.Hour * time.Duration(expHour)),
+	})
+	if err != nil {
+		return core.Invitation{}, "", err
+	}
+
+	return invitationFromModel(inv), code, nil
+}
+
+func (s InvitationService) ById(ctx context.Context, id int) (core.Invitation, error) {
+	inv, err := s.invitationRepo.ById(ctx, id)
+	if err != nil {
+		return core.Invitation{}, err
+	}
+
+	return invitationFromModel(inv), nil
+}
+
+func (s InvitationService) ActiveByClassroomId(ctx context.Context, classroomId int) ([]core.Invitation, error) {
+	models, err := s.invitationRepo.ActiveByClassroomId(ctx, classroomId)
+	if err != nil {
+		return nil, err
+	}
+
+	invitations := make([]core.Invitation, 0, len(models))
+
+	for _, inv := range models {
+		invitations = append(invitations, invitationFromModel(inv))
+	}
+
+	return invitations, nil
+}
+
+// ByTokenForUpdate returns the usable personal invitation and locks it until the end of the transaction.
+func (s InvitationService) ByTokenForUpdate(ctx context.Context, token string) (core.Invitation, error) {
+	return s.usableForUpdate(ctx, utils.HashToken(token))
+}
+
+// ByCodeForUpdate returns the usable join code and locks it until the end of the transaction.
+func (s InvitationService) ByCodeForUpdate(ctx context.Context, code string) (core.Invitation, error) {
+	return s.usableForUpdate(ctx, utils.HashToken(normalizeJoinCode(code)))
+}
+
+func (s InvitationService) Use(ctx context.Context, id int) error {
+	return s.invitationRepo.IncrementUses(ctx, id)
+}
+
+func (s InvitationService) Revoke(ctx context.Context, id int) error {
+	return s.invitationRepo.Revoke(ctx, id)
+}
+
+func (s InvitationService) usableForUpdate(ctx context.Context, hash string) (core.Invitation, error) {
+	inv, err := s.invitationRepo.ByHashForUpdate(ctx, hash)
+	if err != nil {
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return core.Invitation{}, apperrors.InvalidToken
+		}
+
+		return core.Invitation{}, err
+	}
+
+	if inv.RevokedAt != nil || (inv.MaxUses != nil && inv.Uses >= *inv.MaxUses) {
+		return core.Invitation{}, apperrors.InvalidToken
+	}
+
+	if time.Now().After(inv.ExpiresAt) {
+		return core.Invitation{}, apperrors.ExpiredToken
+	}
+
+	return invitationFromModel(inv), nil
+}
+
+func invitationFromModel(inv core.InvitationModel) core.Invitation {
+	return core.Invitation{
+		Id:          inv.Id,
+		ClassroomId: inv.ClassroomId,
+		CreatedBy:   inv.CreatedBy,
+		Email:       inv.Email,
+		MaxUses:     inv.MaxUses,
+		Uses:        inv.Uses,
+		ExpiresAt:   inv.ExpiresAt,
+		RevokedAt:   inv.RevokedAt,
+		CreatedAt:   inv.CreatedAt,
+	}
+}
+
+// generateJoinCode returns a code like "K3JQD-9Q2MX".
+func generateJoinCode() (string, error) {
+	b := make([]byte, joinCodeLength)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	code := make([]byte, joinCodeLength)
+	for i := range b {
+		code[i] = joinCodeAlphabet[int(b[i])%len(joinCodeAlphabet)]
+	}
+
+	return string(code[:joinCodeLength/2]) + "-" + string(code[joinCodeLength/2:]), nil
+}
+
+func normalizeJoinCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.ReplaceAll(code, "-", "")
+
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -15,6 +15,7 @@ type LessonRepo interface {
 
 type LessonClassroomRepo interface {
 	ById(ctx context.Context, id int) (core.ClassroomModel, error)
+	IsStaff(ctx context.Context, classroomId, userId int) (bool, error)
 }
 
 type LessonService struct {
@@ -30,6 +31,7 @@ func (s LessonService) Create(ctx context.Context, lesson core.Lesson) (core.Les
 	newLesson, err := s.lessonRepo.Insert(ctx, core.LessonModel{
 		Title:       lesson.Title,
 		ClassroomId: lesson.ClassroomId,
+		Content:     lesson.Content,
 		Active:      lesson.Active,
 	})
 	if err != nil {
@@ -100,10 +102,5 @@ func (s LessonService) IsBelongs(ctx context.Context, lessonId int, teacherId in
 		return false, err
 	}
 
-	classroom, err := s.classroomRepo.ById(ctx, lesson.ClassroomId)
-	if err != nil {
-		return false, err
-	}
-
-	return classroom.TeacherId == teacherId, nil
+	return s.classroomRepo.IsStaff(ctx, lesson.ClassroomId, teacherId)
 }
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/migmatore/study-platform-api/config"
+	"github.com/migmatore/study-platform-api/pkg/mailer"
+	"net/url"
+	"strings"
+)
+
+type MailService struct {
+	config *config.Config
+	mailer mailer.Mailer
+}
+
+func NewMailService(config *config.Config, mailer mailer.Mailer) *MailService {
+	return &MailService{config: config, mailer: mailer}
+}
+
+func (s MailService) SendPasswordReset(ctx context.Context, to string, fullName string, token string) error {
+	link := s.link("/reset-password", token)
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      []string{to},
+		Subject: "Password reset",
+		Body: fmt.Sprintf(
+			"Hello, %s!\n\nTo set a new password follow the link:\n%s\n\n"+
+				"If you did not request a password reset, just ignore this email.",
+			fullName,
+			link,
+		),
+	})
+}
+
+func (s MailService) SendEmailVerification(ctx context.Context, to string, fullName string, token string) error {
+	link := s.link("/verify-email", token)
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      []string{to},
+		Subject: "Email confirmation",
+		Body: fmt.Sprintf(
+			"Hello, %s!\n\nTo confirm your email address follow the link:\n%s\n\n"+
+				"If you did not register or change your email, just ignore this email.",
+			fullName,
+			link,
+		),
+	})
+}
+
+func (s MailService) SendInvitation(ctx context.Context, to string, classroomTitle string, token string) error {
+	link := s.link("/signup/invite", token)
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      []string{to},
+		Subject: "Invitation to " + classroomTitle,
+		Body: fmt.Sprintf(
+			"Hello!\n\nYou have been invited to join the classroom \"%s\". To create your account "+
+				"follow the link:\n%s\n\nIf you do not know what this is about, just ignore this email.",
+			classroomTitle,
+			link,
+		),
+	})
+}
+
+// SendWaitlistPromotion tells the staff of the classroom which students got a seat from its waitlist.
+func (s MailService) SendWaitlistPromotion(
+	ctx context.Context,
+	to []string,
+	classroomTitle string,
+	studentsName []string,
+) error {
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      to,
+		Subject: "New students in " + classroomTitle,
+		Body: fmt.Sprintf(
+			"Hello!\n\nA seat opened up in the classroom \"%s\", so these students were enrolled "+
+				"from the waitlist:\n%s",
+			classroomTitle,
+			strings.Join(studentsName, "\n"),
+		),
+	})
+}
+
+// link builds a link to the frontend page with the token in the query string.
+func (s MailService) link(path string, token string) string {
+	return fmt.Sprintf(
+		"%s%s?token=%s",
+		strings.TrimRight(s.config.Server.AppURL, "/"),
+		path,
+		url.QueryEscape(token),
+	)
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"github.com/migmatore/study-platform-api/config"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/totp"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMFAIssuer          = "Study Platform"
+	defaultMFAChallengeExpMin = 5
+	mfaChallengeMaxAttempts   = 5
+	recoveryCodesCount        = 10
+)
+
+type MFARepo interface {
+	ByUserId(ctx context.Context, userId int) (core.MFAModel, error)
+	ByUserIdForUpdate(ctx context.Context, userId int) (core.MFAModel, error)
+	Upsert(ctx context.Context, userId int, secret string) error
+	Confirm(ctx context.Context, userId int, step int64) error
+	SetLastUsedStep(ctx context.Context, userId int, step int64) error
+	Delete(ctx context.Context, userId int) error
+	ReplaceRecoveryCodes(ctx context.Context, userId int, hashes []string) error
+	UseRecoveryCode(ctx context.Context, userId int, hash string) (bool, error)
+	CreateChallenge(ctx context.Context, challenge core.MFAChallengeModel) error
+	ChallengeByHashForUpdate(ctx context.Context, hash string) (core.MFAChallengeModel, error)
+	IncrementChallengeAttempts(ctx context.Context, id int) error
+	MarkChallengeUsed(ctx context.Context, id int) error
+}
+
+type MFAService struct {
+	config  *config.Config
+	mfaRepo MFARepo
+}
+
+func NewMFAService(config *config.Config, mfaRepo MFARepo) *MFAService {
+	return &MFAService{config: config, mfaRepo: mfaRepo}
+}
+
+// IsEnabled reports whether the user has a confirmed second factor.
+func (s MFAService) IsEnabled(ctx context.Context, userId int) (bool, error) {
+	m, err := s.mfaRepo.ByUserId(ctx, userId)
+	if err != nil {
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return m.ConfirmedAt != nil, nil
+}
+
+// Enroll generates a new secret for the user. The secret is not used for sign in until it is confirmed.
+func (s MFAService) Enroll(ctx context.Context, userId int, account string) (core.MFAEnrollment, error) {
+	enabled, err := s.IsEnabled(ctx, userId)
+	if err != nil {
+		return core.MFAEnrollment{}, err
+	}
+
+	if enabled {
+		return core.MFAEnrollment{}, apperrors.EntityAlreadyExist
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return core.MFAEnrollment{}, err
+	}
+
+	if err := s.mfaRepo.Upsert(ctx, userId, secret); err != nil {
+		return core.MFAEnrollment{}, err
+	}
+
+	issuer := s.config.Server.MFAIssuer
+	if issuer == "" {
+		issuer = defaultMFAIssuer
+	}
+
+	return core.MFAEnrollment{
+		Secret: secret,
+		URI:    totp.URI(issuer, account, secret),
+	}, nil
+}
+
+// Confirm enables the enrolled secret once the user proves it was imported by sending a valid code.
+// It returns the recovery codes, which are never shown again.
+func (s MFAService) Confirm(ctx context.Context, userId int, code string) ([]string, error) {
+	m, err := s.mfaRepo.ByUserIdForUpdate(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.ConfirmedAt != nil {
+		return nil, apperrors.EntityAlreadyExist
+	}
+
+	step, ok := totp.Validate(m.Secret, code, time.Now())
+	if !ok {
+		return nil, apperrors.InvalidMFACode
+	}
+
+	if err := s.mfaRepo.Confirm(ctx, userId, step); err != nil {
+		return nil, err
+	}
+
+	return s.RegenerateRecoveryCodes(ctx, userId)
+}
+
+// Verify checks a code from the authenticator app or an unused recovery code. A code from the app
+// is accepted only once.
+func (s MFAService) Verify(ctx context.Context, userId int, code string) (bool, error) {
+	m, err := s.mfaRepo.ByUserIdForUpdate(ctx, userId)
+	if err != nil {
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	if m.ConfirmedAt == nil {
+		return false, nil
+	}
+
+	if step, ok := totp.Validate(m.Secret, code, time.Now()); ok {
+		if step <= m.LastUsedStep {
+			return false, nil
+		}
+
+		if err := s.mfaRepo.SetLastUsedStep(ctx, userId, step); err != nil {
+			return false, err
+		}
+
+		return true, nil
+	}
+
+	return s.mfaRepo.UseRecoveryCode(ctx, userId, utils.HashToken(normalizeRecoveryCode(code)))
+}
+
+func (s MFAService) Disable(ctx context.Context, userId int) error {
+	return s.mfaRepo.Delete(ctx, userId)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user with a new set.
+func (s MFAService) RegenerateRecoveryCodes(ctx context.Context, userId int) ([]string, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+
+	for i := 0; i < recoveryCodesCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		codes = append(codes, code)
+		hashes = append(hashes, utils.HashToken(normalizeRecoveryCode(code)))
+	}
+
+	if err := s.mfaRepo.ReplaceRecoveryCodes(ctx, userId, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// CreateChallenge issues a short-lived token that stands for a sign in waiting for the second factor.
+func (s MFAService) CreateChallenge(ctx context.Context, userId int) (string, error) {
+	token, err := utils.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	expMin := s.config.Server.MFAChallengeExpMin
+	if expMin <= 0 {
+		expMin = defaultMFAChallengeExpMin
+	}
+
+	if err := s.mfaRepo.CreateChallenge(ctx, core.MFAChallengeModel{
+		UserId:    userId,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(time.Minute * time.Duration(expMin)),
+	}); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// ChallengeForUpdate returns the pending challenge of the token and locks it until the end of the transaction.
+func (s MFAService) ChallengeForUpdate(ctx context.Context, token string) (core.MFAChallenge, error) {
+	model, err := s.mfaRepo.ChallengeByHashForUpdate(ctx, utils.HashToken(token))
+	if err != nil {
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return core.MFAChallenge{}, apperrors.InvalidToken
+		}
+
+		return core.MFAChallenge{}, err
+	}
+
+	if model.UsedAt != nil || model.Attempts >= mfaChallengeMaxAttempts {
+		return core.MFAChallenge{}, apperrors.InvalidToken
+	}
+
+	if time.Now().After(model.ExpiresAt) {
+		return core.MFAChallenge{}, apperrors.ExpiredToken
+	}
+
+	return core.MFAChallenge{
+		Id:        model.Id,
+		UserId:    model.UserId,
+		Attempts:  model.Attempts,
+		ExpiresAt: model.ExpiresAt,
+		UsedAt:    model.UsedAt,
+	}, nil
+}
+
+// FailChallenge counts a wrong code. The challenge stops working after too many of them.
+func (s MFAService) FailChallenge(ctx context.Context, challenge core.MFAChallenge) error {
+	if err := s.mfaRepo.IncrementChallengeAttempts(ctx, challenge.Id); err != nil {
+		return err
+	}
+
+	if challenge.Attempts+1 >= mfaChallengeMaxAttempts {
+		return s.mfaRepo.MarkChallengeUsed(ctx, challenge.Id)
+	}
+
+	return nil
+}
+
+func (s MFAService) CompleteChallenge(ctx context.Context, id int) error {
+	return s.mfaRepo.MarkChallengeUsed(ctx, id)
+}
+
+// generateRecoveryCode returns a code like "k3j5d-9q2mx".
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(b))[:10]
+
+	return code[:5] + "-" + code[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -0,0 +1,335 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/migmatore/study-platform-api/config"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/oidc"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+	"strings"
+	"time"
+)
+
+const (
+	defaultOIDCHTTPTimeoutSec = 10
+	defaultOIDCStateExpMin    = 10
+	defaultOIDCScopes         = "openid email profile"
+	defaultOIDCRoleClaim      = "roles"
+)
+
+// rolePriority decides which role a user gets when the claim matches several of them.
+var rolePriority = map[core.RoleType]int{
+	core.StudentRole: 1,
+	core.TeacherRole: 2,
+	core.AdminRole:   3,
+}
+
+type OIDCRepo interface {
+	ProviderByInstitutionId(ctx context.Context, institutionId int) (core.OIDCProviderModel, error)
+	SaveProvider(ctx context.Context, p core.OIDCProviderModel) error
+	CreateState(ctx context.Context, state core.OIDCStateModel) error
+	UseState(ctx context.Context, hash string) (core.OIDCStateModel, error)
+	UserIdByIdentity(ctx context.Context, issuer string, subject string) (int, error)
+	LinkIdentity(ctx context.Context, userId int, issuer string, subject string) error
+}
+
+type OIDCService struct {
+	config   *config.Config
+	oidcRepo OIDCRepo
+	client   *oidc.Client
+}
+
+func NewOIDCService(config *config.Config, oidcRepo OIDCRepo) *OIDCService {
+	timeout := config.OIDC.HTTPTimeoutSec
+	if timeout <= 0 {
+		timeout = defaultOIDCHTTPTimeoutSec
+	}
+
+	return &OIDCService{
+		config:   config,
+		oidcRepo: oidcRepo,
+		client:   oidc.NewClient(time.Second * time.Duration(timeout)),
+	}
+}
+
+func (s OIDCService) Provider(ctx context.Context, institutionId int) (core.OIDCProvider, error) {
+	model, err := s.oidcRepo.ProviderByInstitutionId(ctx, institutionId)
+	if err != nil {
+		return core.OIDCProvider{}, err
+	}
+
+	roleMapping := make(map[string]core.RoleType, len(model.RoleMapping))
+	for value, role := range model.RoleMapping {
+		roleMapping[value] = core.RoleType(role)
+	}
+
+	var defaultRole *core.RoleType
+	if model.DefaultRole != nil {
+		role := core.RoleType(*model.DefaultRole)
+		defaultRole = &role
+	}
+
+	return core.OIDCProvider{
+		InstitutionId: model.InstitutionId,
+		Issuer:        model.Issuer,
+		ClientId:      model.ClientId,
+		ClientSecret:  model.ClientSecret,
+		Scopes:        strings.Fields(model.Scopes),
+		RoleClaim:     model.RoleClaim,
+		RoleMapping:   roleMapping,
+		DefaultRole:   defaultRole,
+		Enabled:       model.Enabled,
+	}, nil
+}
+
+// SaveProvider stores the provider after checking that its discovery document can be fetched.
+func (s OIDCService) SaveProvider(ctx context.Context, p core.OIDCProvider) error {
+	if p.Enabled {
+		if _, err := s.client.Discover(ctx, p.Issuer); err != nil {
+			return fmt.Errorf("%w: %v", apperrors.InvalidOIDCProvider, err)
+		}
+	}
+
+	scopes := strings.Join(p.Scopes, " ")
+	if scopes == "" {
+		scopes = defaultOIDCScopes
+	}
+
+	roleClaim := p.RoleClaim
+	if roleClaim == "" {
+		roleClaim = defaultOIDCRoleClaim
+	}
+
+	roleMapping := make(map[string]string, len(p.RoleMapping))
+	for value, role := range p.RoleMapping {
+		roleMapping[value] = string(role)
+	}
+
+	var defaultRole *string
+	if p.DefaultRole != nil {
+		role := string(*p.DefaultRole)
+		defaultRole = &role
+	}
+
+	return s.oidcRepo.SaveProvider(ctx, core.OIDCProviderModel{
+		InstitutionId: p.InstitutionId,
+		Issuer:        strings.TrimSuffix(p.Issuer, "/"),
+		ClientId:      p.ClientId,
+		ClientSecret:  p.ClientSecret,
+		Scopes:        scopes,
+		RoleClaim:     roleClaim,
+		RoleMapping:   roleMapping,
+		DefaultRole:   defaultRole,
+		Enabled:       p.Enabled,
+	})
+}
+
+// RedirectURI returns the callback address registered at the provider of the institution.
+func (s OIDCService) RedirectURI(institutionId int) string {
+	return fmt.Sprintf(
+		"%s/api/v1/auth/oidc/%d/callback",
+		strings.TrimSuffix(s.config.OIDC.RedirectBaseURL, "/"),
+		institutionId,
+	)
+}
+
+// AuthURL starts the authorization code flow and returns the address of the provider sign in page.
+func (s OIDCService) AuthURL(ctx context.Context, institutionId int) (string, error) {
+	p, err := s.enabledProvider(ctx, institutionId)
+	if err != nil {
+		return "", err
+	}
+
+	discovered, err := s.client.Discover(ctx, p.Issuer)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := utils.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	nonce, err := utils.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	verifier, err := utils.RandomToken(48)
+	if err != nil {
+		return "", err
+	}
+
+	expMin := s.config.OIDC.StateExpMin
+	if expMin <= 0 {
+		expMin = defaultOIDCStateExpMin
+	}
+
+	if err := s.oidcRepo.CreateState(ctx, core.OIDCStateModel{
+		StateHash:     utils.HashToken(state),
+		InstitutionId: institutionId,
+		Nonce:         nonce,
+		CodeVerifier:  verifier,
+		ExpiresAt:     time.Now().Add(time.Minute * time.Duration(expMin)),
+	}); err != nil {
+		return "", err
+	}
+
+	return oidc.AuthCodeURL(
+		discovered,
+		p.ClientId,
+		s.RedirectURI(institutionId),
+		p.Scopes,
+		state,
+		nonce,
+		verifier,
+	), nil
+}
+
+// Authenticate completes the flow: it checks the state, exchanges the code and verifies the ID token.
+func (s OIDCService) Authenticate(
+	ctx context.Context,
+	institutionId int,
+	code string,
+	state string,
+) (core.OIDCIdentity, error) {
+	stateModel, err := s.oidcRepo.UseState(ctx, utils.HashToken(state))
+	if err != nil {
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return core.OIDCIdentity{}, apperrors.InvalidToken
+		}
+
+		return core.OIDCIdentity{}, err
+	}
+
+	if stateModel.InstitutionId != institutionId {
+		return core.OIDCIdentity{}, apperrors.InvalidToken
+	}
+
+	if time.Now().After(stateModel.ExpiresAt) {
+		return core.OIDCIdentity{}, apperrors.ExpiredToken
+	}
+
+	p, err := s.enabledProvider(ctx, institutionId)
+	if err != nil {
+		return core.OIDCIdentity{}, err
+	}
+
+	discovered, err := s.client.Discover(ctx, p.Issuer)
+	if err != nil {
+		return core.OIDCIdentity{}, err
+	}
+
+	tokens, err := s.client.Exchange(
+		ctx,
+		discovered,
+		p.ClientId,
+		p.ClientSecret,
+		code,
+		s.RedirectURI(institutionId),
+		stateModel.CodeVerifier,
+	)
+	if err != nil {
+		if errors.Is(err, oidc.ErrExchange) {
+			return core.OIDCIdentity{}, fmt.Errorf("%w: %v", apperrors.InvalidToken, err)
+		}
+
+		return core.OIDCIdentity{}, err
+	}
+
+	claims, err := s.client.VerifyIDToken(ctx, discovered, p.ClientId, tokens.IDToken, stateModel.Nonce)
+	if err != nil {
+		if errors.Is(err, oidc.ErrInvalidIDToken) {
+			return core.OIDCIdentity{}, fmt.Errorf("%w: %v", apperrors.InvalidToken, err)
+		}
+
+		return core.OIDCIdentity{}, err
+	}
+
+	role, ok := mapRole(p, claims[p.RoleClaim])
+	if !ok {
+		return core.OIDCIdentity{}, apperrors.AccessDenied
+	}
+
+	email, _ := claims["email"].(string)
+	if email == "" {
+		return core.OIDCIdentity{}, fmt.Errorf("%w: the id token has no email", apperrors.InvalidToken)
+	}
+
+	emailVerified, _ := claims["email_verified"].(bool)
+
+	fullName, _ := claims["name"].(string)
+	if fullName == "" {
+		fullName = email
+	}
+
+	subject, _ := claims.GetSubject()
+
+	return core.OIDCIdentity{
+		Issuer:        p.Issuer,
+		Subject:       subject,
+		Email:         email,
+		EmailVerified: emailVerified,
+		FullName:      fullName,
+		Role:          role,
+	}, nil
+}
+
+func (s OIDCService) UserIdByIdentity(ctx context.Context, issuer string, subject string) (int, error) {
+	return s.oidcRepo.UserIdByIdentity(ctx, issuer, subject)
+}
+
+func (s OIDCService) LinkIdentity(ctx context.Context, userId int, issuer string, subject string) error {
+	return s.oidcRepo.LinkIdentity(ctx, userId, issuer, subject)
+}
+
+func (s OIDCService) enabledProvider(ctx context.Context, institutionId int) (core.OIDCProvider, error) {
+	p, err := s.Provider(ctx, institutionId)
+	if err != nil {
+		return core.OIDCProvider{}, err
+	}
+
+	if !p.Enabled {
+		return core.OIDCProvider{}, apperrors.EntityNotFound
+	}
+
+	return p, nil
+}
+
+// mapRole picks the role for the value of the role claim, which is either a string or a list of them.
+func mapRole(p core.OIDCProvider, claim interface{}) (core.RoleType, bool) {
+	var values []string
+
+	switch v := claim.(type) {
+	case string:
+		values = append(values, v)
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+
+	var role core.RoleType
+
+	for _, value := range values {
+		mapped, ok := p.RoleMapping[value]
+		if ok && rolePriority[mapped] > rolePriority[role] {
+			role = mapped
+		}
+	}
+
+	if role != "" {
+		return role, true
+	}
+
+	if p.DefaultRole != nil {
+		return *p.DefaultRole, true
+	}
+
+	return "", false
+}
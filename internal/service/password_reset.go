@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/migmatore/study-platform-api/config"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+	"time"
+)
+
+const defaultPasswordResetExpMin = 60
+
+type PasswordResetRepo interface {
+	Create(ctx context.Context, token core.PasswordResetTokenModel) error
+	ByHashForUpdate(ctx context.Context, hash string) (core.PasswordResetTokenModel, error)
+	MarkUsed(ctx context.Context, id int) error
+	InvalidateByUserId(ctx context.Context, userId int) error
+}
+
+type PasswordResetService struct {
+	config            *config.Config
+	passwordResetRepo PasswordResetRepo
+}
+
+func NewPasswordResetService(config *config.Config, passwordResetRepo PasswordResetRepo) *PasswordResetService {
+	return &PasswordResetService{config: config, passwordResetRepo: passwordResetRepo}
+}
+
+// Create issues a new reset token for the user. Previously issued tokens stop working.
+func (s PasswordResetService) Create(ctx context.Context, userId int) (string, error) {
+	token, err := utils.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	expMin := s.config.Server.PasswordResetExpMin
+	if expMin <= 0 {
+		expMin = defaultPasswordResetExpMin
+	}
+
+	if err := s.passwordResetRepo.InvalidateByUserId(ctx, userId); err != nil {
+		return "", err
+	}
+
+	if err := s.passwordResetRepo.Create(ctx, core.PasswordResetTokenModel{
+		UserId:    userId,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(time.Minute * time.Duration(expMin)),
+	}); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Consume marks the token as used and returns the id of the user it was issued for.
+func (s PasswordResetService) Consume(ctx context.Context, token string) (int, error) {
+	model, err := s.passwordResetRepo.ByHashForUpdate(ctx, utils.HashToken(token))
+	if err != nil {
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return 0, apperrors.InvalidToken
+		}
+
+		return 0, err
+	}
+
+	if model.UsedAt != nil {
+		return 0, apperrors.InvalidToken
+	}
+
+	if time.Now().After(model.ExpiresAt) {
+		return 0, apperrors.ExpiredToken
+	}
+
+	if err := s.passwordResetRepo.MarkUsed(ctx, model.Id); err != nil {
+		return 0, err
+	}
+
+	return model.UserId, nil
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"time"
+)
+
+type PrivacyRepo interface {
+	CreateErasure(ctx context.Context, userId int, requestedBy int, reason *string) (core.ErasureRequestModel, error)
+	ErasureById(ctx context.Context, id int) (core.ErasureRequestModel, error)
+	ErasureByIdForUpdate(ctx context.Context, id int) (core.ErasureRequestModel, error)
+	HasPendingErasure(ctx context.Context, userId int) (bool, error)
+	IsErased(ctx context.Context, userId int) (bool, error)
+	ErasuresByInstitutionId(ctx context.Context, institutionId int) ([]core.ErasureRequestModel, error)
+	ReviewErasure(ctx context.Context, id int, status string, reviewedBy int) error
+	Anonymize(ctx context.Context, userId int, email string, passwordHash string) error
+	Enrollments(ctx context.Context, userId int) ([]core.PersonalClassroomRecord, error)
+	Waitlist(ctx context.Context, userId int) ([]core.PersonalClassroomRecord, error)
+	ClassroomRoles(ctx context.Context, userId int) ([]core.PersonalClassroomRecord, error)
+	Sessions(ctx context.Context, userId int) ([]core.PersonalSessionRecord, error)
+	Identities(ctx context.Context, userId int) ([]core.PersonalIdentityRecord, error)
+	APIKeys(ctx context.Context, userId int) ([]core.PersonalAPIKeyRecord, error)
+}
+
+type PrivacyService struct {
+	privacyRepo PrivacyRepo
+}
+
+func NewPrivacyService(privacyRepo PrivacyRepo) *PrivacyService {
+	return &PrivacyService{privacyRepo: privacyRepo}
+}
+
+// PersonalData collects everything stored about the user. Lessons belong to their classrooms and are
+// not included.
+func (s PrivacyService) PersonalData(ctx context.Context, user core.User) (core.PersonalData, error) {
+	data := core.PersonalData{
+		Profile: core.PersonalProfile{
+			Id:            user.Id,
+			FullName:      user.FullName,
+			Email:         user.Email,
+			Phone:         user.Phone,
+			Role:          string(user.Role),
+			InstitutionId: user.InstitutionId,
+			EmailVerified: user.EmailVerified,
+		},
+		ExportedAt: time.Now(),
+	}
+
+	var err error
+
+	if data.Enrollments, err = s.privacyRepo.Enrollments(ctx, user.Id); err != nil {
+		return core.PersonalData{}, err
+	}
+
+	if data.Waitlist, err = s.privacyRepo.Waitlist(ctx, user.Id); err != nil {
+		return core.PersonalData{}, err
+	}
+
+	if data.ClassroomRoles, err = s.privacyRepo.ClassroomRoles(ctx, user.Id); err != nil {
+		return core.PersonalData{}, err
+	}
+
+	if data.Sessions, err = s.privacyRepo.Sessions(ctx, user.Id); err != nil {
+		return core.PersonalData{}, err
+	}
+
+	if data.Identities, err = s.privacyRepo.Identities(ctx, user.Id); err != nil {
+		return core.PersonalData{}, err
+	}
+
+	if data.APIKeys, err = s.privacyRepo.APIKeys(ctx, user.Id); err != nil {
+		return core.PersonalData{}, err
+	}
+
+	return data, nil
+}
+
+// RequestErasure adds a pending erasure of the user. A user has at most one pending request.
+func (s PrivacyService) RequestErasure(
+	ctx context.Context,
+	userId int,
+	requestedBy int,
+	reason *string,
+) (core.ErasureRequest, error) {
+	erased, err := s.privacyRepo.IsErased(ctx, userId)
+	if err != nil {
+		return core.ErasureRequest{}, err
+	}
+
+	if erased {
+		return core.ErasureRequest{}, apperrors.UserErased
+	}
+
+	pending, err := s.privacyRepo.HasPendingErasure(ctx, userId)
+	if err != nil {
+		return core.ErasureRequest{}, err
+	}
+
+	if pending {
+		return core.ErasureRequest{}, apperrors.ErasureInProgress
+	}
+
+	model, err := s.privacyRepo.CreateErasure(ctx, userId, requestedBy, reason)
+	if err != nil {
+		return core.ErasureRequest{}, err
+	}
+
+	return erasureRequestFromModel(model), nil
+}
+
+func (s PrivacyService) ErasureById(ctx context.Context, id int) (core.ErasureRequest, error) {
+	model, err := s.privacyRepo.ErasureById(ctx, id)
+	if err != nil {
+		return core.ErasureRequest{}, err
+	}
+
+	return erasureRequestFromModel(model), nil
+}
+
+// ErasureForReview locks the pending request until the end of the transaction.
+func (s PrivacyService) ErasureForReview(ctx context.Context, id int) (core.ErasureRequest, error) {
+	model, err := s.privacyRepo.ErasureByIdForUpdate(ctx, id)
+	if err != nil {
+		return core.ErasureRequest{}, err
+	}
+
+	if core.ErasureStatus(model.Status) != core.ErasurePending {
+		return core.ErasureRequest{}, apperrors.ErasureReviewed
+	}
+
+	return erasureRequestFromModel(model), nil
+}
+
+func (s PrivacyService) InstitutionErasures(ctx context.Context, institutionId int) ([]core.ErasureRequest, error) {
+	models, err := s.privacyRepo.ErasuresByInstitutionId(ctx, institutionId)
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make([]core.ErasureRequest, 0, len(models))
+
+	for _, model := range models {
+		requests = append(requests, erasureRequestFromModel(model))
+	}
+
+	return requests, nil
+}
+
+// Approve erases the personal data of the user of the request. The account stays, so the records it
+// is part of are kept, but nobody can sign in to it any more. The password hash should be of a random
+// password nobody knows.
+func (s PrivacyService) Approve(ctx context.Context, request core.ErasureRequest, reviewerId int, passwordHash string) error {
+	if err := s.privacyRepo.ReviewErasure(ctx, request.Id, string(core.ErasureApproved), reviewerId); err != nil {
+		return err
+	}
+
+	// The address is unique and can not receive mail.
+	email := fmt.Sprintf("erased-%d@erased.invalid", request.UserId)
+
+	return s.privacyRepo.Anonymize(ctx, request.UserId, email, passwordHash)
+}
+
+func (s PrivacyService) Reject(ctx context.Context, request core.ErasureRequest, reviewerId int) error {
+	return s.privacyRepo.ReviewErasure(ctx, request.Id, string(core.ErasureRejected), reviewerId)
+}
+
+func erasureRequestFromModel(model core.ErasureRequestModel) core.ErasureRequest {
+	return core.ErasureRequest{
+		Id:          model.Id,
+		UserId:      model.UserId,
+		FullName:    model.FullName,
+		Email:       model.Email,
+		RequestedBy: model.RequestedBy,
+		Reason:      model.Reason,
+		Status:      core.ErasureStatus(model.Status),
+		ReviewedBy:  model.ReviewedBy,
+		ReviewedAt:  model.ReviewedAt,
+		CreatedAt:   model.CreatedAt,
+	}
+}
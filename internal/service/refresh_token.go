@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"github.com/migmatore/study-platform-api/internal/core"
+)
+
+type RefreshTokenRepo interface {
+	Create(ctx context.Context, token core.RefreshTokenModel) error
+	ByIdForUpdate(ctx context.Context, id string) (core.RefreshTokenModel, error)
+	Rotate(ctx context.Context, id string) error
+}
+
+type RefreshTokenService struct {
+	refreshTokenRepo RefreshTokenRepo
+}
+
+func NewRefreshTokenService(refreshTokenRepo RefreshTokenRepo) *RefreshTokenService {
+	return &RefreshTokenService{refreshTokenRepo: refreshTokenRepo}
+}
+
+func (s RefreshTokenService) Create(ctx context.Context, token core.RefreshToken) error {
+	return s.refreshTokenRepo.Create(ctx, core.RefreshTokenModel{
+		Id:        token.Id,
+		FamilyId:  token.FamilyId,
+		UserId:    token.UserId,
+		ExpiresAt: token.ExpiresAt,
+	})
+}
+
+func (s RefreshTokenService) ByIdForUpdate(ctx context.Context, id string) (core.RefreshToken, error) {
+	model, err := s.refreshTokenRepo.ByIdForUpdate(ctx, id)
+	if err != nil {
+		return core.RefreshToken{}, err
+	}
+
+	return core.RefreshToken{
+		Id:        model.Id,
+		FamilyId:  model.FamilyId,
+		UserId:    model.UserId,
+		ExpiresAt: model.ExpiresAt,
+		CreatedAt: model.CreatedAt,
+		RotatedAt: model.RotatedAt,
+		RevokedAt: model.RevokedAt,
+	}, nil
+}
+
+func (s RefreshTokenService) Rotate(ctx context.Context, id string) error {
+	return s.refreshTokenRepo.Rotate(ctx, id)
+}
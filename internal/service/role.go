@@ -2,10 +2,153 @@ package service
 
 import (
 	"context"
+	"errors"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
 	"github.com/migmatore/study-platform-api/internal/core"
 )
 
 type RoleRepo interface {
 	ByName(ctx context.Context, name string) (core.RoleModel, error)
 	ById(ctx context.Context, id int) (core.RoleModel, error)
+	InstitutionRoleByName(ctx context.Context, institutionId int, name string) (core.RoleModel, error)
+	ByInstitutionId(ctx context.Context, institutionId int) ([]core.RoleModel, error)
+	Create(ctx context.Context, role core.RoleModel) (core.RoleModel, error)
+	Update(ctx context.Context, role core.RoleModel) (core.RoleModel, error)
+	Delete(ctx context.Context, id int) error
+	Assign(ctx context.Context, classroomId, userId, roleId int) error
+	Unassign(ctx context.Context, classroomId, userId int) error
+	Assignments(ctx context.Context, classroomId int) ([]core.ClassroomRoleModel, error)
+	AssignedPermissions(ctx context.Context, classroomId, userId int) ([]string, error)
+}
+
+type RoleService struct {
+	roleRepo RoleRepo
+}
+
+func NewRoleService(roleRepo RoleRepo) *RoleService {
+	return &RoleService{roleRepo: roleRepo}
+}
+
+// ById returns the role the institution defined. The built-in roles are not found.
+func (s RoleService) ById(ctx context.Context, id int) (core.Role, error) {
+	model, err := s.roleRepo.ById(ctx, id)
+	if err != nil {
+		return core.Role{}, err
+	}
+
+	if model.InstitutionId == nil {
+		return core.Role{}, apperrors.EntityNotFound
+	}
+
+	return roleFromModel(model), nil
+}
+
+// IsExist reports whether the name is taken by a built-in role or another role of the institution.
+func (s RoleService) IsExist(ctx context.Context, institutionId int, name string) (bool, error) {
+	if _, err := s.roleRepo.ByName(ctx, name); err == nil {
+		return true, nil
+	} else if !errors.Is(err, apperrors.EntityNotFound) {
+		return false, err
+	}
+
+	if _, err := s.roleRepo.InstitutionRoleByName(ctx, institutionId, name); err == nil {
+		return true, nil
+	} else if !errors.Is(err, apperrors.EntityNotFound) {
+		return false, err
+	}
+
+	return false, nil
+}
+
+func (s RoleService) ByInstitutionId(ctx context.Context, institutionId int) ([]core.Role, error) {
+	models, err := s.roleRepo.ByInstitutionId(ctx, institutionId)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]core.Role, 0, len(models))
+
+	for _, model := range models {
+		roles = append(roles, roleFromModel(model))
+	}
+
+	return roles, nil
+}
+
+func (s RoleService) Create(ctx context.Context, role core.Role) (core.Role, error) {
+	model, err := s.roleRepo.Create(ctx, core.RoleModel{
+		Name:          role.Name,
+		InstitutionId: &role.InstitutionId,
+		Permissions:   role.Permissions,
+	})
+	if err != nil {
+		return core.Role{}, err
+	}
+
+	return roleFromModel(model), nil
+}
+
+func (s RoleService) Update(ctx context.Context, role core.Role) (core.Role, error) {
+	model, err := s.roleRepo.Update(ctx, core.RoleModel{
+		Id:          role.Id,
+		Name:        role.Name,
+		Permissions: role.Permissions,
+	})
+	if err != nil {
+		return core.Role{}, err
+	}
+
+	return roleFromModel(model), nil
+}
+
+func (s RoleService) Delete(ctx context.Context, id int) error {
+	return s.roleRepo.Delete(ctx, id)
+}
+
+func (s RoleService) Assign(ctx context.Context, classroomId, userId, roleId int) error {
+	return s.roleRepo.Assign(ctx, classroomId, userId, roleId)
+}
+
+func (s RoleService) Unassign(ctx context.Context, classroomId, userId int) error {
+	return s.roleRepo.Unassign(ctx, classroomId, userId)
+}
+
+func (s RoleService) Assignments(ctx context.Context, classroomId int) ([]core.ClassroomRole, error) {
+	models, err := s.roleRepo.Assignments(ctx, classroomId)
+	if err != nil {
+		return nil, err
+	}
+
+	assignments := make([]core.ClassroomRole, 0, len(models))
+
+	for _, model := range models {
+		assignments = append(assignments, core.ClassroomRole{
+			ClassroomId: model.ClassroomId,
+			UserId:      model.UserId,
+			RoleId:      model.RoleId,
+			RoleName:    model.RoleName,
+		})
+	}
+
+	return assignments, nil
+}
+
+// AssignedPermissions returns the permissions the user has in the classroom through an institution
+// defined role.
+func (s RoleService) AssignedPermissions(ctx context.Context, classroomId, userId int) ([]string, error) {
+	return s.roleRepo.AssignedPermissions(ctx, classroomId, userId)
+}
+
+func roleFromModel(model core.RoleModel) core.Role {
+	role := core.Role{
+		Id:          model.Id,
+		Name:        model.Name,
+		Permissions: model.Permissions,
+	}
+
+	if model.InstitutionId != nil {
+		role.InstitutionId = *model.InstitutionId
+	}
+
+	return role
 }
@@ -2,38 +2,87 @@ package service
 
 import (
 	"github.com/migmatore/study-platform-api/config"
+	"github.com/migmatore/study-platform-api/pkg/jwt"
+	"github.com/migmatore/study-platform-api/pkg/mailer"
 )
 
 type Deps struct {
-	TransactorRepo  TransactionRepo
-	UserRepo        UserRepo
-	RoleRepo        RoleRepo
-	InstitutionRepo InstitutionRepo
-	ClassroomRepo   ClassroomRepo
-	LessonRepo      LessonRepo
+	TransactorRepo        TransactionRepo
+	UserRepo              UserRepo
+	RoleRepo              RoleRepo
+	InstitutionRepo       InstitutionRepo
+	ClassroomRepo         ClassroomRepo
+	LessonRepo            LessonRepo
+	RefreshTokenRepo      RefreshTokenRepo
+	SessionRepo           SessionRepo
+	PasswordResetRepo     PasswordResetRepo
+	EmailVerificationRepo EmailVerificationRepo
+	MFARepo               MFARepo
+	SigninThrottleRepo    SigninThrottleRepo
+	OIDCRepo              OIDCRepo
+	InvitationRepo        InvitationRepo
+	APIKeyRepo            APIKeyRepo
+	ImpersonationRepo     ImpersonationRepo
+	AuditRepo             AuditRepo
+	ExportRepo            ExportRepo
+	PrivacyRepo           PrivacyRepo
+	Mailer                mailer.Mailer
+	KeySet                *jwt.KeySet
 }
 
 type Service struct {
-	config      *config.Config
-	Transaction *TransactionService
-	User        *UserService
-	Institution *InstitutionService
-	Token       *TokenService
-	Teacher     *TeacherService
-	Student     *StudentService
-	Classroom   *ClassroomService
-	Lesson      *LessonService
+	config            *config.Config
+	Transaction       *TransactionService
+	User              *UserService
+	Institution       *InstitutionService
+	Token             *TokenService
+	Teacher           *TeacherService
+	Student           *StudentService
+	Classroom         *ClassroomService
+	Lesson            *LessonService
+	RefreshToken      *RefreshTokenService
+	Session           *SessionService
+	PasswordReset     *PasswordResetService
+	EmailVerification *EmailVerificationService
+	MFA               *MFAService
+	SigninThrottle    *SigninThrottleService
+	OIDC              *OIDCService
+	Invitation        *InvitationService
+	APIKey            *APIKeyService
+	Impersonation     *ImpersonationService
+	Role              *RoleService
+	Audit             *AuditService
+	Mail              *MailService
+	Import            *ImportService
+	Export            *ExportService
+	Privacy           *PrivacyService
 }
 
 func New(config *config.Config, deps Deps) *Service {
 	return &Service{
-		Transaction: NewTransactionService(deps.TransactorRepo),
-		User:        NewUserService(deps.UserRepo, deps.RoleRepo),
-		Institution: NewInstitutionService(deps.InstitutionRepo),
-		Token:       NewTokenService(config),
-		Teacher:     NewTeacherService(deps.ClassroomRepo, deps.UserRepo, deps.RoleRepo),
-		Student:     NewStudentService(deps.ClassroomRepo, deps.UserRepo, deps.RoleRepo),
-		Classroom:   NewClassroomService(deps.ClassroomRepo, deps.UserRepo),
-		Lesson:      NewLessonService(deps.LessonRepo, deps.ClassroomRepo),
+		Transaction:       NewTransactionService(deps.TransactorRepo),
+		User:              NewUserService(deps.UserRepo, deps.RoleRepo),
+		Institution:       NewInstitutionService(deps.InstitutionRepo),
+		Token:             NewTokenService(config, deps.KeySet),
+		Teacher:           NewTeacherService(deps.ClassroomRepo, deps.UserRepo, deps.RoleRepo),
+		Student:           NewStudentService(deps.ClassroomRepo, deps.UserRepo, deps.RoleRepo),
+		Classroom:         NewClassroomService(config, deps.ClassroomRepo, deps.UserRepo),
+		Lesson:            NewLessonService(deps.LessonRepo, deps.ClassroomRepo),
+		RefreshToken:      NewRefreshTokenService(deps.RefreshTokenRepo),
+		Session:           NewSessionService(deps.SessionRepo),
+		PasswordReset:     NewPasswordResetService(config, deps.PasswordResetRepo),
+		EmailVerification: NewEmailVerificationService(config, deps.EmailVerificationRepo),
+		MFA:               NewMFAService(config, deps.MFARepo),
+		SigninThrottle:    NewSigninThrottleService(config, deps.SigninThrottleRepo),
+		OIDC:              NewOIDCService(config, deps.OIDCRepo),
+		Invitation:        NewInvitationService(config, deps.InvitationRepo),
+		APIKey:            NewAPIKeyService(deps.APIKeyRepo),
+		Impersonation:     NewImpersonationService(deps.ImpersonationRepo),
+		Role:              NewRoleService(deps.RoleRepo),
+		Audit:             NewAuditService(deps.AuditRepo, deps.UserRepo),
+		Mail:              NewMailService(config, deps.Mailer),
+		Import:            NewImportService(),
+		Export:            NewExportService(config, deps.ExportRepo),
+		Privacy:           NewPrivacyService(deps.PrivacyRepo),
 	}
 }
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"github.com/migmatore/study-platform-api/internal/core"
+)
+
+type SessionRepo interface {
+	Create(ctx context.Context, session core.SessionModel) error
+	ById(ctx context.Context, id string) (core.SessionModel, error)
+	ActiveByUserId(ctx context.Context, userId int) ([]core.SessionModel, error)
+	Touch(ctx context.Context, session core.SessionModel) error
+	Revoke(ctx context.Context, id string) error
+	RevokeByUserId(ctx context.Context, userId int) error
+}
+
+type SessionService struct {
+	sessionRepo SessionRepo
+}
+
+func NewSessionService(sessionRepo SessionRepo) *SessionService {
+	return &SessionService{sessionRepo: sessionRepo}
+}
+
+func (s SessionService) Create(ctx context.Context, session core.Session) error {
+	return s.sessionRepo.Create(ctx, core.SessionModel{
+		Id:        session.Id,
+		UserId:    session.UserId,
+		UserAgent: session.UserAgent,
+		IP:        session.IP,
+		ExpiresAt: session.ExpiresAt,
+	})
+}
+
+func (s SessionService) ById(ctx context.Context, id string) (core.Session, error) {
+	model, err := s.sessionRepo.ById(ctx, id)
+	if err != nil {
+		return core.Session{}, err
+	}
+
+	return core.Session{
+		Id:         model.Id,
+		UserId:     model.UserId,
+		UserAgent:  model.UserAgent,
+		IP:         model.IP,
+		CreatedAt:  model.CreatedAt,
+		LastUsedAt: model.LastUsedAt,
+		ExpiresAt:  model.ExpiresAt,
+		RevokedAt:  model.RevokedAt,
+	}, nil
+}
+
+func (s SessionService) ActiveByUserId(ctx context.Context, userId int) ([]core.Session, error) {
+	models, err := s.sessionRepo.ActiveByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]core.Session, 0, len(models))
+
+	for _, model := range models {
+		sessions = append(sessions, core.Session{
+			Id:         model.Id,
+			UserId:     model.UserId,
+			UserAgent:  model.UserAgent,
+			IP:         model.IP,
+			CreatedAt:  model.CreatedAt,
+			LastUsedAt: model.LastUsedAt,
+			ExpiresAt:  model.ExpiresAt,
+			RevokedAt:  model.RevokedAt,
+		})
+	}
+
+	return sessions, nil
+}
+
+func (s SessionService) Touch(ctx context.Context, session core.Session) error {
+	return s.sessionRepo.Touch(ctx, core.SessionModel{
+		Id:        session.Id,
+		UserAgent: session.UserAgent,
+		IP:        session.IP,
+		ExpiresAt: session.ExpiresAt,
+	})
+}
+
+func (s SessionService) Revoke(ctx context.Context, id string) error {
+	return s.sessionRepo.Revoke(ctx, id)
+}
+
+func (s SessionService) RevokeByUserId(ctx context.Context, userId int) error {
+	return s.sessionRepo.RevokeByUserId(ctx, userId)
+}
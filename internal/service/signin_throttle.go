@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/migmatore/study-platform-api/config"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSigninFreeAttempts  = 3
+	defaultSigninMaxAttempts   = 10
+	defaultSigninIPMaxAttempts = 50
+	defaultSigninBaseDelaySec  = 1
+	defaultSigninMaxDelaySec   = 300
+	defaultSigninLockoutMin    = 30
+	defaultSigninWindowMin     = 60
+)
+
+type SigninThrottleRepo interface {
+	Get(ctx context.Context, scope string, key string) (core.SigninThrottleModel, error)
+	RecordFailure(ctx context.Context, scope string, key string, window time.Duration) (int, error)
+	Lock(ctx context.Context, scope string, key string, until time.Time) error
+	Reset(ctx context.Context, scope string, key string) error
+}
+
+// SigninThrottleService counts failed sign in attempts per account and per IP address. Accounts are
+// keyed by email, so unknown emails are throttled exactly like existing ones.
+type SigninThrottleService struct {
+	config             config.SigninConfig
+	signinThrottleRepo SigninThrottleRepo
+}
+
+func NewSigninThrottleService(config *config.Config, signinThrottleRepo SigninThrottleRepo) *SigninThrottleService {
+	cfg := config.Signin
+
+	if cfg.FreeAttempts <= 0 {
+		cfg.FreeAttempts = defaultSigninFreeAttempts
+	}
+
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultSigninMaxAttempts
+	}
+
+	if cfg.IPMaxAttempts <= 0 {
+		cfg.IPMaxAttempts = defaultSigninIPMaxAttempts
+	}
+
+	if cfg.BaseDelaySec <= 0 {
+		cfg.BaseDelaySec = defaultSigninBaseDelaySec
+	}
+
+	if cfg.MaxDelaySec <= 0 {
+		cfg.MaxDelaySec = defaultSigninMaxDelaySec
+	}
+
+	if cfg.LockoutMin <= 0 {
+		cfg.LockoutMin = defaultSigninLockoutMin
+	}
+
+	if cfg.WindowMin <= 0 {
+		cfg.WindowMin = defaultSigninWindowMin
+	}
+
+	return &SigninThrottleService{config: cfg, signinThrottleRepo: signinThrottleRepo}
+}
+
+// Check returns apperrors.TooManyAttempts if the account or the address must wait before the next attempt.
+func (s SigninThrottleService) Check(ctx context.Context, email string, ip string) error {
+	if err := s.check(ctx, core.AccountThrottleScope, accountKey(email)); err != nil {
+		return err
+	}
+
+	if ip == "" {
+		return nil
+	}
+
+	return s.check(ctx, core.IPThrottleScope, ip)
+}
+
+// Fail records a failed attempt and delays the next one.
+func (s SigninThrottleService) Fail(ctx context.Context, email string, ip string) error {
+	if err := s.fail(ctx, core.AccountThrottleScope, accountKey(email), s.config.MaxAttempts); err != nil {
+		return err
+	}
+
+	if ip == "" {
+		return nil
+	}
+
+	return s.fail(ctx, core.IPThrottleScope, ip, s.config.IPMaxAttempts)
+}
+
+// Reset forgets the failed attempts of the account. It is called after a successful sign in and when
+// an admin unlocks the account. Counters of addresses only expire, so signing in to one account does
+// not allow guessing passwords of others.
+func (s SigninThrottleService) Reset(ctx context.Context, email string) error {
+	return s.signinThrottleRepo.Reset(ctx, string(core.AccountThrottleScope), accountKey(email))
+}
+
+func (s SigninThrottleService) check(ctx context.Context, scope core.ThrottleScope, key string) error {
+	throttle, err := s.signinThrottleRepo.Get(ctx, string(scope), key)
+	if err != nil {
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return nil
+		}
+
+		return err
+	}
+
+	if throttle.LockedUntil != nil && time.Now().Before(*throttle.LockedUntil) {
+		return apperrors.TooManyAttempts
+	}
+
+	return nil
+}
+
+func (s SigninThrottleService) fail(ctx context.Context, scope core.ThrottleScope, key string, maxAttempts int) error {
+	failures, err := s.signinThrottleRepo.RecordFailure(
+		ctx,
+		string(scope),
+		key,
+		time.Minute*time.Duration(s.config.WindowMin),
+	)
+	if err != nil {
+		return err
+	}
+
+	delay := s.delay(failures, maxAttempts)
+	if delay == 0 {
+		return nil
+	}
+
+	return s.signinThrottleRepo.Lock(ctx, string(scope), key, time.Now().Add(delay))
+}
+
+// delay returns how long to wait after the given number of failures in a row.
+func (s SigninThrottleService) delay(failures int, maxAttempts int) time.Duration {
+	if failures >= maxAttempts {
+		return time.Minute * time.Duration(s.config.LockoutMin)
+	}
+
+	if failures < s.config.FreeAttempts {
+		return 0
+	}
+
+	maxDelay := time.Second * time.Duration(s.config.MaxDelaySec)
+	delay := time.Second * time.Duration(s.config.BaseDelaySec)
+
+	for i := s.config.FreeAttempts; i < failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	return delay
+}
+
+func accountKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -46,6 +46,7 @@ func (s StudentService) AllClassrooms(ctx context.Context, studentId int) ([]cor
 			Description: model.Description,
 			TeacherId:   model.TeacherId,
 			MaxStudents: model.MaxStudents,
+			ArchivedAt:  model.ArchivedAt,
 		})
 	}
 
@@ -71,11 +72,13 @@ func (s StudentService) ByInstitutionId(ctx context.Context, institutionId int)
 		}
 
 		students = append(students, core.Student{
-			Id:           model.Id,
-			FullName:     model.FullName,
-			Phone:        model.Phone,
-			Email:        model.Email,
-			ClassroomsId: nil,
+			Id:            model.Id,
+			FullName:      model.FullName,
+			Phone:         model.Phone,
+			Email:         model.Email,
+			ClassroomsId:  nil,
+			DeactivatedAt: model.DeactivatedAt,
+			DeletedAt:     model.DeletedAt,
 		})
 	}
 
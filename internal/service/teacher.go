@@ -53,10 +53,12 @@ func (s TeacherService) All(ctx context.Context, institutionId int) ([]core.Teac
 		}
 
 		teachers = append(teachers, core.Teacher{
-			Id:       user.Id,
-			FullName: user.FullName,
-			Phone:    user.Phone,
-			Email:    user.Email,
+			Id:            user.Id,
+			FullName:      user.FullName,
+			Phone:         user.Phone,
+			Email:         user.Email,
+			DeactivatedAt: user.DeactivatedAt,
+			DeletedAt:     user.DeletedAt,
 		})
 	}
 
@@ -82,6 +84,8 @@ func (s TeacherService) ById(ctx context.Context, id int) (core.User, error) {
 		PasswordHash:  userModel.PasswordHash,
 		Role:          core.RoleType(role.Name),
 		InstitutionId: nil,
+		DeactivatedAt: userModel.DeactivatedAt,
+		DeletedAt:     userModel.DeletedAt,
 	}, nil
 }
 
@@ -100,6 +104,7 @@ func (s TeacherService) AllClassrooms(ctx context.Context, teacherId int) ([]cor
 			Description: model.Description,
 			TeacherId:   model.TeacherId,
 			MaxStudents: model.MaxStudents,
+			ArchivedAt:  model.ArchivedAt,
 		})
 	}
 
@@ -127,11 +132,13 @@ func (s TeacherService) Students(ctx context.Context, teacherId int) ([]core.Stu
 
 	for _, student := range studentsModel {
 		students = append(students, core.Student{
-			Id:           student.Id,
-			FullName:     student.FullName,
-			Phone:        student.Phone,
-			Email:        student.Email,
-			ClassroomsId: student.ClassroomsId,
+			Id:            student.Id,
+			FullName:      student.FullName,
+			Phone:         student.Phone,
+			Email:         student.Email,
+			ClassroomsId:  student.ClassroomsId,
+			DeactivatedAt: student.DeactivatedAt,
+			DeletedAt:     student.DeletedAt,
 		})
 	}
 
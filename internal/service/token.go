@@ -3,32 +3,36 @@ package service
 import (
 	"errors"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/migmatore/study-platform-api/config"
 	"github.com/migmatore/study-platform-api/internal/apperrors"
 	"github.com/migmatore/study-platform-api/internal/core"
+	jwtkeys "github.com/migmatore/study-platform-api/pkg/jwt"
 	"time"
 )
 
+const defaultImpersonationExpMin = 15
+
 type TokenService struct {
 	config *config.Config
+	keySet *jwtkeys.KeySet
 }
 
-func NewTokenService(config *config.Config) *TokenService {
-	return &TokenService{config: config}
+func NewTokenService(config *config.Config, keySet *jwtkeys.KeySet) *TokenService {
+	return &TokenService{config: config, keySet: keySet}
 }
 
-func (s TokenService) Token(userId int, role string) (core.TokenWithClaims, error) {
+func (s TokenService) Token(userId int, role string, sessionId string) (core.TokenWithClaims, error) {
 	expires := time.Now().Add(time.Minute * time.Duration(s.config.Server.JwtExpTimeMin)).Unix()
 
 	claims := jwt.MapClaims{
 		"user_id": userId,
 		"role":    role,
+		"sid":     sessionId,
 		"exp":     expires,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	t, err := token.SignedString([]byte(s.config.Server.JwtSecretKey))
+	t, err := s.keySet.Sign(jwtkeys.AccessToken, claims)
 	if err != nil {
 		return core.TokenWithClaims{}, err
 	}
@@ -40,6 +44,35 @@ func (s TokenService) Token(userId int, role string) (core.TokenWithClaims, erro
 	}, nil
 }
 
+// ImpersonationToken issues a short-lived access token for the user. The act claim names the admin
+// who actually makes the requests. The token has no session, so it can not be refreshed.
+func (s TokenService) ImpersonationToken(userId int, role string, adminId int) (core.TokenWithClaims, int64, error) {
+	expMin := s.config.Server.ImpersonationExpMin
+	if expMin <= 0 {
+		expMin = defaultImpersonationExpMin
+	}
+
+	expires := time.Now().Add(time.Minute * time.Duration(expMin)).Unix()
+
+	claims := jwt.MapClaims{
+		"user_id": userId,
+		"role":    role,
+		"act":     map[string]interface{}{"user_id": adminId},
+		"exp":     expires,
+	}
+
+	t, err := s.keySet.Sign(jwtkeys.AccessToken, claims)
+	if err != nil {
+		return core.TokenWithClaims{}, 0, err
+	}
+
+	return core.TokenWithClaims{
+		Token:  t,
+		UserId: userId,
+		Role:   role,
+	}, expires, nil
+}
+
 func (s TokenService) WSToken(userId int, role string) (core.TokenWithClaims, error) {
 	expires := time.Now().Add(time.Hour * time.Duration(s.config.Server.WSJwtExpTimeHour)).Unix()
 
@@ -49,9 +82,7 @@ func (s TokenService) WSToken(userId int, role string) (core.TokenWithClaims, er
 		"exp":     expires,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	t, err := token.SignedString([]byte(s.config.Server.WSJwtSecretKey))
+	t, err := s.keySet.Sign(jwtkeys.WSToken, claims)
 	if err != nil {
 		return core.TokenWithClaims{}, err
 	}
@@ -63,33 +94,38 @@ func (s TokenService) WSToken(userId int, role string) (core.TokenWithClaims, er
 	}, nil
 }
 
-func (s TokenService) RefreshToken(userId int) (core.RefreshTokenWithClaims, error) {
+func (s TokenService) RefreshToken(userId int, familyId string) (core.RefreshTokenWithClaims, error) {
 	expires := time.Now().Add(time.Hour * time.Duration(s.config.Server.JwtRefreshExpTimeHour)).Unix()
+	id := uuid.NewString()
 
 	claims := jwt.MapClaims{
+		"jti":     id,
+		"fid":     familyId,
 		"user_id": userId,
 		"exp":     expires,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	t, err := token.SignedString([]byte(s.config.Server.JwtRefreshSecretKey))
+	t, err := s.keySet.Sign(jwtkeys.RefreshToken, claims)
 	if err != nil {
 		return core.RefreshTokenWithClaims{}, err
 	}
 
 	return core.RefreshTokenWithClaims{
-		Token:  t,
-		UserId: userId,
+		Token:    t,
+		Id:       id,
+		FamilyId: familyId,
+		UserId:   userId,
+		Expires:  expires,
 	}, nil
 }
 
 func (s TokenService) ExtractTokenMetadata(tokenString string) (core.TokenMetadata, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return []byte(s.config.Server.JwtSecretKey), nil
-	})
+	token, err := jwt.Parse(tokenString, s.keySet.Keyfunc(jwtkeys.AccessToken))
 	if err != nil {
-		if errors.Is(err, jwt.ErrSignatureInvalid) || errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+		if errors.Is(err, jwt.ErrSignatureInvalid) ||
+			errors.Is(err, jwt.ErrTokenSignatureInvalid) ||
+			errors.Is(err, jwt.ErrTokenUnverifiable) ||
+			errors.Is(err, jwt.ErrTokenInvalidClaims) {
 			return core.TokenMetadata{}, apperrors.InvalidToken
 		}
 
@@ -110,11 +146,14 @@ func (s TokenService) ExtractTokenMetadata(tokenString string) (core.TokenMetada
 		expires := int64(claims["exp"].(float64))
 		userId := int(claims["user_id"].(float64))
 		role := claims["role"].(string)
+		sessionId, _ := claims["sid"].(string)
 
 		return core.TokenMetadata{
-			Expires: expires,
-			UserId:  userId,
-			Role:    role,
+			Expires:        expires,
+			UserId:         userId,
+			Role:           role,
+			SessionId:      sessionId,
+			ImpersonatorId: jwtkeys.ImpersonatorId(claims),
 		}, nil
 	}
 
@@ -122,11 +161,12 @@ func (s TokenService) ExtractTokenMetadata(tokenString string) (core.TokenMetada
 }
 
 func (s TokenService) ExtractWSTokenMetadata(tokenString string) (core.TokenMetadata, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return []byte(s.config.Server.WSJwtSecretKey), nil
-	})
+	token, err := jwt.Parse(tokenString, s.keySet.Keyfunc(jwtkeys.WSToken))
 	if err != nil {
-		if errors.Is(err, jwt.ErrSignatureInvalid) || errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+		if errors.Is(err, jwt.ErrSignatureInvalid) ||
+			errors.Is(err, jwt.ErrTokenSignatureInvalid) ||
+			errors.Is(err, jwt.ErrTokenUnverifiable) ||
+			errors.Is(err, jwt.ErrTokenInvalidClaims) {
 			return core.TokenMetadata{}, apperrors.InvalidToken
 		}
 
@@ -158,14 +198,19 @@ func (s TokenService) ExtractWSTokenMetadata(tokenString string) (core.TokenMeta
 }
 
 func (s TokenService) ExtractRefreshTokenMetadata(tokenString string) (core.RefreshTokenMetadata, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return []byte(s.config.Server.JwtRefreshSecretKey), nil
-	})
+	token, err := jwt.Parse(tokenString, s.keySet.Keyfunc(jwtkeys.RefreshToken))
 	if err != nil {
-		if errors.Is(err, jwt.ErrSignatureInvalid) || errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+		if errors.Is(err, jwt.ErrSignatureInvalid) ||
+			errors.Is(err, jwt.ErrTokenSignatureInvalid) ||
+			errors.Is(err, jwt.ErrTokenUnverifiable) ||
+			errors.Is(err, jwt.ErrTokenInvalidClaims) {
 			return core.RefreshTokenMetadata{}, apperrors.InvalidToken
 		}
 
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return core.RefreshTokenMetadata{}, apperrors.ExpiredToken
+		}
+
 		return core.RefreshTokenMetadata{}, err
 	}
 
@@ -175,13 +220,26 @@ func (s TokenService) ExtractRefreshTokenMetadata(tokenString string) (core.Refr
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if ok && token.Valid {
+		// Tokens issued before rotation was introduced carry no id and can not be tracked.
+		id, ok := claims["jti"].(string)
+		if !ok {
+			return core.RefreshTokenMetadata{}, apperrors.InvalidToken
+		}
+
+		familyId, ok := claims["fid"].(string)
+		if !ok {
+			return core.RefreshTokenMetadata{}, apperrors.InvalidToken
+		}
+
 		// Expires time.
 		expires := int64(claims["exp"].(float64))
 		userId := int(claims["user_id"].(float64))
 
 		return core.RefreshTokenMetadata{
-			Expires: expires,
-			UserId:  userId,
+			Id:       id,
+			FamilyId: familyId,
+			Expires:  expires,
+			UserId:   userId,
 		}, nil
 	}
 
@@ -14,6 +14,9 @@ type UserRepo interface {
 	ByInstitutionId(ctx context.Context, institutionId int) ([]core.UserModel, error)
 	UpdateProfile(ctx context.Context, userId int, profile core.UpdateUserProfileModel) (core.UserProfileModel, error)
 	Delete(ctx context.Context, id int) error
+	Deactivate(ctx context.Context, id int) error
+	Reactivate(ctx context.Context, id int) error
+	VerifyEmail(ctx context.Context, userId int, email string) error
 }
 
 type UserRoleRepo interface {
@@ -53,6 +56,7 @@ func (s UserService) Create(ctx context.Context, user core.User) (core.User, err
 		PasswordHash:  user.PasswordHash,
 		RoleId:        role.Id,
 		InstitutionId: user.InstitutionId,
+		EmailVerified: user.EmailVerified,
 	})
 
 	if err != nil {
@@ -66,7 +70,10 @@ func (s UserService) Create(ctx context.Context, user core.User) (core.User, err
 		Email:         userModel.Email,
 		PasswordHash:  userModel.PasswordHash,
 		Role:          core.RoleType(role.Name),
-		InstitutionId: nil,
+		InstitutionId: userModel.InstitutionId,
+		EmailVerified: userModel.EmailVerified,
+		DeactivatedAt: userModel.DeactivatedAt,
+		DeletedAt:     userModel.DeletedAt,
 	}, nil
 }
 
@@ -88,7 +95,10 @@ func (s UserService) ByEmail(ctx context.Context, email string) (core.User, erro
 		Email:         userModel.Email,
 		PasswordHash:  userModel.PasswordHash,
 		Role:          core.RoleType(role.Name),
-		InstitutionId: nil,
+		InstitutionId: userModel.InstitutionId,
+		EmailVerified: userModel.EmailVerified,
+		DeactivatedAt: userModel.DeactivatedAt,
+		DeletedAt:     userModel.DeletedAt,
 	}, nil
 }
 
@@ -111,6 +121,9 @@ func (s UserService) ById(ctx context.Context, id int) (core.User, error) {
 		PasswordHash:  userModel.PasswordHash,
 		Role:          core.RoleType(role.Name),
 		InstitutionId: userModel.InstitutionId,
+		EmailVerified: userModel.EmailVerified,
+		DeactivatedAt: userModel.DeactivatedAt,
+		DeletedAt:     userModel.DeletedAt,
 	}, nil
 }
 
@@ -132,6 +145,19 @@ func (s UserService) UpdateProfile(ctx context.Context, userId int, profile core
 	}, nil
 }
 
+// Delete marks the user as deleted, the user can be restored with Reactivate.
 func (s UserService) Delete(ctx context.Context, id int) error {
 	return s.userRepo.Delete(ctx, id)
 }
+
+func (s UserService) Deactivate(ctx context.Context, id int) error {
+	return s.userRepo.Deactivate(ctx, id)
+}
+
+func (s UserService) Reactivate(ctx context.Context, id int) error {
+	return s.userRepo.Reactivate(ctx, id)
+}
+
+func (s UserService) VerifyEmail(ctx context.Context, userId int, email string) error {
+	return s.userRepo.VerifyEmail(ctx, userId, email)
+}
@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/jwt"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+	"strings"
+)
+
+const apiKeyAuthScheme = "ApiKey "
+
+type APIKeyUseCase interface {
+	Authenticate(ctx context.Context, token string) (core.TokenMetadata, error)
+	PersonalKeys(ctx context.Context, metadata core.TokenMetadata) ([]core.APIKeyResponse, error)
+	CreatePersonalKey(
+		ctx context.Context,
+		metadata core.TokenMetadata,
+		req core.CreateAPIKeyRequest,
+	) (core.CreatedAPIKeyResponse, error)
+	RevokePersonalKey(ctx context.Context, metadata core.TokenMetadata, id int) error
+	InstitutionKeys(ctx context.Context, metadata core.TokenMetadata) ([]core.APIKeyResponse, error)
+	CreateInstitutionKey(
+		ctx context.Context,
+		metadata core.TokenMetadata,
+		req core.CreateAPIKeyRequest,
+	) (core.CreatedAPIKeyResponse, error)
+	RevokeInstitutionKey(ctx context.Context, metadata core.TokenMetadata, id int) error
+}
+
+type APIKeyHandler struct {
+	apiKeyUseCase APIKeyUseCase
+}
+
+func NewAPIKeyHandler(apiKeyUseCase APIKeyUseCase) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyUseCase: apiKeyUseCase}
+}
+
+// Middleware authenticates requests with an "Authorization: ApiKey <key>" header. The key has to have
+// the scope of the route. Other requests are passed on to the JWT middleware.
+func (h APIKeyHandler) Middleware(c *fiber.Ctx) error {
+	header := c.Get(fiber.HeaderAuthorization)
+
+	if !strings.HasPrefix(header, apiKeyAuthScheme) {
+		return c.Next()
+	}
+
+	metadata, err := h.apiKeyUseCase.Authenticate(c.UserContext(), strings.TrimSpace(header[len(apiKeyAuthScheme):]))
+	if err != nil {
+		if errors.Is(err, apperrors.InvalidToken) || errors.Is(err, apperrors.ExpiredToken) {
+			return utils.FiberError(c, fiber.StatusUnauthorized, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	if !hasScope(metadata.Scopes, requiredScope(c)) {
+		return utils.FiberError(c, fiber.StatusForbidden, errors.New("the api key does not have the required scope"))
+	}
+
+	c.Locals(jwt.APIKeyContextKey, metadata)
+
+	return c.Next()
+}
+
+func (h APIKeyHandler) PersonalKeys(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	keys, err := h.apiKeyUseCase.PersonalKeys(ctx, claims)
+	if err != nil {
+		return apiKeyError(c, err)
+	}
+
+	return c.JSON(keys)
+}
+
+func (h APIKeyHandler) CreatePersonalKey(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	req, err := createAPIKeyRequest(c)
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	key, err := h.apiKeyUseCase.CreatePersonalKey(ctx, claims, req)
+	if err != nil {
+		return apiKeyError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(key)
+}
+
+func (h APIKeyHandler) RevokePersonalKey(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	if err := h.apiKeyUseCase.RevokePersonalKey(ctx, claims, id); err != nil {
+		return apiKeyError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "api key successfully revoked",
+	})
+}
+
+func (h APIKeyHandler) InstitutionKeys(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	keys, err := h.apiKeyUseCase.InstitutionKeys(ctx, claims)
+	if err != nil {
+		return apiKeyError(c, err)
+	}
+
+	return c.JSON(keys)
+}
+
+func (h APIKeyHandler) CreateInstitutionKey(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	req, err := createAPIKeyRequest(c)
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	key, err := h.apiKeyUseCase.CreateInstitutionKey(ctx, claims, req)
+	if err != nil {
+		return apiKeyError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(key)
+}
+
+func (h APIKeyHandler) RevokeInstitutionKey(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	if err := h.apiKeyUseCase.RevokeInstitutionKey(ctx, claims, id); err != nil {
+		return apiKeyError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "api key successfully revoked",
+	})
+}
+
+func createAPIKeyRequest(c *fiber.Ctx) (core.CreateAPIKeyRequest, error) {
+	req := core.CreateAPIKeyRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return core.CreateAPIKeyRequest{}, err
+	}
+
+	if req.Name == "" || len(req.Scopes) == 0 {
+		return core.CreateAPIKeyRequest{}, errors.New("the required parameters cannot be empty")
+	}
+
+	if req.ExpiresInDays != nil && *req.ExpiresInDays <= 0 {
+		return core.CreateAPIKeyRequest{}, errors.New("expires in days must be positive")
+	}
+
+	return req, nil
+}
+
+func apiKeyError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, apperrors.AccessDenied) {
+		return utils.FiberError(c, fiber.StatusForbidden, err)
+	}
+
+	if errors.Is(err, apperrors.EntityNotFound) {
+		return utils.FiberError(c, fiber.StatusNotFound, err)
+	}
+
+	if errors.Is(err, apperrors.InvalidAPIKeyScope) {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	return utils.FiberError(c, fiber.StatusInternalServerError, err)
+}
+
+// requiredScope returns the scope of the route, for example "classrooms:read" for GET /api/v1/classrooms/1.
+func requiredScope(c *fiber.Ctx) string {
+	path := strings.TrimPrefix(c.Path(), "/api/v1/")
+	resource, _, _ := strings.Cut(path, "/")
+
+	action := "write"
+	if c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead {
+		action = "read"
+	}
+
+	return resource + ":" + action
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
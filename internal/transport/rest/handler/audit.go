@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/jwt"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+	"strconv"
+	"time"
+)
+
+type AuditUseCase interface {
+	All(ctx context.Context, metadata core.TokenMetadata, filter core.AuditFilter) ([]core.AuditEventResponse, error)
+}
+
+type AuditHandler struct {
+	auditUseCase AuditUseCase
+}
+
+func NewAuditHandler(auditUseCase AuditUseCase) *AuditHandler {
+	return &AuditHandler{auditUseCase: auditUseCase}
+}
+
+// All returns the audit log of the institution. It can be filtered with the actor_id, entity_type,
+// entity_id, from and to query parameters; the times are in RFC 3339.
+func (h AuditHandler) All(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	filter, err := auditFilter(c)
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	events, err := h.auditUseCase.All(ctx, claims, filter)
+	if err != nil {
+		if errors.Is(err, apperrors.AccessDenied) {
+			return utils.FiberError(c, fiber.StatusForbidden, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(events)
+}
+
+func auditFilter(c *fiber.Ctx) (core.AuditFilter, error) {
+	filter := core.AuditFilter{}
+
+	if s := c.Query("actor_id"); s != "" {
+		actorId, err := strconv.Atoi(s)
+		if err != nil {
+			return core.AuditFilter{}, errors.New("the actor id must be number")
+		}
+
+		filter.ActorId = &actorId
+	}
+
+	if s := c.Query("entity_type"); s != "" {
+		filter.EntityType = &s
+	}
+
+	if s := c.Query("entity_id"); s != "" {
+		filter.EntityId = &s
+	}
+
+	for _, param := range []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"from", &filter.From},
+		{"to", &filter.To},
+	} {
+		s := c.Query(param.name)
+		if s == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return core.AuditFilter{}, errors.New(param.name + " must be a time in RFC 3339")
+		}
+
+		*param.dst = &t
+	}
+
+	if s := c.Query("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil {
+			return core.AuditFilter{}, errors.New("the limit must be number")
+		}
+
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
@@ -10,9 +10,18 @@ import (
 )
 
 type AuthUseCase interface {
-	Signin(ctx context.Context, req core.UserSigninRequest) (core.UserAuthResponse, error)
+	Signin(ctx context.Context, req core.UserSigninRequest) (core.SigninResult, error)
+	VerifyMFA(ctx context.Context, req core.MFAVerifyRequest) (core.UserAuthResponse, error)
+	OIDCLogin(ctx context.Context, institutionId int) (string, error)
+	OIDCCallback(ctx context.Context, req core.OIDCCallbackRequest) (core.UserAuthResponse, error)
 	Signup(ctx context.Context, req core.UserSignupRequest) (core.UserAuthResponse, error)
+	SignupInvite(ctx context.Context, req core.InviteSignupRequest) (core.UserAuthResponse, error)
 	Refresh(ctx context.Context, req core.UserTokenRefreshRequest) (core.UserAuthResponse, error)
+	Logout(ctx context.Context, req core.UserLogoutRequest) error
+	ForgotPassword(ctx context.Context, req core.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req core.ResetPasswordRequest) error
+	VerifyEmail(ctx context.Context, req core.VerifyEmailRequest) error
+	ResendVerification(ctx context.Context, req core.ResendVerificationRequest) error
 }
 
 type AuthHandler struct {
@@ -35,12 +44,62 @@ func (h AuthHandler) Signin(c *fiber.Ctx) error {
 		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the required parameters cannot be empty"))
 	}
 
+	req.Client = clientInfo(c)
+
 	resp, err := h.authUseCase.Signin(ctx, req)
 	if err != nil {
-		if errors.Is(err, apperrors.EntityNotFound) || errors.Is(err, apperrors.IncorrectPassword) {
+		if errors.Is(err, apperrors.InvalidCredentials) {
+			return utils.FiberError(c, fiber.StatusUnauthorized, err)
+		}
+
+		if errors.Is(err, apperrors.TooManyAttempts) {
+			return utils.FiberError(c, fiber.StatusTooManyRequests, err)
+		}
+
+		if errors.Is(err, apperrors.EmailNotVerified) || errors.Is(err, apperrors.UserDeactivated) {
+			return utils.FiberError(c, fiber.StatusForbidden, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	if resp.Challenge != nil {
+		return c.Status(fiber.StatusOK).JSON(resp.Challenge)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp.Auth)
+}
+
+func (h AuthHandler) VerifyMFA(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	req := core.MFAVerifyRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	if req.MFAToken == "" || req.Code == "" {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the required parameters cannot be empty"))
+	}
+
+	req.Client = clientInfo(c)
+
+	resp, err := h.authUseCase.VerifyMFA(ctx, req)
+	if err != nil {
+		if errors.Is(err, apperrors.InvalidMFACode) ||
+			errors.Is(err, apperrors.InvalidToken) ||
+			errors.Is(err, apperrors.ExpiredToken) {
 			return utils.FiberError(c, fiber.StatusUnauthorized, err)
 		}
 
+		if errors.Is(err, apperrors.TooManyAttempts) {
+			return utils.FiberError(c, fiber.StatusTooManyRequests, err)
+		}
+
+		if errors.Is(err, apperrors.UserDeactivated) {
+			return utils.FiberError(c, fiber.StatusForbidden, err)
+		}
+
 		return utils.FiberError(c, fiber.StatusInternalServerError, err)
 	}
 
@@ -59,12 +118,62 @@ func (h AuthHandler) Signup(c *fiber.Ctx) error {
 		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the required parameters cannot be empty"))
 	}
 
+	req.Client = clientInfo(c)
+
 	resp, err := h.authUseCase.Signup(ctx, req)
 	if err != nil {
 		if errors.Is(err, apperrors.EntityAlreadyExist) {
 			return utils.FiberError(c, fiber.StatusConflict, err)
 		}
 
+		// The account is created, but it can not be used until the email is confirmed.
+		if errors.Is(err, apperrors.EmailNotVerified) {
+			return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+				"message": "confirm your email to sign in",
+			})
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+func (h AuthHandler) SignupInvite(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	req := core.InviteSignupRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	// A join code is not bound to an email, so the student has to give one.
+	if req.FullName == "" || req.Password == "" || (req.Token == "" && (req.Code == "" || req.Email == "")) {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the required parameters cannot be empty"))
+	}
+
+	req.Client = clientInfo(c)
+
+	resp, err := h.authUseCase.SignupInvite(ctx, req)
+	if err != nil {
+		if errors.Is(err, apperrors.InvalidToken) || errors.Is(err, apperrors.ExpiredToken) {
+			return utils.FiberError(c, fiber.StatusBadRequest, err)
+		}
+
+		if errors.Is(err, apperrors.EntityAlreadyExist) || errors.Is(err, apperrors.ClassroomArchived) {
+			return utils.FiberError(c, fiber.StatusConflict, err)
+		}
+
+		if errors.Is(err, apperrors.NumberOfStudentsExceeded) {
+			return utils.FiberError(c, fiber.StatusBadRequest, err)
+		}
+
+		if errors.Is(err, apperrors.EmailNotVerified) {
+			return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+				"message": "confirm your email to sign in",
+			})
+		}
+
 		return utils.FiberError(c, fiber.StatusInternalServerError, err)
 	}
 
@@ -83,14 +192,216 @@ func (h AuthHandler) Refresh(c *fiber.Ctx) error {
 		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the required parameters cannot be empty"))
 	}
 
+	req.Client = clientInfo(c)
+
 	resp, err := h.authUseCase.Refresh(ctx, req)
 	if err != nil {
-		if errors.Is(err, apperrors.EntityNotFound) || errors.Is(err, apperrors.InvalidToken) {
+		if errors.Is(err, apperrors.EntityNotFound) ||
+			errors.Is(err, apperrors.InvalidToken) ||
+			errors.Is(err, apperrors.ExpiredToken) ||
+			errors.Is(err, apperrors.RevokedToken) ||
+			errors.Is(err, apperrors.UserDeactivated) {
+			return utils.FiberError(c, fiber.StatusForbidden, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (h AuthHandler) Logout(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	req := core.UserLogoutRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	if req.RefreshToken == "" {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the required parameters cannot be empty"))
+	}
+
+	if err := h.authUseCase.Logout(ctx, req); err != nil {
+		if errors.Is(err, apperrors.InvalidToken) || errors.Is(err, apperrors.ExpiredToken) {
+			return utils.FiberError(c, fiber.StatusForbidden, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "successfully logged out",
+	})
+}
+
+func (h AuthHandler) ForgotPassword(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	req := core.ForgotPasswordRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	if req.Email == "" {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the required parameters cannot be empty"))
+	}
+
+	if err := h.authUseCase.ForgotPassword(ctx, req); err != nil {
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "if the account exists, a password reset link has been sent",
+	})
+}
+
+func (h AuthHandler) ResetPassword(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	req := core.ResetPasswordRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	if req.Token == "" || req.Password == "" {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the required parameters cannot be empty"))
+	}
+
+	if err := h.authUseCase.ResetPassword(ctx, req); err != nil {
+		if errors.Is(err, apperrors.InvalidToken) || errors.Is(err, apperrors.ExpiredToken) {
+			return utils.FiberError(c, fiber.StatusBadRequest, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "password successfully changed",
+	})
+}
+
+func (h AuthHandler) VerifyEmail(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	req := core.VerifyEmailRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	if req.Token == "" {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the required parameters cannot be empty"))
+	}
+
+	if err := h.authUseCase.VerifyEmail(ctx, req); err != nil {
+		if errors.Is(err, apperrors.InvalidToken) || errors.Is(err, apperrors.ExpiredToken) {
+			return utils.FiberError(c, fiber.StatusBadRequest, err)
+		}
+
+		if errors.Is(err, apperrors.EntityAlreadyExist) {
+			return utils.FiberError(c, fiber.StatusConflict, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "email successfully verified",
+	})
+}
+
+func (h AuthHandler) ResendVerification(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	req := core.ResendVerificationRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	if req.Email == "" {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the required parameters cannot be empty"))
+	}
+
+	if err := h.authUseCase.ResendVerification(ctx, req); err != nil {
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "if the account exists and is not verified, a confirmation link has been sent",
+	})
+}
+
+func (h AuthHandler) OIDCLogin(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	institutionId, err := c.ParamsInt("institution")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the institution must be number"))
+	}
+
+	url, err := h.authUseCase.OIDCLogin(ctx, institutionId)
+	if err != nil {
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return utils.FiberError(c, fiber.StatusNotFound, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusBadGateway, err)
+	}
+
+	return c.Redirect(url, fiber.StatusFound)
+}
+
+func (h AuthHandler) OIDCCallback(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	institutionId, err := c.ParamsInt("institution")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the institution must be number"))
+	}
+
+	// The provider reports a refused or failed sign in instead of sending a code.
+	if providerErr := c.Query("error"); providerErr != "" {
+		return utils.FiberError(c, fiber.StatusUnauthorized, errors.New(providerErr))
+	}
+
+	req := core.OIDCCallbackRequest{
+		InstitutionId: institutionId,
+		Code:          c.Query("code"),
+		State:         c.Query("state"),
+		Client:        clientInfo(c),
+	}
+
+	if req.Code == "" || req.State == "" {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the required parameters cannot be empty"))
+	}
+
+	resp, err := h.authUseCase.OIDCCallback(ctx, req)
+	if err != nil {
+		if errors.Is(err, apperrors.InvalidToken) || errors.Is(err, apperrors.ExpiredToken) {
+			return utils.FiberError(c, fiber.StatusUnauthorized, err)
+		}
+
+		if errors.Is(err, apperrors.AccessDenied) || errors.Is(err, apperrors.UserDeactivated) {
 			return utils.FiberError(c, fiber.StatusForbidden, err)
 		}
 
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return utils.FiberError(c, fiber.StatusNotFound, err)
+		}
+
+		if errors.Is(err, apperrors.EntityAlreadyExist) {
+			return utils.FiberError(c, fiber.StatusConflict, err)
+		}
+
 		return utils.FiberError(c, fiber.StatusInternalServerError, err)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(resp)
 }
+
+func clientInfo(c *fiber.Ctx) core.ClientInfo {
+	return core.ClientInfo{
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		IP:        c.IP(),
+	}
+}
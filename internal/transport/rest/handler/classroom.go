@@ -11,10 +11,36 @@ import (
 )
 
 type ClassroomUseCase interface {
-	All(ctx context.Context, metadata core.TokenMetadata) ([]core.ClassroomResponse, error)
+	All(ctx context.Context, metadata core.TokenMetadata, archived bool) ([]core.ClassroomResponse, error)
 	Create(ctx context.Context, metadata core.TokenMetadata, req core.CreateClassroomRequest) (core.ClassroomResponse, error)
-	Delete(ctx context.Context, metadata core.TokenMetadata, id int) error
-	Students(ctx context.Context, metadata core.TokenMetadata, classroomId int) ([]core.StudentResponse, error)
+	Update(
+		ctx context.Context,
+		metadata core.TokenMetadata,
+		id int,
+		req core.UpdateClassroomRequest,
+	) (core.ClassroomResponse, error)
+	Clone(
+		ctx context.Context,
+		metadata core.TokenMetadata,
+		id int,
+		req core.CloneClassroomRequest,
+	) (core.ClassroomResponse, error)
+	Archive(ctx context.Context, metadata core.TokenMetadata, id int) (core.ClassroomResponse, error)
+	Restore(ctx context.Context, metadata core.TokenMetadata, id int) (core.ClassroomResponse, error)
+	Purge(ctx context.Context, metadata core.TokenMetadata, id int) error
+	Students(
+		ctx context.Context,
+		metadata core.TokenMetadata,
+		classroomId int,
+		includeInactive bool,
+	) ([]core.StudentResponse, error)
+	Enroll(
+		ctx context.Context,
+		metadata core.TokenMetadata,
+		classroomId int,
+		studentsId []int,
+	) (core.EnrollStudentsResponse, error)
+	Unenroll(ctx context.Context, metadata core.TokenMetadata, classroomId int, studentsId []int) error
 }
 
 type ClassroomLessonUseCase interface {
@@ -33,11 +59,12 @@ func NewClassroomHandler(classroomUseCase ClassroomUseCase, lessonUseCase Lesson
 	return &ClassroomHandler{classroomUseCase: classroomUseCase, lessonUseCase: lessonUseCase}
 }
 
+// All returns the active classrooms, or the archived ones with ?archived=true.
 func (h ClassroomHandler) All(c *fiber.Ctx) error {
 	ctx := c.UserContext()
 	claims := jwt.ExtractTokenMetadata(c)
 
-	classrooms, err := h.classroomUseCase.All(ctx, claims)
+	classrooms, err := h.classroomUseCase.All(ctx, claims, c.QueryBool("archived"))
 	if err != nil {
 		return utils.FiberError(c, fiber.StatusInternalServerError, err)
 	}
@@ -61,12 +88,78 @@ func (h ClassroomHandler) Create(c *fiber.Ctx) error {
 			return utils.FiberError(c, fiber.StatusForbidden, err)
 		}
 
+		if errors.Is(err, apperrors.InvalidClassroom) {
+			return utils.FiberError(c, fiber.StatusBadRequest, err)
+		}
+
 		return utils.FiberError(c, fiber.StatusInternalServerError, err)
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(newClassroom)
 }
 
+// Update replaces the classroom on PUT, so the title and the number of students are required and an
+// omitted description is cleared. PATCH changes only the fields which are set.
+func (h ClassroomHandler) Update(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	classroomId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	req := core.UpdateClassroomRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	if c.Method() == fiber.MethodPut {
+		if req.Title == nil || req.MaxStudents == nil {
+			return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the required parameters cannot be empty"))
+		}
+
+		if req.Description == nil {
+			req.Description = new(string)
+		}
+	}
+
+	classroom, err := h.classroomUseCase.Update(ctx, claims, classroomId, req)
+	if err != nil {
+		return classroomError(c, err)
+	}
+
+	return c.JSON(classroom)
+}
+
+// Clone copies the classroom with its lessons. The body with the options may be omitted.
+func (h ClassroomHandler) Clone(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	classroomId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	req := core.CloneClassroomRequest{}
+
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return utils.FiberError(c, fiber.StatusBadRequest, err)
+		}
+	}
+
+	classroom, err := h.classroomUseCase.Clone(ctx, claims, classroomId, req)
+	if err != nil {
+		return classroomError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(classroom)
+}
+
+// Delete archives the classroom. It is deleted for good only by Purge.
 func (h ClassroomHandler) Delete(c *fiber.Ctx) error {
 	ctx := c.UserContext()
 	claims := jwt.ExtractTokenMetadata(c)
@@ -76,16 +169,47 @@ func (h ClassroomHandler) Delete(c *fiber.Ctx) error {
 		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be int"))
 	}
 
-	if err := h.classroomUseCase.Delete(ctx, claims, classroomId); err != nil {
-		if errors.Is(err, apperrors.AccessDenied) {
-			return utils.FiberError(c, fiber.StatusForbidden, err)
-		}
+	if _, err := h.classroomUseCase.Archive(ctx, claims, classroomId); err != nil {
+		return classroomError(c, err)
+	}
 
-		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "classroom successfully archived",
+	})
+}
+
+func (h ClassroomHandler) Restore(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	classroomId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	classroom, err := h.classroomUseCase.Restore(ctx, claims, classroomId)
+	if err != nil {
+		return classroomError(c, err)
+	}
+
+	return c.JSON(classroom)
+}
+
+func (h ClassroomHandler) Purge(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	classroomId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	if err := h.classroomUseCase.Purge(ctx, claims, classroomId); err != nil {
+		return classroomError(c, err)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "classroom successfully deleted",
+		"message": "classroom successfully purged",
 	})
 }
 
@@ -148,11 +272,7 @@ func (h ClassroomHandler) CreateLesson(c *fiber.Ctx) error {
 
 	newLesson, err := h.lessonUseCase.Create(ctx, claims, classroomId, req)
 	if err != nil {
-		if errors.Is(err, apperrors.AccessDenied) {
-			return utils.FiberError(c, fiber.StatusForbidden, err)
-		}
-
-		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+		return classroomError(c, err)
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(newLesson)
@@ -180,11 +300,7 @@ func (h ClassroomHandler) UpdateLesson(c *fiber.Ctx) error {
 	}
 
 	if err := h.lessonUseCase.Update(ctx, claims, req); err != nil {
-		if errors.Is(err, apperrors.AccessDenied) {
-			return utils.FiberError(c, fiber.StatusForbidden, err)
-		}
-
-		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+		return classroomError(c, err)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -201,7 +317,7 @@ func (h ClassroomHandler) Students(c *fiber.Ctx) error {
 		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
 	}
 
-	students, err := h.classroomUseCase.Students(ctx, claims, classroomId)
+	students, err := h.classroomUseCase.Students(ctx, claims, classroomId, c.QueryBool("include_inactive"))
 	if err != nil {
 		if errors.Is(err, apperrors.AccessDenied) {
 			return utils.FiberError(c, fiber.StatusForbidden, err)
@@ -212,3 +328,110 @@ func (h ClassroomHandler) Students(c *fiber.Ctx) error {
 
 	return c.JSON(students)
 }
+
+// EnrollStudent enrolls the student from the path, or puts the student on the waitlist when the
+// classroom is full.
+func (h ClassroomHandler) EnrollStudent(c *fiber.Ctx) error {
+	return h.changeStudent(c, h.enroll)
+}
+
+func (h ClassroomHandler) UnenrollStudent(c *fiber.Ctx) error {
+	return h.changeStudent(c, h.unenroll("student successfully unenrolled"))
+}
+
+// EnrollStudents enrolls the students from the body. The ones who do not fit into the classroom are
+// put on the waitlist.
+func (h ClassroomHandler) EnrollStudents(c *fiber.Ctx) error {
+	return h.changeStudents(c, h.enroll)
+}
+
+func (h ClassroomHandler) UnenrollStudents(c *fiber.Ctx) error {
+	return h.changeStudents(c, h.unenroll("students successfully unenrolled"))
+}
+
+type enrollmentFunc func(c *fiber.Ctx, classroomId int, studentsId []int) error
+
+func (h ClassroomHandler) enroll(c *fiber.Ctx, classroomId int, studentsId []int) error {
+	enrollment, err := h.classroomUseCase.Enroll(c.UserContext(), jwt.ExtractTokenMetadata(c), classroomId, studentsId)
+	if err != nil {
+		return classroomError(c, err)
+	}
+
+	return c.JSON(enrollment)
+}
+
+func (h ClassroomHandler) unenroll(message string) enrollmentFunc {
+	return func(c *fiber.Ctx, classroomId int, studentsId []int) error {
+		if err := h.classroomUseCase.Unenroll(
+			c.UserContext(),
+			jwt.ExtractTokenMetadata(c),
+			classroomId,
+			studentsId,
+		); err != nil {
+			return classroomError(c, err)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"message": message,
+		})
+	}
+}
+
+// changeStudent enrolls or unenrolls the student from the path.
+func (h ClassroomHandler) changeStudent(c *fiber.Ctx, change enrollmentFunc) error {
+	classroomId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	studentId, err := c.ParamsInt("studentId")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the student id must be number"))
+	}
+
+	return change(c, classroomId, []int{studentId})
+}
+
+// changeStudents enrolls or unenrolls the students from the body.
+func (h ClassroomHandler) changeStudents(c *fiber.Ctx, change enrollmentFunc) error {
+	classroomId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	req := core.EnrollStudentsRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	if len(req.StudentsId) == 0 {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the required parameters cannot be empty"))
+	}
+
+	return change(c, classroomId, req.StudentsId)
+}
+
+func classroomError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, apperrors.AccessDenied) {
+		return utils.FiberError(c, fiber.StatusForbidden, err)
+	}
+
+	if errors.Is(err, apperrors.EntityNotFound) {
+		return utils.FiberError(c, fiber.StatusNotFound, err)
+	}
+
+	if errors.Is(err, apperrors.InvalidClassroom) ||
+		errors.Is(err, apperrors.MaxStudentsBelowEnrolled) ||
+		errors.Is(err, apperrors.NumberOfStudentsExceeded) {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	if errors.Is(err, apperrors.ClassroomArchived) ||
+		errors.Is(err, apperrors.ClassroomNotArchived) ||
+		errors.Is(err, apperrors.RetentionPeriodNotOver) {
+		return utils.FiberError(c, fiber.StatusConflict, err)
+	}
+
+	return utils.FiberError(c, fiber.StatusInternalServerError, err)
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/jwt"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+	"net/url"
+	"strings"
+)
+
+type ExportUseCase interface {
+	Start(ctx context.Context, metadata core.TokenMetadata) (core.ExportJobResponse, error)
+	All(ctx context.Context, metadata core.TokenMetadata) ([]core.ExportJobResponse, error)
+	ById(ctx context.Context, metadata core.TokenMetadata, id int) (core.ExportJobResponse, error)
+	Link(ctx context.Context, metadata core.TokenMetadata, id int) (core.ExportLink, error)
+	Download(ctx context.Context, token string) (core.ExportFile, error)
+}
+
+type ExportHandler struct {
+	exportUseCase ExportUseCase
+	publicURL     string
+}
+
+func NewExportHandler(exportUseCase ExportUseCase, publicURL string) *ExportHandler {
+	return &ExportHandler{exportUseCase: exportUseCase, publicURL: strings.TrimSuffix(publicURL, "/")}
+}
+
+// Start begins an export of the institution. The export is built in the background, the response
+// only tells its id.
+func (h ExportHandler) Start(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	job, err := h.exportUseCase.Start(ctx, claims)
+	if err != nil {
+		return exportError(c, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(job)
+}
+
+func (h ExportHandler) All(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	jobs, err := h.exportUseCase.All(ctx, claims)
+	if err != nil {
+		return exportError(c, err)
+	}
+
+	return c.JSON(jobs)
+}
+
+func (h ExportHandler) ById(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	job, err := h.exportUseCase.ById(ctx, claims, id)
+	if err != nil {
+		return exportError(c, err)
+	}
+
+	return c.JSON(job)
+}
+
+// Link returns a download link of the finished export, which works without authentication until the
+// export expires. The link is built from the configured public URL, never from the Host header of the
+// request, which the client controls.
+func (h ExportHandler) Link(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	link, err := h.exportUseCase.Link(ctx, claims, id)
+	if err != nil {
+		return exportError(c, err)
+	}
+
+	return c.JSON(core.ExportLinkResponse{
+		URL:       h.publicURL + "/api/v1/exports/download?token=" + url.QueryEscape(link.Token),
+		ExpiresAt: link.ExpiresAt,
+	})
+}
+
+func (h ExportHandler) Download(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	token := c.Query("token")
+	if token == "" {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the required parameters cannot be empty"))
+	}
+
+	file, err := h.exportUseCase.Download(ctx, token)
+	if err != nil {
+		if errors.Is(err, apperrors.InvalidToken) || errors.Is(err, apperrors.ExpiredToken) {
+			return utils.FiberError(c, fiber.StatusForbidden, err)
+		}
+
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return utils.FiberError(c, fiber.StatusNotFound, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.Download(file.Path, file.Name)
+}
+
+func exportError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, apperrors.AccessDenied) {
+		return utils.FiberError(c, fiber.StatusForbidden, err)
+	}
+
+	if errors.Is(err, apperrors.EntityNotFound) {
+		return utils.FiberError(c, fiber.StatusNotFound, err)
+	}
+
+	if errors.Is(err, apperrors.ExportInProgress) || errors.Is(err, apperrors.ExportNotReady) {
+		return utils.FiberError(c, fiber.StatusConflict, err)
+	}
+
+	return utils.FiberError(c, fiber.StatusInternalServerError, err)
+}
@@ -6,40 +6,82 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	httpLog "github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/migmatore/study-platform-api/config"
+	"github.com/migmatore/study-platform-api/internal/core"
 	"github.com/migmatore/study-platform-api/pkg/jwt"
+	"github.com/migmatore/study-platform-api/pkg/utils"
 )
 
 type Deps struct {
-	AuthUseCase      AuthUseCase
-	UserUseCase      UserUseCase
-	ClassroomUseCase ClassroomUseCase
-	LessonUseCase    LessonUseCase
-	StudentUseCase   StudentUseCase
-	TeacherUseCase   TeacherUseCase
+	KeySet               *jwt.KeySet
+	AuthUseCase          AuthUseCase
+	UserUseCase          UserUseCase
+	MFAUseCase           MFAUseCase
+	InstitutionUseCase   InstitutionUseCase
+	InvitationUseCase    InvitationUseCase
+	APIKeyUseCase        APIKeyUseCase
+	ImpersonationUseCase ImpersonationUseCase
+	RoleUseCase          RoleUseCase
+	AuditUseCase         AuditUseCase
+	ClassroomUseCase     ClassroomUseCase
+	WaitlistUseCase      WaitlistUseCase
+	StaffUseCase         StaffUseCase
+	LessonUseCase        LessonUseCase
+	StudentUseCase       StudentUseCase
+	TeacherUseCase       TeacherUseCase
+	ImportUseCase        ImportUseCase
+	ExportUseCase        ExportUseCase
+	PrivacyUseCase       PrivacyUseCase
 }
 
 type Handler struct {
 	config *config.Config
+	keySet *jwt.KeySet
 	app    *fiber.App
 
-	auth      *AuthHandler
-	user      *UserHandler
-	classroom *ClassroomHandler
-	lesson    *LessonHandler
-	student   *StudentHandler
-	teacher   *TeacherHandler
+	auth          *AuthHandler
+	user          *UserHandler
+	mfa           *MFAHandler
+	institution   *InstitutionHandler
+	invitation    *InvitationHandler
+	apiKey        *APIKeyHandler
+	impersonation *ImpersonationHandler
+	role          *RoleHandler
+	audit         *AuditHandler
+	classroom     *ClassroomHandler
+	waitlist      *WaitlistHandler
+	staff         *StaffHandler
+	lesson        *LessonHandler
+	student       *StudentHandler
+	teacher       *TeacherHandler
+	importer      *ImportHandler
+	export        *ExportHandler
+	privacy       *PrivacyHandler
 }
 
 func New(config *config.Config, deps Deps) *Handler {
 	return &Handler{
-		config:    config,
-		auth:      NewAuthHandler(deps.AuthUseCase),
-		user:      NewUserHandler(deps.UserUseCase),
-		classroom: NewClassroomHandler(deps.ClassroomUseCase, deps.LessonUseCase),
-		lesson:    NewLessonHandler(deps.LessonUseCase),
-		student:   NewStudentsHandler(deps.StudentUseCase),
-		teacher:   NewTeacherHandler(deps.TeacherUseCase),
+		config:        config,
+		keySet:        deps.KeySet,
+		auth:          NewAuthHandler(deps.AuthUseCase),
+		user:          NewUserHandler(deps.UserUseCase),
+		mfa:           NewMFAHandler(deps.MFAUseCase),
+		institution:   NewInstitutionHandler(deps.InstitutionUseCase),
+		invitation:    NewInvitationHandler(deps.InvitationUseCase),
+		apiKey:        NewAPIKeyHandler(deps.APIKeyUseCase),
+		impersonation: NewImpersonationHandler(deps.ImpersonationUseCase),
+		role:          NewRoleHandler(deps.RoleUseCase),
+		audit:         NewAuditHandler(deps.AuditUseCase),
+		classroom:     NewClassroomHandler(deps.ClassroomUseCase, deps.LessonUseCase),
+		waitlist:      NewWaitlistHandler(deps.WaitlistUseCase),
+		staff:         NewStaffHandler(deps.StaffUseCase),
+		lesson:        NewLessonHandler(deps.LessonUseCase),
+		student:       NewStudentsHandler(deps.StudentUseCase),
+		teacher:       NewTeacherHandler(deps.TeacherUseCase),
+		importer:      NewImportHandler(deps.ImportUseCase),
+		export:        NewExportHandler(deps.ExportUseCase, config.Server.PublicURL),
+		privacy:       NewPrivacyHandler(deps.PrivacyUseCase),
 	}
 }
 
@@ -52,40 +94,119 @@ func (h *Handler) Init(ctx context.Context) *fiber.App {
 		AllowHeaders: "*",
 	}))
 	//h.app.Use(cors.New())
+	h.app.Use(requestid.New())
 	h.app.Use(httpLog.New())
 	h.app.Use(func(c *fiber.Ctx) error {
-		c.SetUserContext(ctx)
+		// The use cases record the request in the audit log.
+		c.SetUserContext(utils.WithRequestInfo(ctx, core.RequestInfo{
+			Id:     c.GetRespHeader(fiber.HeaderXRequestID),
+			Client: clientInfo(c),
+		}))
 
 		return c.Next()
 	})
 
+	h.app.Get("/.well-known/jwks.json", h.jwks)
+
 	api := h.app.Group("/api")
 	v1 := api.Group("/v1")
 
 	auth := v1.Group("/auth")
 	auth.Post("/signin", h.auth.Signin)
+	auth.Post("/mfa/verify", h.auth.VerifyMFA)
+	auth.Get("/oidc/:institution/login", h.auth.OIDCLogin)
+	auth.Get("/oidc/:institution/callback", h.auth.OIDCCallback)
 	auth.Post("/signup", h.auth.Signup)
+	auth.Post("/signup/invite", h.auth.SignupInvite)
 	auth.Post("/refresh", h.auth.Refresh)
+	auth.Post("/logout", h.auth.Logout)
+	auth.Post("/password/forgot", h.auth.ForgotPassword)
+	auth.Post("/password/reset", h.auth.ResetPassword)
+	auth.Post("/verify-email", h.auth.VerifyEmail)
+	auth.Post("/verify-email/resend", h.auth.ResendVerification)
+
+	// Download links of exports carry their own token, so they can be opened without signing in.
+	v1.Get("/exports/download", h.export.Download)
 
+	v1.Use(h.apiKey.Middleware)
 	v1.Use(jwtware.New(jwtware.Config{
-		SigningKey:   jwtware.SigningKey{Key: []byte(h.config.Server.JwtSecretKey)},
+		// Requests authenticated with an API key do not carry a token.
+		Filter: func(c *fiber.Ctx) bool {
+			return c.Locals(jwt.APIKeyContextKey) != nil
+		},
+		KeyFunc:      h.keySet.Keyfunc(jwt.AccessToken),
 		ContextKey:   "jwt",
 		ErrorHandler: jwt.JwtError,
 	}))
+	v1.Use(h.impersonation.Middleware)
 	users := v1.Group("/users")
 	users.Get("/profile", h.user.Profile)
 	users.Put("/profile", h.user.UpdateProfile)
+	users.Get("/sessions", h.user.Sessions)
+	users.Get("/data", h.privacy.PersonalData)
+	users.Post("/erasure", h.privacy.RequestErasure)
+	users.Delete("/sessions/:id", h.user.RevokeSession)
+	users.Get("/:id/sessions", h.user.UserSessions)
+	users.Delete("/:id/sessions/:sessionId", h.user.RevokeUserSession)
+	users.Delete("/:id/mfa", h.user.ResetUserMFA)
+	users.Post("/:id/unlock", h.user.UnlockUser)
+	users.Post("/:id/deactivate", h.user.Deactivate)
+	users.Post("/:id/reactivate", h.user.Reactivate)
+	users.Post("/:id/erasure", h.privacy.RequestUserErasure)
+	users.Post("/:id/impersonate", h.impersonation.Impersonate)
+	users.Get("/mfa", h.mfa.Status)
+	users.Post("/mfa/enroll", h.mfa.Enroll)
+	users.Post("/mfa/confirm", h.mfa.Confirm)
+	users.Post("/mfa/recovery-codes", h.mfa.RecoveryCodes)
+	users.Delete("/mfa", h.mfa.Disable)
+	users.Get("/api-keys", h.apiKey.PersonalKeys)
+	users.Post("/api-keys", h.apiKey.CreatePersonalKey)
+	users.Delete("/api-keys/:id", h.apiKey.RevokePersonalKey)
+
+	institutions := v1.Group("/institutions")
+	institutions.Get("/oidc", h.institution.OIDCProvider)
+	institutions.Put("/oidc", h.institution.UpdateOIDCProvider)
+	institutions.Get("/api-keys", h.apiKey.InstitutionKeys)
+	institutions.Post("/api-keys", h.apiKey.CreateInstitutionKey)
+	institutions.Delete("/api-keys/:id", h.apiKey.RevokeInstitutionKey)
+	institutions.Get("/roles", h.role.All)
+	institutions.Post("/roles", h.role.Create)
+	institutions.Put("/roles/:id", h.role.Update)
+	institutions.Delete("/roles/:id", h.role.Delete)
 
 	classrooms := v1.Group("/classrooms")
 	classrooms.Get("/", h.classroom.All)
 	classrooms.Post("/", h.classroom.Create)
+	classrooms.Put("/:id", h.classroom.Update)
+	classrooms.Patch("/:id", h.classroom.Update)
 	classrooms.Delete("/:id", h.classroom.Delete)
+	classrooms.Post("/:id/clone", h.classroom.Clone)
+	classrooms.Post("/:id/restore", h.classroom.Restore)
+	classrooms.Delete("/:id/purge", h.classroom.Purge)
 	classrooms.Get("/:id/lessons", h.classroom.Lessons)
 	classrooms.Get("/:id/lessons/current", h.classroom.CurrentLesson)
 	classrooms.Post("/:id/lessons", h.classroom.CreateLesson)
 	classrooms.Put("/:id/lessons", h.classroom.UpdateLesson)
 
 	classrooms.Get("/:id/students", h.classroom.Students)
+	classrooms.Post("/:id/students", h.classroom.EnrollStudents)
+	classrooms.Delete("/:id/students", h.classroom.UnenrollStudents)
+	classrooms.Post("/:id/students/:studentId", h.classroom.EnrollStudent)
+	classrooms.Delete("/:id/students/:studentId", h.classroom.UnenrollStudent)
+	classrooms.Get("/:id/waitlist", h.waitlist.All)
+	classrooms.Put("/:id/waitlist", h.waitlist.Reorder)
+	classrooms.Post("/:id/waitlist/:studentId/promote", h.waitlist.Promote)
+	classrooms.Get("/:id/invitations", h.invitation.All)
+	classrooms.Post("/:id/invitations", h.invitation.Invite)
+	classrooms.Delete("/:id/invitations/:invitationId", h.invitation.Revoke)
+	classrooms.Post("/:id/join-codes", h.invitation.CreateJoinCode)
+	classrooms.Get("/:id/staff", h.staff.All)
+	classrooms.Put("/:id/staff", h.staff.Add)
+	classrooms.Delete("/:id/staff/:userId", h.staff.Remove)
+	classrooms.Put("/:id/owner", h.staff.TransferOwnership)
+	classrooms.Get("/:id/roles", h.role.Assignments)
+	classrooms.Put("/:id/roles/:userId", h.role.Assign)
+	classrooms.Delete("/:id/roles/:userId", h.role.Unassign)
 
 	lessons := v1.Group("/lessons")
 	lessons.Get("/:id", h.lesson.ById)
@@ -101,5 +222,28 @@ func (h *Handler) Init(ctx context.Context) *fiber.App {
 	teachers.Post("/", h.teacher.Create)
 	teachers.Delete("/:id", h.teacher.Delete)
 
+	imports := v1.Group("/imports")
+	imports.Post("/users", h.importer.Users)
+
+	exports := v1.Group("/exports")
+	exports.Get("/", h.export.All)
+	exports.Post("/", h.export.Start)
+	exports.Get("/:id", h.export.ById)
+	exports.Post("/:id/link", h.export.Link)
+
+	erasures := v1.Group("/erasures")
+	erasures.Get("/", h.privacy.Erasures)
+	erasures.Post("/:id/approve", h.privacy.Approve)
+	erasures.Post("/:id/reject", h.privacy.Reject)
+
+	v1.Get("/audit", h.audit.All)
+
 	return h.app
 }
+
+// jwks publishes the public keys, so other services can verify our tokens without sharing a secret.
+func (h *Handler) jwks(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+
+	return c.JSON(h.keySet.JWKS())
+}
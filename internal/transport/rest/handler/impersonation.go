@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/jwt"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+	"strconv"
+)
+
+// impersonatorHeader flags responses to requests made under impersonation.
+const impersonatorHeader = "X-Impersonator-Id"
+
+type ImpersonationUseCase interface {
+	Impersonate(
+		ctx context.Context,
+		metadata core.TokenMetadata,
+		userId int,
+		client core.ClientInfo,
+	) (core.ImpersonationResponse, error)
+	RecordRequest(
+		ctx context.Context,
+		metadata core.TokenMetadata,
+		method string,
+		path string,
+		client core.ClientInfo,
+	) error
+}
+
+type ImpersonationHandler struct {
+	impersonationUseCase ImpersonationUseCase
+}
+
+func NewImpersonationHandler(impersonationUseCase ImpersonationUseCase) *ImpersonationHandler {
+	return &ImpersonationHandler{impersonationUseCase: impersonationUseCase}
+}
+
+// Middleware writes every request made with an impersonation token to the audit trail before it is
+// handled, so nothing can be done on behalf of a user without a trace.
+func (h ImpersonationHandler) Middleware(c *fiber.Ctx) error {
+	claims := jwt.ExtractTokenMetadata(c)
+
+	if claims.ImpersonatorId == 0 {
+		return c.Next()
+	}
+
+	if err := h.impersonationUseCase.RecordRequest(
+		c.UserContext(),
+		claims,
+		c.Method(),
+		c.OriginalURL(),
+		clientInfo(c),
+	); err != nil {
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	c.Set(impersonatorHeader, strconv.Itoa(claims.ImpersonatorId))
+
+	return c.Next()
+}
+
+func (h ImpersonationHandler) Impersonate(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	userId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	resp, err := h.impersonationUseCase.Impersonate(ctx, claims, userId, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, apperrors.AccessDenied) || errors.Is(err, apperrors.UserDeactivated) {
+			return utils.FiberError(c, fiber.StatusForbidden, err)
+		}
+
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return utils.FiberError(c, fiber.StatusNotFound, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
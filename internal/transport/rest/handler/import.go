@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/jwt"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+	"io"
+)
+
+type ImportUseCase interface {
+	Users(
+		ctx context.Context,
+		metadata core.TokenMetadata,
+		file core.ImportFile,
+		dryRun bool,
+	) (core.ImportResponse, error)
+}
+
+type ImportHandler struct {
+	importUseCase ImportUseCase
+}
+
+func NewImportHandler(importUseCase ImportUseCase) *ImportHandler {
+	return &ImportHandler{importUseCase: importUseCase}
+}
+
+// Users imports the accounts from the CSV or XLSX file in the "file" form field. With ?dry_run=true the
+// file is only validated.
+func (h ImportHandler) Users(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the file is required"))
+	}
+
+	f, err := header.Open()
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	defer f.Close()
+
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	resp, err := h.importUseCase.Users(
+		ctx,
+		claims,
+		core.ImportFile{Name: header.Filename, Content: content},
+		c.QueryBool("dry_run"),
+	)
+	if err != nil {
+		if errors.Is(err, apperrors.InvalidImport) {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(resp)
+		}
+
+		if errors.Is(err, apperrors.AccessDenied) {
+			return utils.FiberError(c, fiber.StatusForbidden, err)
+		}
+
+		if errors.Is(err, apperrors.InvalidImportFile) {
+			return utils.FiberError(c, fiber.StatusBadRequest, err)
+		}
+
+		if errors.Is(err, apperrors.EntityAlreadyExist) || errors.Is(err, apperrors.ClassroomArchived) {
+			return utils.FiberError(c, fiber.StatusConflict, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	if resp.DryRun {
+		return c.JSON(resp)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/jwt"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+)
+
+type InstitutionUseCase interface {
+	OIDCProvider(ctx context.Context, metadata core.TokenMetadata) (core.OIDCProviderResponse, error)
+	UpdateOIDCProvider(
+		ctx context.Context,
+		metadata core.TokenMetadata,
+		req core.UpdateOIDCProviderRequest,
+	) (core.OIDCProviderResponse, error)
+}
+
+type InstitutionHandler struct {
+	institutionUseCase InstitutionUseCase
+}
+
+func NewInstitutionHandler(institutionUseCase InstitutionUseCase) *InstitutionHandler {
+	return &InstitutionHandler{institutionUseCase: institutionUseCase}
+}
+
+func (h InstitutionHandler) OIDCProvider(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	provider, err := h.institutionUseCase.OIDCProvider(ctx, claims)
+	if err != nil {
+		if errors.Is(err, apperrors.AccessDenied) {
+			return utils.FiberError(c, fiber.StatusForbidden, err)
+		}
+
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return utils.FiberError(c, fiber.StatusNotFound, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(provider)
+}
+
+func (h InstitutionHandler) UpdateOIDCProvider(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+	req := core.UpdateOIDCProviderRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	if req.Issuer == "" || req.ClientId == "" {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the required parameters cannot be empty"))
+	}
+
+	provider, err := h.institutionUseCase.UpdateOIDCProvider(ctx, claims, req)
+	if err != nil {
+		if errors.Is(err, apperrors.AccessDenied) {
+			return utils.FiberError(c, fiber.StatusForbidden, err)
+		}
+
+		if errors.Is(err, apperrors.InvalidOIDCProvider) {
+			return utils.FiberError(c, fiber.StatusBadRequest, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(provider)
+}
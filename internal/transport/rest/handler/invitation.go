@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/jwt"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+)
+
+type InvitationUseCase interface {
+	Invite(
+		ctx context.Context,
+		metadata core.TokenMetadata,
+		classroomId int,
+		req core.CreateInvitationRequest,
+	) (core.InvitationResponse, error)
+	CreateJoinCode(
+		ctx context.Context,
+		metadata core.TokenMetadata,
+		classroomId int,
+		req core.CreateJoinCodeRequest,
+	) (core.InvitationResponse, error)
+	All(ctx context.Context, metadata core.TokenMetadata, classroomId int) ([]core.InvitationResponse, error)
+	Revoke(ctx context.Context, metadata core.TokenMetadata, classroomId int, invitationId int) error
+}
+
+type InvitationHandler struct {
+	invitationUseCase InvitationUseCase
+}
+
+func NewInvitationHandler(invitationUseCase InvitationUseCase) *InvitationHandler {
+	return &InvitationHandler{invitationUseCase: invitationUseCase}
+}
+
+func (h InvitationHandler) Invite(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	classroomId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	req := core.CreateInvitationRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	if req.Email == "" {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the required parameters cannot be empty"))
+	}
+
+	invitation, err := h.invitationUseCase.Invite(ctx, claims, classroomId, req)
+	if err != nil {
+		return invitationError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(invitation)
+}
+
+func (h InvitationHandler) CreateJoinCode(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	classroomId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	req := core.CreateJoinCodeRequest{}
+
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return utils.FiberError(c, fiber.StatusBadRequest, err)
+		}
+	}
+
+	if req.MaxUses != nil && *req.MaxUses <= 0 {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("max uses must be positive"))
+	}
+
+	code, err := h.invitationUseCase.CreateJoinCode(ctx, claims, classroomId, req)
+	if err != nil {
+		return invitationError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(code)
+}
+
+func (h InvitationHandler) All(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	classroomId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	invitations, err := h.invitationUseCase.All(ctx, claims, classroomId)
+	if err != nil {
+		return invitationError(c, err)
+	}
+
+	return c.JSON(invitations)
+}
+
+func (h InvitationHandler) Revoke(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	classroomId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	invitationId, err := c.ParamsInt("invitationId")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	if err := h.invitationUseCase.Revoke(ctx, claims, classroomId, invitationId); err != nil {
+		return invitationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "invitation successfully revoked",
+	})
+}
+
+func invitationError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, apperrors.AccessDenied) {
+		return utils.FiberError(c, fiber.StatusForbidden, err)
+	}
+
+	if errors.Is(err, apperrors.EntityNotFound) {
+		return utils.FiberError(c, fiber.StatusNotFound, err)
+	}
+
+	if errors.Is(err, apperrors.EntityAlreadyExist) {
+		return utils.FiberError(c, fiber.StatusConflict, err)
+	}
+
+	if errors.Is(err, apperrors.ClassroomArchived) {
+		return utils.FiberError(c, fiber.StatusConflict, err)
+	}
+
+	return utils.FiberError(c, fiber.StatusInternalServerError, err)
+}
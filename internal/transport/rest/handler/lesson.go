@@ -66,6 +66,10 @@ func (h LessonHandler) Delete(c *fiber.Ctx) error {
 			return utils.FiberError(c, fiber.StatusForbidden, err)
 		}
 
+		if errors.Is(err, apperrors.ClassroomArchived) {
+			return utils.FiberError(c, fiber.StatusConflict, err)
+		}
+
 		return utils.FiberError(c, fiber.StatusInternalServerError, err)
 	}
 
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/jwt"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+)
+
+type MFAUseCase interface {
+	Status(ctx context.Context, metadata core.TokenMetadata) (core.MFAStatusResponse, error)
+	Enroll(ctx context.Context, metadata core.TokenMetadata) (core.MFAEnrollResponse, error)
+	Confirm(ctx context.Context, metadata core.TokenMetadata, req core.MFACodeRequest) (core.MFARecoveryCodesResponse, error)
+	Disable(ctx context.Context, metadata core.TokenMetadata, req core.MFACodeRequest) error
+	RecoveryCodes(ctx context.Context, metadata core.TokenMetadata, req core.MFACodeRequest) (core.MFARecoveryCodesResponse, error)
+}
+
+type MFAHandler struct {
+	mfaUseCase MFAUseCase
+}
+
+func NewMFAHandler(mfaUseCase MFAUseCase) *MFAHandler {
+	return &MFAHandler{mfaUseCase: mfaUseCase}
+}
+
+func (h MFAHandler) Status(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	status, err := h.mfaUseCase.Status(ctx, claims)
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(status)
+}
+
+func (h MFAHandler) Enroll(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	enrollment, err := h.mfaUseCase.Enroll(ctx, claims)
+	if err != nil {
+		if errors.Is(err, apperrors.AccessDenied) {
+			return utils.FiberError(c, fiber.StatusForbidden, err)
+		}
+
+		if errors.Is(err, apperrors.EntityAlreadyExist) {
+			return utils.FiberError(c, fiber.StatusConflict, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(enrollment)
+}
+
+func (h MFAHandler) Confirm(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+	req := core.MFACodeRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	if req.Code == "" {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the required parameters cannot be empty"))
+	}
+
+	codes, err := h.mfaUseCase.Confirm(ctx, claims, req)
+	if err != nil {
+		if errors.Is(err, apperrors.AccessDenied) {
+			return utils.FiberError(c, fiber.StatusForbidden, err)
+		}
+
+		if errors.Is(err, apperrors.InvalidMFACode) {
+			return utils.FiberError(c, fiber.StatusBadRequest, err)
+		}
+
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return utils.FiberError(c, fiber.StatusNotFound, err)
+		}
+
+		if errors.Is(err, apperrors.EntityAlreadyExist) {
+			return utils.FiberError(c, fiber.StatusConflict, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(codes)
+}
+
+func (h MFAHandler) Disable(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+	req := core.MFACodeRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	if req.Code == "" {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the required parameters cannot be empty"))
+	}
+
+	if err := h.mfaUseCase.Disable(ctx, claims, req); err != nil {
+		if errors.Is(err, apperrors.AccessDenied) {
+			return utils.FiberError(c, fiber.StatusForbidden, err)
+		}
+
+		if errors.Is(err, apperrors.InvalidMFACode) {
+			return utils.FiberError(c, fiber.StatusBadRequest, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "mfa successfully disabled",
+	})
+}
+
+func (h MFAHandler) RecoveryCodes(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+	req := core.MFACodeRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	if req.Code == "" {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the required parameters cannot be empty"))
+	}
+
+	codes, err := h.mfaUseCase.RecoveryCodes(ctx, claims, req)
+	if err != nil {
+		if errors.Is(err, apperrors.AccessDenied) {
+			return utils.FiberError(c, fiber.StatusForbidden, err)
+		}
+
+		if errors.Is(err, apperrors.InvalidMFACode) {
+			return utils.FiberError(c, fiber.StatusBadRequest, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(codes)
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/jwt"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+)
+
+type PrivacyUseCase interface {
+	PersonalData(ctx context.Context, metadata core.TokenMetadata) (core.PersonalData, error)
+	RequestErasure(
+		ctx context.Context,
+		metadata core.TokenMetadata,
+		req core.CreateErasureRequest,
+	) (core.ErasureRequestResponse, error)
+	RequestUserErasure(
+		ctx context.Context,
+		metadata core.TokenMetadata,
+		userId int,
+		req core.CreateErasureRequest,
+	) (core.ErasureRequestResponse, error)
+	Erasures(ctx context.Context, metadata core.TokenMetadata) ([]core.ErasureRequestResponse, error)
+	Approve(ctx context.Context, metadata core.TokenMetadata, id int) error
+	Reject(ctx context.Context, metadata core.TokenMetadata, id int) error
+}
+
+type PrivacyHandler struct {
+	privacyUseCase PrivacyUseCase
+}
+
+func NewPrivacyHandler(privacyUseCase PrivacyUseCase) *PrivacyHandler {
+	return &PrivacyHandler{privacyUseCase: privacyUseCase}
+}
+
+// PersonalData sends everything stored about the user as a JSON file.
+func (h PrivacyHandler) PersonalData(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	data, err := h.privacyUseCase.PersonalData(ctx, claims)
+	if err != nil {
+		return privacyError(c, err)
+	}
+
+	c.Attachment("personal-data.json")
+
+	return c.JSON(data)
+}
+
+func (h PrivacyHandler) RequestErasure(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	req, err := erasureRequestBody(c)
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	request, err := h.privacyUseCase.RequestErasure(ctx, claims, req)
+	if err != nil {
+		return privacyError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(request)
+}
+
+func (h PrivacyHandler) RequestUserErasure(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	userId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	req, err := erasureRequestBody(c)
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	request, err := h.privacyUseCase.RequestUserErasure(ctx, claims, userId, req)
+	if err != nil {
+		return privacyError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(request)
+}
+
+func (h PrivacyHandler) Erasures(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	requests, err := h.privacyUseCase.Erasures(ctx, claims)
+	if err != nil {
+		return privacyError(c, err)
+	}
+
+	return c.JSON(requests)
+}
+
+func (h PrivacyHandler) Approve(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	if err := h.privacyUseCase.Approve(ctx, claims, id); err != nil {
+		return privacyError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "personal data successfully erased",
+	})
+}
+
+func (h PrivacyHandler) Reject(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	if err := h.privacyUseCase.Reject(ctx, claims, id); err != nil {
+		return privacyError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "erasure request successfully rejected",
+	})
+}
+
+// erasureRequestBody parses the optional body of an erasure request.
+func erasureRequestBody(c *fiber.Ctx) (core.CreateErasureRequest, error) {
+	req := core.CreateErasureRequest{}
+
+	if len(c.Body()) == 0 {
+		return req, nil
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return core.CreateErasureRequest{}, err
+	}
+
+	return req, nil
+}
+
+func privacyError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, apperrors.AccessDenied) {
+		return utils.FiberError(c, fiber.StatusForbidden, err)
+	}
+
+	if errors.Is(err, apperrors.EntityNotFound) {
+		return utils.FiberError(c, fiber.StatusNotFound, err)
+	}
+
+	if errors.Is(err, apperrors.ErasureInProgress) ||
+		errors.Is(err, apperrors.ErasureReviewed) ||
+		errors.Is(err, apperrors.UserErased) {
+		return utils.FiberError(c, fiber.StatusConflict, err)
+	}
+
+	return utils.FiberError(c, fiber.StatusInternalServerError, err)
+}
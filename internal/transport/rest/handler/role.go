@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/jwt"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+)
+
+type RoleUseCase interface {
+	All(ctx context.Context, metadata core.TokenMetadata) ([]core.RoleResponse, error)
+	Create(ctx context.Context, metadata core.TokenMetadata, req core.RoleRequest) (core.RoleResponse, error)
+	Update(ctx context.Context, metadata core.TokenMetadata, id int, req core.RoleRequest) (core.RoleResponse, error)
+	Delete(ctx context.Context, metadata core.TokenMetadata, id int) error
+	Assignments(ctx context.Context, metadata core.TokenMetadata, classroomId int) ([]core.ClassroomRoleResponse, error)
+	Assign(
+		ctx context.Context,
+		metadata core.TokenMetadata,
+		classroomId int,
+		userId int,
+		req core.AssignClassroomRoleRequest,
+	) error
+	Unassign(ctx context.Context, metadata core.TokenMetadata, classroomId int, userId int) error
+}
+
+type RoleHandler struct {
+	roleUseCase RoleUseCase
+}
+
+func NewRoleHandler(roleUseCase RoleUseCase) *RoleHandler {
+	return &RoleHandler{roleUseCase: roleUseCase}
+}
+
+func (h RoleHandler) All(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	roles, err := h.roleUseCase.All(ctx, claims)
+	if err != nil {
+		return roleError(c, err)
+	}
+
+	return c.JSON(roles)
+}
+
+func (h RoleHandler) Create(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	req, err := roleRequest(c)
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	role, err := h.roleUseCase.Create(ctx, claims, req)
+	if err != nil {
+		return roleError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(role)
+}
+
+func (h RoleHandler) Update(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	req, err := roleRequest(c)
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	role, err := h.roleUseCase.Update(ctx, claims, id, req)
+	if err != nil {
+		return roleError(c, err)
+	}
+
+	return c.JSON(role)
+}
+
+func (h RoleHandler) Delete(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	if err := h.roleUseCase.Delete(ctx, claims, id); err != nil {
+		return roleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "role successfully deleted",
+	})
+}
+
+func (h RoleHandler) Assignments(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	classroomId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	assignments, err := h.roleUseCase.Assignments(ctx, claims, classroomId)
+	if err != nil {
+		return roleError(c, err)
+	}
+
+	return c.JSON(assignments)
+}
+
+func (h RoleHandler) Assign(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	classroomId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	userId, err := c.ParamsInt("userId")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the user id must be number"))
+	}
+
+	req := core.AssignClassroomRoleRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	if req.RoleId == 0 {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the required parameters cannot be empty"))
+	}
+
+	if err := h.roleUseCase.Assign(ctx, claims, classroomId, userId, req); err != nil {
+		return roleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "role successfully assigned",
+	})
+}
+
+func (h RoleHandler) Unassign(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	classroomId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	userId, err := c.ParamsInt("userId")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the user id must be number"))
+	}
+
+	if err := h.roleUseCase.Unassign(ctx, claims, classroomId, userId); err != nil {
+		return roleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "role successfully unassigned",
+	})
+}
+
+func roleRequest(c *fiber.Ctx) (core.RoleRequest, error) {
+	req := core.RoleRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return core.RoleRequest{}, err
+	}
+
+	if req.Name == "" || len(req.Permissions) == 0 {
+		return core.RoleRequest{}, errors.New("the required parameters cannot be empty")
+	}
+
+	return req, nil
+}
+
+func roleError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, apperrors.AccessDenied) {
+		return utils.FiberError(c, fiber.StatusForbidden, err)
+	}
+
+	if errors.Is(err, apperrors.EntityNotFound) {
+		return utils.FiberError(c, fiber.StatusNotFound, err)
+	}
+
+	if errors.Is(err, apperrors.EntityAlreadyExist) {
+		return utils.FiberError(c, fiber.StatusConflict, err)
+	}
+
+	if errors.Is(err, apperrors.InvalidPermission) || errors.Is(err, apperrors.InvalidRoleName) {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	if errors.Is(err, apperrors.ClassroomArchived) {
+		return utils.FiberError(c, fiber.StatusConflict, err)
+	}
+
+	return utils.FiberError(c, fiber.StatusInternalServerError, err)
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/jwt"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+)
+
+type StaffUseCase interface {
+	All(ctx context.Context, metadata core.TokenMetadata, classroomId int) ([]core.ClassroomStaffResponse, error)
+	Add(
+		ctx context.Context,
+		metadata core.TokenMetadata,
+		classroomId int,
+		req core.AddClassroomStaffRequest,
+	) (core.ClassroomStaffResponse, error)
+	Remove(ctx context.Context, metadata core.TokenMetadata, classroomId int, userId int) error
+	TransferOwnership(
+		ctx context.Context,
+		metadata core.TokenMetadata,
+		classroomId int,
+		req core.TransferClassroomOwnershipRequest,
+	) (core.ClassroomResponse, error)
+}
+
+type StaffHandler struct {
+	staffUseCase StaffUseCase
+}
+
+func NewStaffHandler(staffUseCase StaffUseCase) *StaffHandler {
+	return &StaffHandler{staffUseCase: staffUseCase}
+}
+
+func (h StaffHandler) All(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	classroomId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	staff, err := h.staffUseCase.All(ctx, claims, classroomId)
+	if err != nil {
+		return staffError(c, err)
+	}
+
+	return c.JSON(staff)
+}
+
+func (h StaffHandler) Add(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	classroomId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	req := core.AddClassroomStaffRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	if req.UserId == 0 || req.Role == "" {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the required parameters cannot be empty"))
+	}
+
+	member, err := h.staffUseCase.Add(ctx, claims, classroomId, req)
+	if err != nil {
+		return staffError(c, err)
+	}
+
+	return c.JSON(member)
+}
+
+func (h StaffHandler) Remove(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	classroomId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	userId, err := c.ParamsInt("userId")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the user id must be number"))
+	}
+
+	if err := h.staffUseCase.Remove(ctx, claims, classroomId, userId); err != nil {
+		return staffError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "staff member successfully removed",
+	})
+}
+
+func (h StaffHandler) TransferOwnership(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	classroomId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	req := core.TransferClassroomOwnershipRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	if req.UserId == 0 {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the required parameters cannot be empty"))
+	}
+
+	classroom, err := h.staffUseCase.TransferOwnership(ctx, claims, classroomId, req)
+	if err != nil {
+		return staffError(c, err)
+	}
+
+	return c.JSON(classroom)
+}
+
+func staffError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, apperrors.AccessDenied) {
+		return utils.FiberError(c, fiber.StatusForbidden, err)
+	}
+
+	if errors.Is(err, apperrors.EntityNotFound) {
+		return utils.FiberError(c, fiber.StatusNotFound, err)
+	}
+
+	if errors.Is(err, apperrors.InvalidStaffRole) {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	if errors.Is(err, apperrors.ClassroomArchived) {
+		return utils.FiberError(c, fiber.StatusConflict, err)
+	}
+
+	return utils.FiberError(c, fiber.StatusInternalServerError, err)
+}
@@ -11,7 +11,7 @@ import (
 )
 
 type StudentUseCase interface {
-	All(ctx context.Context, metadata core.TokenMetadata) ([]core.StudentResponse, error)
+	All(ctx context.Context, metadata core.TokenMetadata, includeInactive bool) ([]core.StudentResponse, error)
 	Create(ctx context.Context, metadata core.TokenMetadata, req core.CreateStudentRequest) (core.StudentResponse, error)
 	Delete(ctx context.Context, metadata core.TokenMetadata, id int) error
 }
@@ -28,7 +28,7 @@ func (h StudentHandler) Students(c *fiber.Ctx) error {
 	ctx := c.UserContext()
 	claims := jwt.ExtractTokenMetadata(c)
 
-	students, err := h.studentUseCase.All(ctx, claims)
+	students, err := h.studentUseCase.All(ctx, claims, c.QueryBool("include_inactive"))
 	if err != nil {
 		if errors.Is(err, apperrors.AccessDenied) {
 			return utils.FiberError(c, fiber.StatusForbidden, err)
@@ -63,6 +63,10 @@ func (h StudentHandler) Create(c *fiber.Ctx) error {
 			return utils.FiberError(c, fiber.StatusBadRequest, err)
 		}
 
+		if errors.Is(err, apperrors.ClassroomArchived) {
+			return utils.FiberError(c, fiber.StatusConflict, err)
+		}
+
 		return utils.FiberError(c, fiber.StatusInternalServerError, err)
 	}
 
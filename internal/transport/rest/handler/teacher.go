@@ -11,7 +11,7 @@ import (
 )
 
 type TeacherUseCase interface {
-	All(ctx context.Context, metadata core.TokenMetadata) ([]core.TeacherResponse, error)
+	All(ctx context.Context, metadata core.TokenMetadata, includeInactive bool) ([]core.TeacherResponse, error)
 	Create(ctx context.Context, metadata core.TokenMetadata, req core.CreateTeacherRequest) (core.TeacherResponse, error)
 	Delete(ctx context.Context, metadata core.TokenMetadata, id int) error
 }
@@ -28,7 +28,7 @@ func (h TeacherHandler) All(c *fiber.Ctx) error {
 	ctx := c.UserContext()
 	claims := jwt.ExtractTokenMetadata(c)
 
-	teachers, err := h.teacherUseCase.All(ctx, claims)
+	teachers, err := h.teacherUseCase.All(ctx, claims, c.QueryBool("include_inactive"))
 	if err != nil {
 		if errors.Is(err, apperrors.AccessDenied) {
 			return utils.FiberError(c, fiber.StatusForbidden, err)
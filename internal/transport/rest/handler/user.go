@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/migmatore/study-platform-api/internal/apperrors"
 	"github.com/migmatore/study-platform-api/internal/core"
 	"github.com/migmatore/study-platform-api/pkg/jwt"
@@ -13,6 +14,14 @@ import (
 type UserUseCase interface {
 	Profile(ctx context.Context, metadata core.TokenMetadata) (core.ProfileResponse, error)
 	UpdateProfile(ctx context.Context, metadata core.TokenMetadata, req core.UpdateProfileRequest) (core.ProfileResponse, error)
+	Sessions(ctx context.Context, metadata core.TokenMetadata) ([]core.SessionResponse, error)
+	RevokeSession(ctx context.Context, metadata core.TokenMetadata, sessionId string) error
+	UserSessions(ctx context.Context, metadata core.TokenMetadata, userId int) ([]core.SessionResponse, error)
+	RevokeUserSession(ctx context.Context, metadata core.TokenMetadata, userId int, sessionId string) error
+	ResetUserMFA(ctx context.Context, metadata core.TokenMetadata, userId int) error
+	UnlockUser(ctx context.Context, metadata core.TokenMetadata, userId int) error
+	Deactivate(ctx context.Context, metadata core.TokenMetadata, userId int) error
+	Reactivate(ctx context.Context, metadata core.TokenMetadata, userId int) error
 }
 
 type UserHandler struct {
@@ -50,6 +59,10 @@ func (h UserHandler) UpdateProfile(c *fiber.Ctx) error {
 
 	newProfile, err := h.userUseCase.UpdateProfile(ctx, claims, req)
 	if err != nil {
+		if errors.Is(err, apperrors.AccessDenied) {
+			return utils.FiberError(c, fiber.StatusForbidden, err)
+		}
+
 		if errors.Is(err, apperrors.EntityNotFound) {
 			return utils.FiberError(c, fiber.StatusNotFound, err)
 		}
@@ -63,3 +76,198 @@ func (h UserHandler) UpdateProfile(c *fiber.Ctx) error {
 
 	return c.JSON(newProfile)
 }
+
+func (h UserHandler) Sessions(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	sessions, err := h.userUseCase.Sessions(ctx, claims)
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(sessions)
+}
+
+func (h UserHandler) RevokeSession(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	// A malformed id cannot belong to any session, so it is not found rather than passed to the database.
+	sessionId, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusNotFound, apperrors.EntityNotFound)
+	}
+
+	if err := h.userUseCase.RevokeSession(ctx, claims, sessionId.String()); err != nil {
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return utils.FiberError(c, fiber.StatusNotFound, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "session successfully revoked",
+	})
+}
+
+func (h UserHandler) UserSessions(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	userId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	sessions, err := h.userUseCase.UserSessions(ctx, claims, userId)
+	if err != nil {
+		if errors.Is(err, apperrors.AccessDenied) {
+			return utils.FiberError(c, fiber.StatusForbidden, err)
+		}
+
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return utils.FiberError(c, fiber.StatusNotFound, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(sessions)
+}
+
+func (h UserHandler) RevokeUserSession(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	userId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	sessionId, err := uuid.Parse(c.Params("sessionId"))
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusNotFound, apperrors.EntityNotFound)
+	}
+
+	if err := h.userUseCase.RevokeUserSession(ctx, claims, userId, sessionId.String()); err != nil {
+		if errors.Is(err, apperrors.AccessDenied) {
+			return utils.FiberError(c, fiber.StatusForbidden, err)
+		}
+
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return utils.FiberError(c, fiber.StatusNotFound, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "session successfully revoked",
+	})
+}
+
+func (h UserHandler) ResetUserMFA(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	userId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	if err := h.userUseCase.ResetUserMFA(ctx, claims, userId); err != nil {
+		if errors.Is(err, apperrors.AccessDenied) {
+			return utils.FiberError(c, fiber.StatusForbidden, err)
+		}
+
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return utils.FiberError(c, fiber.StatusNotFound, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "mfa successfully reset",
+	})
+}
+
+func (h UserHandler) UnlockUser(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	userId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	if err := h.userUseCase.UnlockUser(ctx, claims, userId); err != nil {
+		if errors.Is(err, apperrors.AccessDenied) {
+			return utils.FiberError(c, fiber.StatusForbidden, err)
+		}
+
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return utils.FiberError(c, fiber.StatusNotFound, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "user successfully unlocked",
+	})
+}
+
+func (h UserHandler) Deactivate(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	userId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	if err := h.userUseCase.Deactivate(ctx, claims, userId); err != nil {
+		if errors.Is(err, apperrors.AccessDenied) {
+			return utils.FiberError(c, fiber.StatusForbidden, err)
+		}
+
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return utils.FiberError(c, fiber.StatusNotFound, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "user successfully deactivated",
+	})
+}
+
+func (h UserHandler) Reactivate(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	userId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	if err := h.userUseCase.Reactivate(ctx, claims, userId); err != nil {
+		if errors.Is(err, apperrors.AccessDenied) {
+			return utils.FiberError(c, fiber.StatusForbidden, err)
+		}
+
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return utils.FiberError(c, fiber.StatusNotFound, err)
+		}
+
+		return utils.FiberError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "user successfully reactivated",
+	})
+}
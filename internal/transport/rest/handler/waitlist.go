@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/jwt"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+)
+
+type WaitlistUseCase interface {
+	All(ctx context.Context, metadata core.TokenMetadata, classroomId int) ([]core.WaitlistEntryResponse, error)
+	Reorder(
+		ctx context.Context,
+		metadata core.TokenMetadata,
+		classroomId int,
+		req core.ReorderWaitlistRequest,
+	) ([]core.WaitlistEntryResponse, error)
+	Promote(ctx context.Context, metadata core.TokenMetadata, classroomId, studentId int) error
+}
+
+type WaitlistHandler struct {
+	waitlistUseCase WaitlistUseCase
+}
+
+func NewWaitlistHandler(waitlistUseCase WaitlistUseCase) *WaitlistHandler {
+	return &WaitlistHandler{waitlistUseCase: waitlistUseCase}
+}
+
+func (h WaitlistHandler) All(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	classroomId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	waitlist, err := h.waitlistUseCase.All(ctx, claims, classroomId)
+	if err != nil {
+		return waitlistError(c, err)
+	}
+
+	return c.JSON(waitlist)
+}
+
+// Reorder sets the order of the waitlist to the order of the students in the body.
+func (h WaitlistHandler) Reorder(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	classroomId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	req := core.ReorderWaitlistRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	if len(req.StudentsId) == 0 {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the required parameters cannot be empty"))
+	}
+
+	waitlist, err := h.waitlistUseCase.Reorder(ctx, claims, classroomId, req)
+	if err != nil {
+		return waitlistError(c, err)
+	}
+
+	return c.JSON(waitlist)
+}
+
+func (h WaitlistHandler) Promote(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := jwt.ExtractTokenMetadata(c)
+
+	classroomId, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the id must be number"))
+	}
+
+	studentId, err := c.ParamsInt("studentId")
+	if err != nil {
+		return utils.FiberError(c, fiber.StatusBadRequest, errors.New("the student id must be number"))
+	}
+
+	if err := h.waitlistUseCase.Promote(ctx, claims, classroomId, studentId); err != nil {
+		return waitlistError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "student successfully enrolled",
+	})
+}
+
+func waitlistError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, apperrors.AccessDenied) {
+		return utils.FiberError(c, fiber.StatusForbidden, err)
+	}
+
+	if errors.Is(err, apperrors.EntityNotFound) {
+		return utils.FiberError(c, fiber.StatusNotFound, err)
+	}
+
+	if errors.Is(err, apperrors.InvalidWaitlistOrder) {
+		return utils.FiberError(c, fiber.StatusBadRequest, err)
+	}
+
+	if errors.Is(err, apperrors.ClassroomArchived) || errors.Is(err, apperrors.NumberOfStudentsExceeded) {
+		return utils.FiberError(c, fiber.StatusConflict, err)
+	}
+
+	return utils.FiberError(c, fiber.StatusInternalServerError, err)
+}
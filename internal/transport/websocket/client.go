@@ -10,6 +10,7 @@ import (
 	"github.com/livekit/protocol/auth"
 	"github.com/migmatore/study-platform-api/internal/core"
 	"log"
+	"sync"
 	"time"
 )
 
@@ -41,6 +42,7 @@ type ClientArgs struct {
 
 type ClientDeps struct {
 	classroomUseCase ClassroomUseCase
+	staffUseCase     StaffUseCase
 }
 
 type Client struct {
@@ -50,13 +52,17 @@ type Client struct {
 	hub  *Hub
 	conn *websocket.Conn
 
-	send chan []byte
+	// mu guards send, which the hub closes while the read pump of the client may still write to it.
+	mu     sync.Mutex
+	closed bool
+	send   chan []byte
 
 	classroomUseCase ClassroomUseCase
+	staffUseCase     StaffUseCase
 }
 
 func NewClient(args ClientArgs, deps ClientDeps) *Client {
-	return &Client{hub: args.hub, conn: args.conn, userId: args.userId, userRole: args.userRole, send: make(chan []byte, 256), classroomUseCase: deps.classroomUseCase}
+	return &Client{hub: args.hub, conn: args.conn, userId: args.userId, userRole: args.userRole, send: make(chan []byte, 256), classroomUseCase: deps.classroomUseCase, staffUseCase: deps.staffUseCase}
 }
 
 // TODO: REFACTOR!!!!
@@ -104,20 +110,25 @@ func (c *Client) readPump() {
 			break
 		}
 
-		students, err := c.classroomUseCase.Students(
-			context.Background(),
-			core.TokenMetadata{
-				UserId: c.userId,
-				Role:   string(c.userRole),
-			},
-			req.ClassroomId,
-		)
+		metadata := core.TokenMetadata{
+			UserId: c.userId,
+			Role:   string(c.userRole),
+		}
+
+		students, err := c.classroomUseCase.Students(context.Background(), metadata, req.ClassroomId, false)
 		if err != nil {
 			log.Println("error while getting classroom's students")
 			c.conn.WriteMessage(websocket.CloseMessage, []byte{})
 			break
 		}
 
+		staff, err := c.staffUseCase.All(context.Background(), metadata, req.ClassroomId)
+		if err != nil {
+			log.Println("error while getting classroom's staff")
+			c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+			break
+		}
+
 		to := make([]Receiver, 0)
 
 		for _, student := range students {
@@ -127,6 +138,18 @@ func (c *Client) readPump() {
 			})
 		}
 
+		// The rest of the staff teaches the classroom too, so they see what the teacher does.
+		for _, member := range staff {
+			if member.UserId == c.userId {
+				continue
+			}
+
+			to = append(to, Receiver{
+				Id:   member.UserId,
+				role: core.TeacherRole,
+			})
+		}
+
 		if req.Type == NewRoom {
 			at := auth.NewAccessToken("APIZxVphSP9wcLk", "umceP0rAfax3K5fEUelwJV6LWLqQDyJLOflf9hA9524H")
 
@@ -150,15 +173,16 @@ func (c *Client) readPump() {
 				JoinToken: token,
 			})
 
-			c.send <- jsonMsg
+			c.queue(jsonMsg)
 
-			for client := range c.hub.clients {
+			// The clients belong to the hub, so the tokens are handed to it instead of sent directly.
+			for _, receiver := range to {
 				grant := &auth.VideoGrant{
 					RoomJoin: true,
 					Room:     roomName.String(),
 				}
 				at.AddGrant(grant).
-					SetIdentity(fmt.Sprintf("student-%d", client.userId)).
+					SetIdentity(fmt.Sprintf("%s-%d", receiver.role, receiver.Id)).
 					SetValidFor(time.Hour)
 
 				studentToken, _ := at.ToJWT()
@@ -171,9 +195,7 @@ func (c *Client) readPump() {
 					JoinToken: studentToken,
 				})
 
-				if client.userRole == core.StudentRole {
-					client.send <- jsonMsg
-				}
+				c.hub.broadcast <- NewMessage(jsonMsg, []Receiver{receiver})
 			}
 
 			continue
@@ -185,6 +207,35 @@ func (c *Client) readPump() {
 	}
 }
 
+// queue puts the message into the send buffer of the client. It reports false when the client is
+// closed or does not keep up with the messages.
+func (c *Client) queue(message []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return false
+	}
+
+	select {
+	case c.send <- message:
+		return true
+	default:
+		return false
+	}
+}
+
+// close closes the send buffer once, after which the write pump sends the close message.
+func (c *Client) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.closed {
+		c.closed = true
+		close(c.send)
+	}
+}
+
 func (c *Client) writePump() {
 	ticker := time.NewTicker(pingPeriod)
 	defer func() {
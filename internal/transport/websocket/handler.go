@@ -20,12 +20,23 @@ type AuthUseCase interface {
 }
 
 type ClassroomUseCase interface {
-	Students(ctx context.Context, metadata core.TokenMetadata, classroomId int) ([]core.StudentResponse, error)
+	Students(
+		ctx context.Context,
+		metadata core.TokenMetadata,
+		classroomId int,
+		includeInactive bool,
+	) ([]core.StudentResponse, error)
+}
+
+type StaffUseCase interface {
+	All(ctx context.Context, metadata core.TokenMetadata, classroomId int) ([]core.ClassroomStaffResponse, error)
 }
 
 type HandlerDeps struct {
+	Hub              *Hub
 	AuthUseCase      AuthUseCase
 	ClassroomUseCase ClassroomUseCase
+	StaffUseCase     StaffUseCase
 }
 
 type Handler struct {
@@ -35,14 +46,16 @@ type Handler struct {
 	hub              *Hub
 	authUseCase      AuthUseCase
 	classroomUseCase ClassroomUseCase
+	staffUseCase     StaffUseCase
 }
 
 func NewHandler(config *config.Config, deps HandlerDeps) *Handler {
 	return &Handler{
 		config:           config,
-		hub:              NewHub(),
+		hub:              deps.Hub,
 		authUseCase:      deps.AuthUseCase,
 		classroomUseCase: deps.ClassroomUseCase,
+		staffUseCase:     deps.StaffUseCase,
 	}
 }
 
@@ -122,7 +135,7 @@ func (h *Handler) Init() *fiber.App {
 				userId:   metadata.UserId,
 				userRole: core.RoleType(metadata.Role),
 			},
-			ClientDeps{classroomUseCase: h.classroomUseCase},
+			ClientDeps{classroomUseCase: h.classroomUseCase, staffUseCase: h.staffUseCase},
 		)
 
 		h.hub.register <- client
@@ -16,6 +16,9 @@ type Hub struct {
 
 	// Unregister requests from clients.
 	unregister chan *Client
+
+	// Users whose clients have to be disconnected.
+	closeUser chan int
 }
 
 func NewHub() *Hub {
@@ -23,6 +26,7 @@ func NewHub() *Hub {
 		broadcast:  make(chan *Message),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+		closeUser:  make(chan int),
 		clients:    make(map[*Client]bool),
 	}
 }
@@ -36,16 +40,22 @@ func (h *Hub) Run() {
 			if _, ok := h.clients[client]; ok {
 				fmt.Println("close client")
 				delete(h.clients, client)
-				close(client.send)
+				client.close()
+			}
+		case userId := <-h.closeUser:
+			for client := range h.clients {
+				if client.userId == userId {
+					delete(h.clients, client)
+					// The write pump sends the close message and closes the connection, which ends the read pump.
+					client.close()
+				}
 			}
 		case message := <-h.broadcast:
 			for client := range h.clients {
 				for _, to := range message.To {
 					if client.userId == to.Id {
-						select {
-						case client.send <- message.Data:
-						default:
-							close(client.send)
+						if !client.queue(message.Data) {
+							client.close()
 							delete(h.clients, client)
 						}
 					}
@@ -54,3 +64,8 @@ func (h *Hub) Run() {
 		}
 	}
 }
+
+// CloseUser disconnects every client of the user, for example once the user is deactivated.
+func (h *Hub) CloseUser(userId int) {
+	h.closeUser <- userId
+}
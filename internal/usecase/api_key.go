@@ -0,0 +1,271 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"time"
+)
+
+type APIKeyService interface {
+	Create(ctx context.Context, key core.APIKey) (core.APIKey, string, error)
+	Authenticate(ctx context.Context, token string) (core.APIKey, error)
+	ById(ctx context.Context, id int) (core.APIKey, error)
+	ActiveByUserId(ctx context.Context, userId int) ([]core.APIKey, error)
+	ActiveByInstitutionId(ctx context.Context, institutionId int) ([]core.APIKey, error)
+	Revoke(ctx context.Context, id int) error
+}
+
+type APIKeyUseCase struct {
+	authorizer    Authorizer
+	auditService  AuditService
+	apiKeyService APIKeyService
+	userService   InstitutionUserService
+}
+
+func NewAPIKeyUseCase(
+	authorizer Authorizer,
+	auditService AuditService,
+	apiKeyService APIKeyService,
+	userService InstitutionUserService,
+) *APIKeyUseCase {
+	return &APIKeyUseCase{
+		authorizer:    authorizer,
+		auditService:  auditService,
+		apiKeyService: apiKeyService,
+		userService:   userService,
+	}
+}
+
+// Authenticate turns the key into the same metadata a token carries, so handlers do not have to know
+// how the request was authenticated.
+func (uc APIKeyUseCase) Authenticate(ctx context.Context, token string) (core.TokenMetadata, error) {
+	key, err := uc.apiKeyService.Authenticate(ctx, token)
+	if err != nil {
+		return core.TokenMetadata{}, err
+	}
+
+	user, err := uc.userService.ById(ctx, key.UserId)
+	if err != nil {
+		if errors.Is(err, apperrors.EntityNotFound) {
+			return core.TokenMetadata{}, apperrors.InvalidToken
+		}
+
+		return core.TokenMetadata{}, err
+	}
+
+	if !user.Active() {
+		return core.TokenMetadata{}, apperrors.InvalidToken
+	}
+
+	// An institution key stops working once its creator is no longer an admin of the institution.
+	if key.InstitutionId != nil {
+		if user.Role != core.AdminRole || user.InstitutionId == nil || *user.InstitutionId != *key.InstitutionId {
+			return core.TokenMetadata{}, apperrors.InvalidToken
+		}
+	}
+
+	var expires int64
+	if key.ExpiresAt != nil {
+		expires = key.ExpiresAt.Unix()
+	}
+
+	return core.TokenMetadata{
+		UserId:   user.Id,
+		Role:     string(user.Role),
+		Expires:  expires,
+		APIKeyId: key.Id,
+		Scopes:   key.Scopes,
+	}, nil
+}
+
+func (uc APIKeyUseCase) PersonalKeys(ctx context.Context, metadata core.TokenMetadata) ([]core.APIKeyResponse, error) {
+	if delegated(metadata) {
+		return nil, apperrors.AccessDenied
+	}
+
+	keys, err := uc.apiKeyService.ActiveByUserId(ctx, metadata.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	return apiKeysResponse(keys), nil
+}
+
+func (uc APIKeyUseCase) CreatePersonalKey(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	req core.CreateAPIKeyRequest,
+) (core.CreatedAPIKeyResponse, error) {
+	if delegated(metadata) {
+		return core.CreatedAPIKeyResponse{}, apperrors.AccessDenied
+	}
+
+	return uc.create(ctx, metadata, nil, req)
+}
+
+func (uc APIKeyUseCase) RevokePersonalKey(ctx context.Context, metadata core.TokenMetadata, id int) error {
+	if delegated(metadata) {
+		return apperrors.AccessDenied
+	}
+
+	key, err := uc.apiKeyService.ById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if key.UserId != metadata.UserId || key.InstitutionId != nil {
+		return apperrors.EntityNotFound
+	}
+
+	return uc.revoke(ctx, metadata, key)
+}
+
+func (uc APIKeyUseCase) InstitutionKeys(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+) ([]core.APIKeyResponse, error) {
+	if delegated(metadata) {
+		return nil, apperrors.AccessDenied
+	}
+
+	institutionId, err := adminInstitutionId(ctx, uc.authorizer, uc.userService, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := uc.apiKeyService.ActiveByInstitutionId(ctx, institutionId)
+	if err != nil {
+		return nil, err
+	}
+
+	return apiKeysResponse(keys), nil
+}
+
+func (uc APIKeyUseCase) CreateInstitutionKey(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	req core.CreateAPIKeyRequest,
+) (core.CreatedAPIKeyResponse, error) {
+	if delegated(metadata) {
+		return core.CreatedAPIKeyResponse{}, apperrors.AccessDenied
+	}
+
+	institutionId, err := adminInstitutionId(ctx, uc.authorizer, uc.userService, metadata)
+	if err != nil {
+		return core.CreatedAPIKeyResponse{}, err
+	}
+
+	return uc.create(ctx, metadata, &institutionId, req)
+}
+
+func (uc APIKeyUseCase) RevokeInstitutionKey(ctx context.Context, metadata core.TokenMetadata, id int) error {
+	if delegated(metadata) {
+		return apperrors.AccessDenied
+	}
+
+	institutionId, err := adminInstitutionId(ctx, uc.authorizer, uc.userService, metadata)
+	if err != nil {
+		return err
+	}
+
+	key, err := uc.apiKeyService.ById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if key.InstitutionId == nil || *key.InstitutionId != institutionId {
+		return apperrors.EntityNotFound
+	}
+
+	return uc.revoke(ctx, metadata, key)
+}
+
+func (uc APIKeyUseCase) revoke(ctx context.Context, metadata core.TokenMetadata, key core.APIKey) error {
+	if err := uc.apiKeyService.Revoke(ctx, key.Id); err != nil {
+		return err
+	}
+
+	return uc.auditService.Record(ctx, metadata, auditDelete(core.AuditAPIKey, key.Id, apiKeyResponse(key)))
+}
+
+func (uc APIKeyUseCase) create(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	institutionId *int,
+	req core.CreateAPIKeyRequest,
+) (core.CreatedAPIKeyResponse, error) {
+	if len(req.Scopes) == 0 {
+		return core.CreatedAPIKeyResponse{}, apperrors.InvalidAPIKeyScope
+	}
+
+	for _, scope := range req.Scopes {
+		if !validAPIKeyScope(scope) {
+			return core.CreatedAPIKeyResponse{}, apperrors.InvalidAPIKeyScope
+		}
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays != nil {
+		t := time.Now().AddDate(0, 0, *req.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	key, token, err := uc.apiKeyService.Create(ctx, core.APIKey{
+		UserId:        metadata.UserId,
+		InstitutionId: institutionId,
+		Name:          req.Name,
+		Scopes:        req.Scopes,
+		ExpiresAt:     expiresAt,
+	})
+	if err != nil {
+		return core.CreatedAPIKeyResponse{}, err
+	}
+
+	// Only the description of the key is recorded, never the key itself.
+	if err := uc.auditService.Record(
+		ctx,
+		metadata,
+		auditCreate(core.AuditAPIKey, key.Id, apiKeyResponse(key)),
+	); err != nil {
+		return core.CreatedAPIKeyResponse{}, err
+	}
+
+	return core.CreatedAPIKeyResponse{
+		APIKeyResponse: apiKeyResponse(key),
+		Key:            token,
+	}, nil
+}
+
+func apiKeysResponse(keys []core.APIKey) []core.APIKeyResponse {
+	keysResp := make([]core.APIKeyResponse, 0, len(keys))
+
+	for _, key := range keys {
+		keysResp = append(keysResp, apiKeyResponse(key))
+	}
+
+	return keysResp
+}
+
+func apiKeyResponse(key core.APIKey) core.APIKeyResponse {
+	return core.APIKeyResponse{
+		Id:         key.Id,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+func validAPIKeyScope(scope string) bool {
+	for _, s := range core.APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
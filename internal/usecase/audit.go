@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/authz"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"strconv"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+type AuditService interface {
+	Record(ctx context.Context, actor core.TokenMetadata, event core.AuditEvent) error
+	Search(ctx context.Context, filter core.AuditFilter) ([]core.AuditRecord, error)
+}
+
+type AuditUseCase struct {
+	authorizer   Authorizer
+	auditService AuditService
+	userService  InstitutionUserService
+}
+
+func NewAuditUseCase(
+	authorizer Authorizer,
+	auditService AuditService,
+	userService InstitutionUserService,
+) *AuditUseCase {
+	return &AuditUseCase{authorizer: authorizer, auditService: auditService, userService: userService}
+}
+
+// All returns the latest changes made by the users of the institution of the admin.
+func (uc AuditUseCase) All(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	filter core.AuditFilter,
+) ([]core.AuditEventResponse, error) {
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.AuditView, authz.Any); err != nil {
+		return nil, err
+	}
+
+	admin, err := uc.userService.ById(ctx, metadata.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if admin.InstitutionId == nil {
+		return nil, apperrors.AccessDenied
+	}
+
+	filter.InstitutionId = *admin.InstitutionId
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLimit
+	}
+
+	if filter.Limit > maxAuditLimit {
+		filter.Limit = maxAuditLimit
+	}
+
+	records, err := uc.auditService.Search(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	eventsResp := make([]core.AuditEventResponse, 0, len(records))
+
+	for _, r := range records {
+		eventsResp = append(eventsResp, core.AuditEventResponse{
+			Id:             r.Id,
+			ActorId:        r.ActorId,
+			ActorRole:      r.ActorRole,
+			ImpersonatorId: r.ImpersonatorId,
+			Action:         string(r.Action),
+			EntityType:     r.EntityType,
+			EntityId:       r.EntityId,
+			Before:         r.Before,
+			After:          r.After,
+			RequestId:      r.RequestId,
+			IP:             r.IP,
+			CreatedAt:      r.CreatedAt,
+		})
+	}
+
+	return eventsResp, nil
+}
+
+// The audit log cannot be changed, so personal data recorded in it would outlive the erasure of the
+// user. The events about users therefore record ids, never names, emails or phones.
+
+// userAudit is the audited state of a student or a teacher account.
+type userAudit struct {
+	Id           int   `json:"id"`
+	ClassroomsId []int `json:"classrooms_id,omitempty"`
+}
+
+// enrollmentAudit is the audited state of a student in a classroom or on its waitlist.
+type enrollmentAudit struct {
+	ClassroomId int `json:"classroom_id"`
+	StudentId   int `json:"student_id"`
+	Position    int `json:"position,omitempty"`
+}
+
+// staffAudit is the audited state of a teacher on the staff of a classroom.
+type staffAudit struct {
+	UserId int            `json:"user_id"`
+	Role   core.StaffRole `json:"role"`
+}
+
+func auditCreate(entityType string, id int, after interface{}) core.AuditEvent {
+	return core.AuditEvent{Action: core.AuditCreate, EntityType: entityType, EntityId: strconv.Itoa(id), After: after}
+}
+
+func auditUpdate(entityType string, id int, before interface{}, after interface{}) core.AuditEvent {
+	return core.AuditEvent{
+		Action:     core.AuditUpdate,
+		EntityType: entityType,
+		EntityId:   strconv.Itoa(id),
+		Before:     before,
+		After:      after,
+	}
+}
+
+func auditDelete(entityType string, id int, before interface{}) core.AuditEvent {
+	return core.AuditEvent{Action: core.AuditDelete, EntityType: entityType, EntityId: strconv.Itoa(id), Before: before}
+}
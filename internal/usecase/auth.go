@@ -2,9 +2,14 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/migmatore/study-platform-api/config"
 	"github.com/migmatore/study-platform-api/internal/apperrors"
 	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/utils"
 	"golang.org/x/crypto/bcrypt"
+	"time"
 )
 
 type AuthUserService interface {
@@ -13,6 +18,8 @@ type AuthUserService interface {
 	ByEmail(ctx context.Context, email string) (core.User, error)
 	ById(ctx context.Context, id int) (core.User, error)
 	Create(ctx context.Context, user core.User) (core.User, error)
+	UpdateProfile(ctx context.Context, userId int, profile core.UpdateUserProfile) (core.UserProfile, error)
+	VerifyEmail(ctx context.Context, userId int, email string) error
 }
 
 type InstitutionService interface {
@@ -21,77 +28,266 @@ type InstitutionService interface {
 }
 
 type TokenService interface {
-	Token(userId int, role string) (core.TokenWithClaims, error)
+	Token(userId int, role string, sessionId string) (core.TokenWithClaims, error)
 	WSToken(userId int, role string) (core.TokenWithClaims, error)
-	RefreshToken(userId int) (core.RefreshTokenWithClaims, error)
+	ImpersonationToken(userId int, role string, adminId int) (core.TokenWithClaims, int64, error)
+	RefreshToken(userId int, familyId string) (core.RefreshTokenWithClaims, error)
 	ExtractTokenMetadata(tokenString string) (core.TokenMetadata, error)
 	ExtractWSTokenMetadata(tokenString string) (core.TokenMetadata, error)
 	ExtractRefreshTokenMetadata(tokenString string) (core.RefreshTokenMetadata, error)
 }
 
+type RefreshTokenService interface {
+	Create(ctx context.Context, token core.RefreshToken) error
+	ByIdForUpdate(ctx context.Context, id string) (core.RefreshToken, error)
+	Rotate(ctx context.Context, id string) error
+}
+
+type AuthSessionService interface {
+	Create(ctx context.Context, session core.Session) error
+	Touch(ctx context.Context, session core.Session) error
+	Revoke(ctx context.Context, id string) error
+	RevokeByUserId(ctx context.Context, userId int) error
+}
+
+type PasswordResetService interface {
+	Create(ctx context.Context, userId int) (string, error)
+	Consume(ctx context.Context, token string) (int, error)
+}
+
+type EmailVerificationService interface {
+	Create(ctx context.Context, userId int, email string) (string, error)
+	Consume(ctx context.Context, token string) (core.EmailVerification, error)
+}
+
+// dummyPasswordHash is compared against when the email is unknown.
+const dummyPasswordHash = "$2a$10$s1MCHo5batSW/Gyo96CyEOBm8ppzN/TDmT9ZTAb0nMJCeGthsMYj."
+
+type SigninThrottleService interface {
+	Check(ctx context.Context, email string, ip string) error
+	Fail(ctx context.Context, email string, ip string) error
+	Reset(ctx context.Context, email string) error
+}
+
+type AuthMFAService interface {
+	IsEnabled(ctx context.Context, userId int) (bool, error)
+	Verify(ctx context.Context, userId int, code string) (bool, error)
+	CreateChallenge(ctx context.Context, userId int) (string, error)
+	ChallengeForUpdate(ctx context.Context, token string) (core.MFAChallenge, error)
+	FailChallenge(ctx context.Context, challenge core.MFAChallenge) error
+	CompleteChallenge(ctx context.Context, id int) error
+}
+
+type AuthOIDCService interface {
+	AuthURL(ctx context.Context, institutionId int) (string, error)
+	Authenticate(ctx context.Context, institutionId int, code string, state string) (core.OIDCIdentity, error)
+	UserIdByIdentity(ctx context.Context, issuer string, subject string) (int, error)
+	LinkIdentity(ctx context.Context, userId int, issuer string, subject string) error
+}
+
+type AuthInvitationService interface {
+	ByTokenForUpdate(ctx context.Context, token string) (core.Invitation, error)
+	ByCodeForUpdate(ctx context.Context, code string) (core.Invitation, error)
+	Use(ctx context.Context, id int) error
+}
+
+type AuthClassroomService interface {
+	ById(ctx context.Context, id int) (core.Classroom, error)
+	Enroll(ctx context.Context, classroomId int, studentsId []int) (core.Enrollment, error)
+}
+
+type MailService interface {
+	SendPasswordReset(ctx context.Context, to string, fullName string, token string) error
+	SendEmailVerification(ctx context.Context, to string, fullName string, token string) error
+	SendInvitation(ctx context.Context, to string, classroomTitle string, token string) error
+	SendWaitlistPromotion(ctx context.Context, to []string, classroomTitle string, studentsName []string) error
+}
+
 type AuthUseCase struct {
-	transactionService TransactionService
-	userService        AuthUserService
-	institutionService InstitutionService
-	tokenService       TokenService
+	config                   *config.Config
+	auditService             AuditService
+	transactionService       TransactionService
+	userService              AuthUserService
+	institutionService       InstitutionService
+	tokenService             TokenService
+	refreshTokenService      RefreshTokenService
+	sessionService           AuthSessionService
+	passwordResetService     PasswordResetService
+	emailVerificationService EmailVerificationService
+	mfaService               AuthMFAService
+	signinThrottleService    SigninThrottleService
+	oidcService              AuthOIDCService
+	invitationService        AuthInvitationService
+	classroomService         AuthClassroomService
+	mailService              MailService
 }
 
 func NewAuthUseCase(
+	config *config.Config,
+	auditService AuditService,
 	transactionService TransactionService,
 	userService AuthUserService,
 	institutionService InstitutionService,
 	tokenService TokenService,
+	refreshTokenService RefreshTokenService,
+	sessionService AuthSessionService,
+	passwordResetService PasswordResetService,
+	emailVerificationService EmailVerificationService,
+	mfaService AuthMFAService,
+	signinThrottleService SigninThrottleService,
+	oidcService AuthOIDCService,
+	invitationService AuthInvitationService,
+	classroomService AuthClassroomService,
+	mailService MailService,
 ) *AuthUseCase {
 	return &AuthUseCase{
-		transactionService: transactionService,
-		userService:        userService,
-		institutionService: institutionService,
-		tokenService:       tokenService,
+		config:                   config,
+		auditService:             auditService,
+		transactionService:       transactionService,
+		userService:              userService,
+		institutionService:       institutionService,
+		tokenService:             tokenService,
+		refreshTokenService:      refreshTokenService,
+		sessionService:           sessionService,
+		passwordResetService:     passwordResetService,
+		emailVerificationService: emailVerificationService,
+		mfaService:               mfaService,
+		signinThrottleService:    signinThrottleService,
+		oidcService:              oidcService,
+		invitationService:        invitationService,
+		classroomService:         classroomService,
+		mailService:              mailService,
 	}
 }
 
-func (uc AuthUseCase) Signin(ctx context.Context, req core.UserSigninRequest) (core.UserAuthResponse, error) {
+// Signin checks the password. Users with MFA enabled get a challenge instead of tokens, which has
+// to be answered with a code through VerifyMFA.
+func (uc AuthUseCase) Signin(ctx context.Context, req core.UserSigninRequest) (core.SigninResult, error) {
+	if err := uc.signinThrottleService.Check(ctx, req.Email, req.Client.IP); err != nil {
+		return core.SigninResult{}, err
+	}
+
 	userExist, err := uc.userService.IsExist(ctx, req.Email)
 	if err != nil {
-		return core.UserAuthResponse{}, err
+		return core.SigninResult{}, err
 	}
 
-	if !userExist {
-		return core.UserAuthResponse{}, apperrors.EntityNotFound
+	// The password is checked even for unknown emails, so the response time does not reveal
+	// whether the account exists.
+	user := core.User{PasswordHash: dummyPasswordHash}
+
+	if userExist {
+		user, err = uc.userService.ByEmail(ctx, req.Email)
+		if err != nil {
+			return core.SigninResult{}, err
+		}
 	}
 
-	user, err := uc.userService.ByEmail(ctx, req.Email)
-	if err != nil {
-		return core.UserAuthResponse{}, err
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil || !userExist {
+		if err := uc.signinThrottleService.Fail(ctx, req.Email, req.Client.IP); err != nil {
+			return core.SigninResult{}, err
+		}
+
+		return core.SigninResult{}, apperrors.InvalidCredentials
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		return core.UserAuthResponse{}, apperrors.IncorrectPassword
+	// Checked only after the password, so the response does not tell strangers the account is suspended.
+	if !user.Active() {
+		return core.SigninResult{}, apperrors.UserDeactivated
 	}
 
-	tokenClaims, err := uc.tokenService.Token(user.Id, string(user.Role))
-	if err != nil {
-		return core.UserAuthResponse{}, err
+	if uc.config.Server.RequireVerifiedEmail && !user.EmailVerified {
+		return core.SigninResult{}, apperrors.EmailNotVerified
 	}
 
-	wsTokenClaims, err := uc.tokenService.WSToken(user.Id, string(user.Role))
+	mfaEnabled, err := uc.mfaService.IsEnabled(ctx, user.Id)
 	if err != nil {
-		return core.UserAuthResponse{}, err
+		return core.SigninResult{}, err
 	}
 
-	refreshTokenClaims, err := uc.tokenService.RefreshToken(user.Id)
+	if mfaEnabled {
+		token, err := uc.mfaService.CreateChallenge(ctx, user.Id)
+		if err != nil {
+			return core.SigninResult{}, err
+		}
+
+		return core.SigninResult{
+			Challenge: &core.MFAChallengeResponse{
+				MFARequired: true,
+				MFAToken:    token,
+			},
+		}, nil
+	}
+
+	// With MFA enabled the password alone is not a successful sign in, the attempts are forgotten only
+	// after VerifyMFA accepts the code.
+	if err := uc.signinThrottleService.Reset(ctx, req.Email); err != nil {
+		return core.SigninResult{}, err
+	}
+
+	resp, err := uc.startSession(ctx, user, req.Client)
 	if err != nil {
+		return core.SigninResult{}, err
+	}
+
+	return core.SigninResult{Auth: &resp}, nil
+}
+
+// VerifyMFA completes the sign in started by Signin. A wrong code counts against the challenge,
+// which stops working after a few attempts, and against the account like a wrong password, so new
+// challenges do not give new attempts at guessing the code.
+func (uc AuthUseCase) VerifyMFA(ctx context.Context, req core.MFAVerifyRequest) (core.UserAuthResponse, error) {
+	var (
+		user     core.User
+		verified bool
+	)
+
+	if err := uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		challenge, err := uc.mfaService.ChallengeForUpdate(txCtx, req.MFAToken)
 		if err != nil {
-			return core.UserAuthResponse{}, err
+			return err
+		}
+
+		user, err = uc.userService.ById(txCtx, challenge.UserId)
+		if err != nil {
+			return err
+		}
+
+		if err := uc.signinThrottleService.Check(txCtx, user.Email, req.Client.IP); err != nil {
+			return err
+		}
+
+		verified, err = uc.mfaService.Verify(txCtx, challenge.UserId, req.Code)
+		if err != nil {
+			return err
+		}
+
+		if !verified {
+			if err := uc.signinThrottleService.Fail(txCtx, user.Email, req.Client.IP); err != nil {
+				return err
+			}
+
+			return uc.mfaService.FailChallenge(txCtx, challenge)
 		}
+
+		return uc.mfaService.CompleteChallenge(txCtx, challenge.Id)
+	}); err != nil {
+		return core.UserAuthResponse{}, err
 	}
 
-	return core.UserAuthResponse{
-		Token:        tokenClaims.Token,
-		WSToken:      wsTokenClaims.Token,
-		RefreshToken: refreshTokenClaims.Token,
-		Role:         tokenClaims.Role,
-	}, nil
+	if !verified {
+		return core.UserAuthResponse{}, apperrors.InvalidMFACode
+	}
+
+	if err := uc.signinThrottleService.Reset(ctx, user.Email); err != nil {
+		return core.UserAuthResponse{}, err
+	}
+
+	if !user.Active() {
+		return core.UserAuthResponse{}, apperrors.UserDeactivated
+	}
+
+	return uc.startSession(ctx, user, req.Client)
 }
 
 func (uc AuthUseCase) Signup(ctx context.Context, req core.UserSignupRequest) (core.UserAuthResponse, error) {
@@ -113,10 +309,22 @@ func (uc AuthUseCase) Signup(ctx context.Context, req core.UserSignupRequest) (c
 		return core.UserAuthResponse{}, apperrors.EntityAlreadyExist
 	}
 
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return core.UserAuthResponse{}, err
+	}
+
 	var user core.User
 
-	if req.Role == core.AdminRole {
-		err = uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+	if err := uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		newUser := core.User{
+			FullName:     req.FullName,
+			Email:        req.Email,
+			PasswordHash: string(hash),
+			Role:         core.TeacherRole,
+		}
+
+		if req.Role == core.AdminRole {
 			inst, err := uc.institutionService.Create(txCtx, core.Institution{
 				Name: req.InstitutionName,
 			})
@@ -124,110 +332,433 @@ func (uc AuthUseCase) Signup(ctx context.Context, req core.UserSignupRequest) (c
 				return err
 			}
 
-			hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-			if err != nil {
-				return err
-			}
+			newUser.Role = core.AdminRole
+			newUser.InstitutionId = &inst.Id
+		}
 
-			user, err = uc.userService.Create(txCtx, core.User{
-				FullName:      req.FullName,
-				Email:         req.Email,
-				PasswordHash:  string(hash),
-				Role:          core.AdminRole,
-				InstitutionId: &inst.Id,
-			})
-			if err != nil {
-				return err
-			}
+		user, err = uc.userService.Create(txCtx, newUser)
+		if err != nil {
+			return err
+		}
 
-			return nil
-		})
+		return uc.auditService.Record(txCtx, selfActor(user), auditCreate(core.AuditUser, user.Id, newAccountAudit(user)))
+	}); err != nil {
+		return core.UserAuthResponse{}, err
+	}
+
+	if err := uc.sendEmailVerification(ctx, user, user.Email); err != nil {
+		return core.UserAuthResponse{}, err
+	}
+
+	if uc.config.Server.RequireVerifiedEmail {
+		return core.UserAuthResponse{}, apperrors.EmailNotVerified
+	}
+
+	return uc.startSession(ctx, user, req.Client)
+}
+
+// SignupInvite creates a student account from a personal invitation or a join code and adds it to the
+// classroom of the invitation. The student joins the institution of the teacher who owns the classroom.
+func (uc AuthUseCase) SignupInvite(ctx context.Context, req core.InviteSignupRequest) (core.UserAuthResponse, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return core.UserAuthResponse{}, err
+	}
+
+	var user core.User
+
+	if err := uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var invitation core.Invitation
+
+		if req.Token != "" {
+			invitation, err = uc.invitationService.ByTokenForUpdate(txCtx, req.Token)
+		} else {
+			invitation, err = uc.invitationService.ByCodeForUpdate(txCtx, req.Code)
+		}
 		if err != nil {
-			return core.UserAuthResponse{}, err
+			return err
 		}
-	} else {
-		hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+
+		// A personal invitation is bound to the email it was sent to, which also proves the email.
+		email := req.Email
+		if invitation.Email != nil {
+			email = *invitation.Email
+		}
+
+		exist, err := uc.userService.IsExist(txCtx, email)
 		if err != nil {
-			return core.UserAuthResponse{}, err
+			return err
 		}
 
-		user, err = uc.userService.Create(ctx, core.User{
-			FullName:     req.FullName,
-			Email:        req.Email,
-			PasswordHash: string(hash),
-			Role:         core.TeacherRole,
+		if exist {
+			return apperrors.EntityAlreadyExist
+		}
+
+		classroom, err := uc.classroomService.ById(txCtx, invitation.ClassroomId)
+		if err != nil {
+			return err
+		}
+
+		teacher, err := uc.userService.ById(txCtx, classroom.TeacherId)
+		if err != nil {
+			return err
+		}
+
+		user, err = uc.userService.Create(txCtx, core.User{
+			FullName:      req.FullName,
+			Phone:         req.Phone,
+			Email:         email,
+			PasswordHash:  string(hash),
+			Role:          core.StudentRole,
+			InstitutionId: teacher.InstitutionId,
+			EmailVerified: invitation.Email != nil,
 		})
 		if err != nil {
-			return core.UserAuthResponse{}, err
+			return err
 		}
-	}
 
-	tokenClaims, err := uc.tokenService.Token(user.Id, string(req.Role))
-	if err != nil {
+		enrollment, err := uc.classroomService.Enroll(txCtx, classroom.Id, []int{user.Id})
+		if err != nil {
+			return err
+		}
+
+		if err := uc.invitationService.Use(txCtx, invitation.Id); err != nil {
+			return err
+		}
+
+		actor := selfActor(user)
+
+		if err := uc.auditService.Record(
+			txCtx,
+			actor,
+			auditCreate(core.AuditUser, user.Id, newAccountAudit(user)),
+		); err != nil {
+			return err
+		}
+
+		entityType := core.AuditEnrollment
+		if containsId(enrollment.Waitlisted, user.Id) {
+			entityType = core.AuditWaitlist
+		}
+
+		return uc.auditService.Record(txCtx, actor, core.AuditEvent{
+			Action:     core.AuditCreate,
+			EntityType: entityType,
+			EntityId:   enrollmentAuditId(classroom.Id, user.Id),
+			After:      enrollmentAudit{ClassroomId: classroom.Id, StudentId: user.Id},
+		})
+	}); err != nil {
 		return core.UserAuthResponse{}, err
 	}
 
-	wsTokenClaims, err := uc.tokenService.WSToken(user.Id, string(user.Role))
+	if !user.EmailVerified {
+		if err := uc.sendEmailVerification(ctx, user, user.Email); err != nil {
+			return core.UserAuthResponse{}, err
+		}
+
+		if uc.config.Server.RequireVerifiedEmail {
+			return core.UserAuthResponse{}, apperrors.EmailNotVerified
+		}
+	}
+
+	return uc.startSession(ctx, user, req.Client)
+}
+
+// Refresh rotates the refresh token: the presented token is marked as used and a new one of the same
+// family is issued. Presenting an already rotated token means it has leaked, so the whole family is revoked.
+func (uc AuthUseCase) Refresh(ctx context.Context, req core.UserTokenRefreshRequest) (core.UserAuthResponse, error) {
+	metadata, err := uc.tokenService.ExtractRefreshTokenMetadata(req.RefreshToken)
 	if err != nil {
 		return core.UserAuthResponse{}, err
 	}
 
-	refreshTokenClaims, err := uc.tokenService.RefreshToken(user.Id)
-	if err != nil {
+	var (
+		resp   core.UserAuthResponse
+		reused bool
+	)
+
+	if err := uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		token, err := uc.refreshTokenService.ByIdForUpdate(txCtx, metadata.Id)
 		if err != nil {
-			return core.UserAuthResponse{}, err
+			if errors.Is(err, apperrors.EntityNotFound) {
+				return apperrors.InvalidToken
+			}
+
+			return err
+		}
+
+		if token.RevokedAt != nil {
+			return apperrors.RevokedToken
+		}
+
+		if token.RotatedAt != nil {
+			reused = true
+
+			return uc.sessionService.Revoke(txCtx, token.FamilyId)
+		}
+
+		exist, err := uc.userService.IsExistById(txCtx, token.UserId)
+		if err != nil {
+			return err
+		}
+
+		if !exist {
+			return apperrors.EntityNotFound
+		}
+
+		user, err := uc.userService.ById(txCtx, token.UserId)
+		if err != nil {
+			return err
+		}
+
+		if !user.Active() {
+			return apperrors.UserDeactivated
+		}
+
+		if err := uc.refreshTokenService.Rotate(txCtx, token.Id); err != nil {
+			return err
+		}
+
+		var expiresAt time.Time
+
+		resp, expiresAt, err = uc.issueTokens(txCtx, user, token.FamilyId)
+		if err != nil {
+			return err
 		}
+
+		return uc.sessionService.Touch(txCtx, core.Session{
+			Id:        token.FamilyId,
+			UserAgent: optionalString(req.Client.UserAgent),
+			IP:        optionalString(req.Client.IP),
+			ExpiresAt: expiresAt,
+		})
+	}); err != nil {
+		return core.UserAuthResponse{}, err
 	}
 
-	return core.UserAuthResponse{
-		Token:        tokenClaims.Token,
-		WSToken:      wsTokenClaims.Token,
-		RefreshToken: refreshTokenClaims.Token,
-		Role:         tokenClaims.Role,
-	}, nil
+	if reused {
+		return core.UserAuthResponse{}, apperrors.RevokedToken
+	}
+
+	return resp, nil
 }
 
-func (uc AuthUseCase) Refresh(ctx context.Context, req core.UserTokenRefreshRequest) (core.UserAuthResponse, error) {
+func (uc AuthUseCase) Logout(ctx context.Context, req core.UserLogoutRequest) error {
 	metadata, err := uc.tokenService.ExtractRefreshTokenMetadata(req.RefreshToken)
 	if err != nil {
-		return core.UserAuthResponse{}, err
+		return err
 	}
 
-	exist, err := uc.userService.IsExistById(ctx, metadata.UserId)
+	return uc.sessionService.Revoke(ctx, metadata.FamilyId)
+}
+
+// ForgotPassword sends a password reset link. The result does not reveal whether the account exists.
+func (uc AuthUseCase) ForgotPassword(ctx context.Context, req core.ForgotPasswordRequest) error {
+	exist, err := uc.userService.IsExist(ctx, req.Email)
 	if err != nil {
-		return core.UserAuthResponse{}, err
+		return err
 	}
 
 	if !exist {
-		return core.UserAuthResponse{}, apperrors.EntityNotFound
+		return nil
 	}
 
-	user, err := uc.userService.ById(ctx, metadata.UserId)
+	user, err := uc.userService.ByEmail(ctx, req.Email)
 	if err != nil {
-		return core.UserAuthResponse{}, err
+		return err
 	}
 
-	tokenClaims, err := uc.tokenService.Token(user.Id, string(user.Role))
+	token, err := uc.passwordResetService.Create(ctx, user.Id)
 	if err != nil {
-		return core.UserAuthResponse{}, err
+		return err
 	}
 
-	wsTokenClaims, err := uc.tokenService.WSToken(user.Id, string(user.Role))
+	return uc.mailService.SendPasswordReset(ctx, user.Email, user.FullName, token)
+}
+
+// ResetPassword sets a new password and signs the user out of every session.
+func (uc AuthUseCase) ResetPassword(ctx context.Context, req core.ResetPasswordRequest) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
-		return core.UserAuthResponse{}, err
+		return err
 	}
 
-	refreshTokenClaims, err := uc.tokenService.RefreshToken(user.Id)
+	hashStr := string(hash)
+
+	return uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		userId, err := uc.passwordResetService.Consume(txCtx, req.Token)
+		if err != nil {
+			return err
+		}
+
+		if _, err := uc.userService.UpdateProfile(txCtx, userId, core.UpdateUserProfile{
+			Password: &hashStr,
+		}); err != nil {
+			return err
+		}
+
+		user, err := uc.userService.ById(txCtx, userId)
+		if err != nil {
+			return err
+		}
+
+		if err := uc.sessionService.RevokeByUserId(txCtx, userId); err != nil {
+			return err
+		}
+
+		return uc.auditService.Record(txCtx, selfActor(user), auditUpdate(core.AuditUser, userId, nil, map[string]bool{
+			"password_changed": true,
+		}))
+	})
+}
+
+func (uc AuthUseCase) VerifyEmail(ctx context.Context, req core.VerifyEmailRequest) error {
+	return uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		verification, err := uc.emailVerificationService.Consume(txCtx, req.Token)
+		if err != nil {
+			return err
+		}
+
+		// The address could have been taken by another account while the link was waiting in the mailbox.
+		exist, err := uc.userService.IsExist(txCtx, verification.Email)
+		if err != nil {
+			return err
+		}
+
+		if exist {
+			owner, err := uc.userService.ByEmail(txCtx, verification.Email)
+			if err != nil {
+				return err
+			}
+
+			if owner.Id != verification.UserId {
+				return apperrors.EntityAlreadyExist
+			}
+		}
+
+		return uc.userService.VerifyEmail(txCtx, verification.UserId, verification.Email)
+	})
+}
+
+// ResendVerification sends a new confirmation link. The result does not reveal whether the account exists.
+func (uc AuthUseCase) ResendVerification(ctx context.Context, req core.ResendVerificationRequest) error {
+	exist, err := uc.userService.IsExist(ctx, req.Email)
+	if err != nil {
+		return err
+	}
+
+	if !exist {
+		return nil
+	}
+
+	user, err := uc.userService.ByEmail(ctx, req.Email)
+	if err != nil {
+		return err
+	}
+
+	if user.EmailVerified {
+		return nil
+	}
+
+	return uc.sendEmailVerification(ctx, user, user.Email)
+}
+
+// OIDCLogin returns the address of the sign in page of the institution identity provider.
+func (uc AuthUseCase) OIDCLogin(ctx context.Context, institutionId int) (string, error) {
+	return uc.oidcService.AuthURL(ctx, institutionId)
+}
+
+// OIDCCallback signs in the user the identity provider has authenticated. The account is found by the
+// provider identity, then by email within the institution, and is created when there is none.
+func (uc AuthUseCase) OIDCCallback(ctx context.Context, req core.OIDCCallbackRequest) (core.UserAuthResponse, error) {
+	identity, err := uc.oidcService.Authenticate(ctx, req.InstitutionId, req.Code, req.State)
 	if err != nil {
 		return core.UserAuthResponse{}, err
 	}
 
-	return core.UserAuthResponse{
-		Token:        tokenClaims.Token,
-		WSToken:      wsTokenClaims.Token,
-		RefreshToken: refreshTokenClaims.Token,
-		Role:         tokenClaims.Role,
-	}, nil
+	var user core.User
+
+	if err := uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		userId, err := uc.oidcService.UserIdByIdentity(txCtx, identity.Issuer, identity.Subject)
+		if err == nil {
+			user, err = uc.userService.ById(txCtx, userId)
+
+			return err
+		}
+
+		if !errors.Is(err, apperrors.EntityNotFound) {
+			return err
+		}
+
+		exist, err := uc.userService.IsExist(txCtx, identity.Email)
+		if err != nil {
+			return err
+		}
+
+		if exist {
+			// An existing account is linked only when the provider vouches for the email, otherwise
+			// anyone able to set an arbitrary email at the provider could take it over.
+			if !identity.EmailVerified {
+				return apperrors.EntityAlreadyExist
+			}
+
+			user, err = uc.userService.ByEmail(txCtx, identity.Email)
+			if err != nil {
+				return err
+			}
+
+			if user.InstitutionId == nil || *user.InstitutionId != req.InstitutionId {
+				return apperrors.EntityAlreadyExist
+			}
+
+			if err := uc.auditService.Record(txCtx, selfActor(user), auditUpdate(core.AuditUser, user.Id, nil, map[string]string{
+				"oidc_issuer": identity.Issuer,
+			})); err != nil {
+				return err
+			}
+		} else {
+			// The account can be used only through the provider until a password is set with a reset.
+			password, err := utils.RandomToken(32)
+			if err != nil {
+				return err
+			}
+
+			hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+			if err != nil {
+				return err
+			}
+
+			user, err = uc.userService.Create(txCtx, core.User{
+				FullName:      identity.FullName,
+				Email:         identity.Email,
+				PasswordHash:  string(hash),
+				Role:          identity.Role,
+				InstitutionId: &req.InstitutionId,
+				EmailVerified: true,
+			})
+			if err != nil {
+				return err
+			}
+
+			if err := uc.auditService.Record(
+				txCtx,
+				selfActor(user),
+				auditCreate(core.AuditUser, user.Id, newAccountAudit(user)),
+			); err != nil {
+				return err
+			}
+		}
+
+		return uc.oidcService.LinkIdentity(txCtx, user.Id, identity.Issuer, identity.Subject)
+	}); err != nil {
+		return core.UserAuthResponse{}, err
+	}
+
+	if !user.Active() {
+		return core.UserAuthResponse{}, apperrors.UserDeactivated
+	}
+
+	return uc.startSession(ctx, user, req.Client)
 }
 
 func (uc AuthUseCase) Auth(ctx context.Context, req core.UserAuthRequest) (core.TokenMetadata, error) {
@@ -245,5 +776,124 @@ func (uc AuthUseCase) Auth(ctx context.Context, req core.UserAuthRequest) (core.
 		return core.TokenMetadata{}, apperrors.EntityNotFound
 	}
 
+	user, err := uc.userService.ById(ctx, metadata.UserId)
+	if err != nil {
+		return core.TokenMetadata{}, err
+	}
+
+	if !user.Active() {
+		return core.TokenMetadata{}, apperrors.UserDeactivated
+	}
+
 	return metadata, nil
 }
+
+func (uc AuthUseCase) sendEmailVerification(ctx context.Context, user core.User, email string) error {
+	token, err := uc.emailVerificationService.Create(ctx, user.Id, email)
+	if err != nil {
+		return err
+	}
+
+	return uc.mailService.SendEmailVerification(ctx, email, user.FullName, token)
+}
+
+// startSession opens a new session for the user and issues the first set of tokens for it.
+func (uc AuthUseCase) startSession(
+	ctx context.Context,
+	user core.User,
+	client core.ClientInfo,
+) (core.UserAuthResponse, error) {
+	var resp core.UserAuthResponse
+
+	if err := uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		sessionId := uuid.NewString()
+
+		var (
+			expiresAt time.Time
+			err       error
+		)
+
+		resp, expiresAt, err = uc.issueTokens(txCtx, user, sessionId)
+		if err != nil {
+			return err
+		}
+
+		return uc.sessionService.Create(txCtx, core.Session{
+			Id:        sessionId,
+			UserId:    user.Id,
+			UserAgent: optionalString(client.UserAgent),
+			IP:        optionalString(client.IP),
+			ExpiresAt: expiresAt,
+		})
+	}); err != nil {
+		return core.UserAuthResponse{}, err
+	}
+
+	return resp, nil
+}
+
+// issueTokens issues access, websocket and refresh tokens for the session. It returns the expiration
+// time of the refresh token, which is also the new expiration time of the session.
+func (uc AuthUseCase) issueTokens(
+	ctx context.Context,
+	user core.User,
+	sessionId string,
+) (core.UserAuthResponse, time.Time, error) {
+	tokenClaims, err := uc.tokenService.Token(user.Id, string(user.Role), sessionId)
+	if err != nil {
+		return core.UserAuthResponse{}, time.Time{}, err
+	}
+
+	wsTokenClaims, err := uc.tokenService.WSToken(user.Id, string(user.Role))
+	if err != nil {
+		return core.UserAuthResponse{}, time.Time{}, err
+	}
+
+	refreshTokenClaims, err := uc.tokenService.RefreshToken(user.Id, sessionId)
+	if err != nil {
+		return core.UserAuthResponse{}, time.Time{}, err
+	}
+
+	expiresAt := time.Unix(refreshTokenClaims.Expires, 0)
+
+	if err := uc.refreshTokenService.Create(ctx, core.RefreshToken{
+		Id:        refreshTokenClaims.Id,
+		FamilyId:  refreshTokenClaims.FamilyId,
+		UserId:    user.Id,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return core.UserAuthResponse{}, time.Time{}, err
+	}
+
+	return core.UserAuthResponse{
+		Token:        tokenClaims.Token,
+		WSToken:      wsTokenClaims.Token,
+		RefreshToken: refreshTokenClaims.Token,
+		Role:         tokenClaims.Role,
+	}, expiresAt, nil
+}
+
+// selfActor is the audit actor for users acting on their own account before they have a token, like
+// while signing up.
+func selfActor(user core.User) core.TokenMetadata {
+	return core.TokenMetadata{UserId: user.Id, Role: string(user.Role)}
+}
+
+// accountAudit is the audited state of a new account. The personal data of the user is left out.
+type accountAudit struct {
+	Role          core.RoleType `json:"role"`
+	InstitutionId *int          `json:"institution_id"`
+	EmailVerified bool          `json:"email_verified"`
+}
+
+func newAccountAudit(user core.User) accountAudit {
+	return accountAudit{Role: user.Role, InstitutionId: user.InstitutionId, EmailVerified: user.EmailVerified}
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
@@ -0,0 +1,393 @@
+package usecase_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/migmatore/study-platform-api/config"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/internal/service"
+	"github.com/migmatore/study-platform-api/internal/usecase"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const (
+	testInstitutionId = 1
+	testClientId      = "study-platform"
+	testState         = "test-state"
+	testNonce         = "test-nonce"
+	testCode          = "test-code"
+	testSubject       = "idp-user-1"
+	testEmail         = "student@school.test"
+	studentRoleId     = 3
+)
+
+// mockIdP is a local identity provider serving discovery, the token endpoint and the key set.
+type mockIdP struct {
+	server *httptest.Server
+	key    ed25519.PrivateKey
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp := &mockIdP{key: key}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "OKP",
+				"crv": "Ed25519",
+				"kid": "test-key",
+				"use": "sig",
+				"x":   base64.RawURLEncoding.EncodeToString(pub),
+			}},
+		})
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("code") != testCode ||
+			r.PostForm.Get("grant_type") != "authorization_code" || r.PostForm.Get("code_verifier") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.MapClaims{
+			"iss":            idp.server.URL,
+			"aud":            testClientId,
+			"sub":            testSubject,
+			"email":          testEmail,
+			"email_verified": true,
+			"name":           "Test Student",
+			"nonce":          testNonce,
+			"roles":          []string{"student"},
+			"iat":            time.Now().Unix(),
+			"exp":            time.Now().Add(time.Minute).Unix(),
+		})
+		token.Header["kid"] = "test-key"
+
+		idToken, err := token.SignedString(idp.key)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, map[string]string{"access_token": "access", "token_type": "Bearer", "id_token": idToken})
+	})
+
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+type fakeOIDCRepo struct {
+	service.OIDCRepo
+	issuer string
+	linked map[string]int
+}
+
+func (r *fakeOIDCRepo) ProviderByInstitutionId(_ context.Context, institutionId int) (core.OIDCProviderModel, error) {
+	if institutionId != testInstitutionId {
+		return core.OIDCProviderModel{}, apperrors.EntityNotFound
+	}
+
+	return core.OIDCProviderModel{
+		InstitutionId: testInstitutionId,
+		Issuer:        r.issuer,
+		ClientId:      testClientId,
+		ClientSecret:  "secret",
+		Scopes:        "openid email profile",
+		RoleClaim:     "roles",
+		RoleMapping:   map[string]string{"student": string(core.StudentRole)},
+		Enabled:       true,
+	}, nil
+}
+
+func (r *fakeOIDCRepo) UseState(_ context.Context, hash string) (core.OIDCStateModel, error) {
+	if hash != utils.HashToken(testState) {
+		return core.OIDCStateModel{}, apperrors.EntityNotFound
+	}
+
+	return core.OIDCStateModel{
+		InstitutionId: testInstitutionId,
+		Nonce:         testNonce,
+		CodeVerifier:  "test-verifier",
+		ExpiresAt:     time.Now().Add(time.Minute),
+	}, nil
+}
+
+func (r *fakeOIDCRepo) UserIdByIdentity(_ context.Context, issuer string, subject string) (int, error) {
+	if id, ok := r.linked[issuer+" "+subject]; ok {
+		return id, nil
+	}
+
+	return 0, apperrors.EntityNotFound
+}
+
+func (r *fakeOIDCRepo) LinkIdentity(_ context.Context, userId int, issuer string, subject string) error {
+	r.linked[issuer+" "+subject] = userId
+
+	return nil
+}
+
+type fakeUserRepo struct {
+	service.UserRepo
+	users   []core.UserModel
+	created int
+}
+
+func (r *fakeUserRepo) IsExist(_ context.Context, email string) (bool, error) {
+	_, err := r.ByEmail(context.Background(), email)
+
+	return err == nil, nil
+}
+
+func (r *fakeUserRepo) ByEmail(_ context.Context, email string) (core.UserModel, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+
+	return core.UserModel{}, apperrors.EntityNotFound
+}
+
+func (r *fakeUserRepo) ById(_ context.Context, id int) (core.UserModel, error) {
+	for _, user := range r.users {
+		if user.Id == id {
+			return user, nil
+		}
+	}
+
+	return core.UserModel{}, apperrors.EntityNotFound
+}
+
+func (r *fakeUserRepo) Create(_ context.Context, user core.UserModel) (core.UserModel, error) {
+	r.created++
+	user.Id = 100 + r.created
+	r.users = append(r.users, user)
+
+	return user, nil
+}
+
+type fakeRoleRepo struct{}
+
+func (fakeRoleRepo) ByName(_ context.Context, name string) (core.RoleModel, error) {
+	return core.RoleModel{Id: studentRoleId, Name: name}, nil
+}
+
+func (fakeRoleRepo) ById(_ context.Context, id int) (core.RoleModel, error) {
+	return core.RoleModel{Id: id, Name: string(core.StudentRole)}, nil
+}
+
+type fakeTransaction struct{}
+
+func (fakeTransaction) WithinTransaction(ctx context.Context, txFunc func(txCtx context.Context) error) error {
+	return txFunc(ctx)
+}
+
+type fakeAuditService struct {
+	usecase.AuditService
+	actors []int
+	events []core.AuditEvent
+}
+
+func (s *fakeAuditService) Record(_ context.Context, actor core.TokenMetadata, event core.AuditEvent) error {
+	s.actors = append(s.actors, actor.UserId)
+	s.events = append(s.events, event)
+
+	return nil
+}
+
+type fakeTokenService struct {
+	usecase.TokenService
+}
+
+func (fakeTokenService) Token(userId int, role string, _ string) (core.TokenWithClaims, error) {
+	return core.TokenWithClaims{Token: "access-token", UserId: userId, Role: role}, nil
+}
+
+func (fakeTokenService) WSToken(userId int, role string) (core.TokenWithClaims, error) {
+	return core.TokenWithClaims{Token: "ws-token", UserId: userId, Role: role}, nil
+}
+
+func (fakeTokenService) RefreshToken(userId int, familyId string) (core.RefreshTokenWithClaims, error) {
+	return core.RefreshTokenWithClaims{
+		Token:    "refresh-token",
+		Id:       "refresh-id",
+		FamilyId: familyId,
+		UserId:   userId,
+		Expires:  time.Now().Add(time.Hour).Unix(),
+	}, nil
+}
+
+type fakeRefreshTokenService struct {
+	usecase.RefreshTokenService
+}
+
+func (fakeRefreshTokenService) Create(context.Context, core.RefreshToken) error {
+	return nil
+}
+
+type fakeSessionService struct {
+	usecase.AuthSessionService
+}
+
+func (fakeSessionService) Create(context.Context, core.Session) error {
+	return nil
+}
+
+func newOIDCAuthUseCase(
+	t *testing.T,
+	users []core.UserModel,
+) (*usecase.AuthUseCase, *fakeOIDCRepo, *fakeUserRepo, *fakeAuditService) {
+	t.Helper()
+
+	idp := newMockIdP(t)
+
+	cfg := &config.Config{}
+	cfg.OIDC.RedirectBaseURL = "http://localhost"
+
+	oidcRepo := &fakeOIDCRepo{issuer: idp.server.URL, linked: make(map[string]int)}
+	userRepo := &fakeUserRepo{users: users}
+	audit := &fakeAuditService{}
+
+	uc := usecase.NewAuthUseCase(
+		cfg,
+		audit,
+		fakeTransaction{},
+		service.NewUserService(userRepo, fakeRoleRepo{}),
+		nil,
+		fakeTokenService{},
+		fakeRefreshTokenService{},
+		fakeSessionService{},
+		nil,
+		nil,
+		nil,
+		nil,
+		service.NewOIDCService(cfg, oidcRepo),
+		nil,
+		nil,
+		nil,
+	)
+
+	return uc, oidcRepo, userRepo, audit
+}
+
+func callbackRequest() core.OIDCCallbackRequest {
+	return core.OIDCCallbackRequest{InstitutionId: testInstitutionId, Code: testCode, State: testState}
+}
+
+func TestOIDCCallbackLinksExistingAccount(t *testing.T) {
+	institutionId := testInstitutionId
+
+	uc, oidcRepo, userRepo, audit := newOIDCAuthUseCase(t, []core.UserModel{{
+		Id:            7,
+		FullName:      "Test Student",
+		Email:         testEmail,
+		RoleId:        studentRoleId,
+		InstitutionId: &institutionId,
+		EmailVerified: true,
+	}})
+
+	resp, err := uc.OIDCCallback(context.Background(), callbackRequest())
+	if err != nil {
+		t.Fatalf("OIDCCallback() error = %v", err)
+	}
+
+	if resp.Token == "" || resp.RefreshToken == "" {
+		t.Fatalf("OIDCCallback() = %+v, want issued tokens", resp)
+	}
+
+	if userRepo.created != 0 {
+		t.Fatalf("created %d users, want the existing account to be used", userRepo.created)
+	}
+
+	if id := oidcRepo.linked[oidcRepo.issuer+" "+testSubject]; id != 7 {
+		t.Fatalf("identity linked to user %d, want 7", id)
+	}
+
+	if len(audit.events) != 1 || audit.actors[0] != 7 || audit.events[0].EntityId != "7" {
+		t.Fatalf("audit events %+v by %v, want the link recorded by user 7", audit.events, audit.actors)
+	}
+}
+
+func TestOIDCCallbackCreatesAccount(t *testing.T) {
+	uc, oidcRepo, userRepo, audit := newOIDCAuthUseCase(t, nil)
+
+	if _, err := uc.OIDCCallback(context.Background(), callbackRequest()); err != nil {
+		t.Fatalf("OIDCCallback() error = %v", err)
+	}
+
+	if userRepo.created != 1 {
+		t.Fatalf("created %d users, want 1", userRepo.created)
+	}
+
+	user := userRepo.users[0]
+
+	if user.InstitutionId == nil || *user.InstitutionId != testInstitutionId || !user.EmailVerified {
+		t.Fatalf("created %+v, want a verified user of institution %d", user, testInstitutionId)
+	}
+
+	if id := oidcRepo.linked[oidcRepo.issuer+" "+testSubject]; id != user.Id {
+		t.Fatalf("identity linked to user %d, want %d", id, user.Id)
+	}
+
+	if len(audit.events) != 1 || audit.actors[0] != user.Id || audit.events[0].Action != core.AuditCreate {
+		t.Fatalf("audit events %+v by %v, want the account creation recorded by user %d", audit.events, audit.actors, user.Id)
+	}
+}
+
+func TestOIDCCallbackRejectsAccountOfAnotherInstitution(t *testing.T) {
+	otherInstitutionId := testInstitutionId + 1
+
+	uc, oidcRepo, _, audit := newOIDCAuthUseCase(t, []core.UserModel{{
+		Id:            7,
+		Email:         testEmail,
+		RoleId:        studentRoleId,
+		InstitutionId: &otherInstitutionId,
+	}})
+
+	_, err := uc.OIDCCallback(context.Background(), callbackRequest())
+	if !errors.Is(err, apperrors.EntityAlreadyExist) {
+		t.Fatalf("OIDCCallback() error = %v, want %v", err, apperrors.EntityAlreadyExist)
+	}
+
+	if len(oidcRepo.linked) != 0 {
+		t.Fatalf("linked %v, want no identity linked", oidcRepo.linked)
+	}
+
+	if len(audit.events) != 0 {
+		t.Fatalf("audit events %+v, want none", audit.events)
+	}
+}
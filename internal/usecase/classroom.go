@@ -2,22 +2,50 @@ package usecase
 
 import (
 	"context"
+	"github.com/google/uuid"
 	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/authz"
 	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/logger"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The limits of the classrooms table columns.
+const (
+	classroomTitleMaxLength       = 100
+	classroomDescriptionMaxLength = 1000
 )
 
 type ClassroomService interface {
 	Create(ctx context.Context, classroom core.Classroom) (core.Classroom, error)
-	Delete(ctx context.Context, id int) error
+	Update(ctx context.Context, classroom core.UpdateClassroom) (core.Classroom, error)
+	Archive(ctx context.Context, id int) (core.Classroom, error)
+	Restore(ctx context.Context, id int) (core.Classroom, error)
+	Purge(ctx context.Context, classroom core.Classroom) error
 	ById(ctx context.Context, id int) (core.Classroom, error)
+	ByIdForUpdate(ctx context.Context, id int) (core.Classroom, error)
 	IsBelongs(ctx context.Context, classroomId int, teacherId int) (bool, error)
 	IsIn(ctx context.Context, classroomId, studentId int) (bool, error)
 	Students(ctx context.Context, classroomId int) ([]core.Student, error)
-	AddStudent(ctx context.Context, studentId int, classroomsId []int) error
-}
-
-type AdminService interface {
-	AllClassrooms(ctx context.Context, studentId int) ([]core.Classroom, error)
+	Enroll(ctx context.Context, classroomId int, studentsId []int) (core.Enrollment, error)
+	RemoveStudents(ctx context.Context, classroomId int, studentsId []int) error
+	Waitlist(ctx context.Context, classroomId int) ([]core.WaitlistEntry, error)
+	ReorderWaitlist(ctx context.Context, classroomId int, studentsId []int) error
+	RemoveFromWaitlist(ctx context.Context, classroomId int, studentsId []int) error
+	Promote(ctx context.Context, classroomId, studentId int) (core.WaitlistEntry, error)
+	PromoteWaitlisted(ctx context.Context, classroomId int) ([]core.WaitlistEntry, error)
+	Readmit(ctx context.Context, classroomId, studentId int) (bool, error)
+	OccupiedSeats(ctx context.Context, classroomId int) (int, error)
+	StudentClassrooms(ctx context.Context, studentId int) ([]core.Classroom, error)
+	InstitutionClassrooms(ctx context.Context, institutionId int) ([]core.Classroom, error)
+	AssignedClassrooms(ctx context.Context, userId int) ([]core.Classroom, error)
+	Staff(ctx context.Context, classroomId int) ([]core.ClassroomStaff, error)
+	AddStaff(ctx context.Context, classroomId, userId int, role core.StaffRole) error
+	RemoveStaff(ctx context.Context, classroomId, userId int) error
+	SetOwner(ctx context.Context, classroomId, teacherId int) error
 }
 
 type ClassroomTeacherService interface {
@@ -29,69 +57,115 @@ type ClassroomStudentService interface {
 	AllClassrooms(ctx context.Context, studentId int) ([]core.Classroom, error)
 }
 
+type ClassroomLessonService interface {
+	All(ctx context.Context, classroomId int) ([]core.Lesson, error)
+	Create(ctx context.Context, lesson core.Lesson) (core.Lesson, error)
+}
+
+type ClassroomUserService interface {
+	ById(ctx context.Context, id int) (core.User, error)
+}
+
 type ClassroomUseCase struct {
-	classroomService ClassroomService
-	teacherService   TeacherService
-	studentService   ClassroomStudentService
+	logger             logger.Logger
+	authorizer         Authorizer
+	auditService       AuditService
+	transactionService TransactionService
+	classroomService   ClassroomService
+	lessonService      ClassroomLessonService
+	teacherService     TeacherService
+	studentService     ClassroomStudentService
+	userService        ClassroomUserService
+	mailService        WaitlistMailService
 }
 
 func NewClassroomUseCase(
+	logger logger.Logger,
+	authorizer Authorizer,
+	auditService AuditService,
+	transactionService TransactionService,
 	classroomService ClassroomService,
+	lessonService ClassroomLessonService,
 	teacherService TeacherService,
 	studentService ClassroomStudentService,
+	userService ClassroomUserService,
+	mailService WaitlistMailService,
 ) *ClassroomUseCase {
 	return &ClassroomUseCase{
-		classroomService: classroomService,
-		teacherService:   teacherService,
-		studentService:   studentService,
+		logger:             logger,
+		authorizer:         authorizer,
+		auditService:       auditService,
+		transactionService: transactionService,
+		classroomService:   classroomService,
+		lessonService:      lessonService,
+		teacherService:     teacherService,
+		studentService:     studentService,
+		userService:        userService,
+		mailService:        mailService,
 	}
 }
 
-func (uc ClassroomUseCase) All(ctx context.Context, metadata core.TokenMetadata) ([]core.ClassroomResponse, error) {
-	switch core.RoleType(metadata.Role) {
-	case core.AdminRole:
-	case core.TeacherRole:
-		classrooms, err := uc.teacherService.AllClassrooms(ctx, metadata.UserId)
+// All returns the classrooms of the institution to admins, the own classrooms to teachers and the
+// classrooms students study in, together with the classrooms the user has a role in. Archived
+// classrooms are returned instead of the active ones when asked for.
+func (uc ClassroomUseCase) All(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	archived bool,
+) ([]core.ClassroomResponse, error) {
+	scope, err := uc.authorizer.Scope(ctx, metadata, authz.ClassroomList)
+	if err != nil {
+		return nil, err
+	}
+
+	var classrooms []core.Classroom
+
+	switch scope {
+	case authz.InstitutionScope:
+		admin, err := uc.userService.ById(ctx, metadata.UserId)
 		if err != nil {
 			return nil, err
 		}
 
-		classroomsResp := make([]core.ClassroomResponse, 0, len(classrooms))
+		if admin.InstitutionId == nil {
+			return nil, apperrors.AccessDenied
+		}
 
-		for _, classroom := range classrooms {
-			classroomsResp = append(classroomsResp, core.ClassroomResponse{
-				Id:          classroom.Id,
-				Title:       classroom.Title,
-				Description: classroom.Description,
-				TeacherId:   classroom.TeacherId,
-				MaxStudents: classroom.MaxStudents,
-			})
+		classrooms, err = uc.classroomService.InstitutionClassrooms(ctx, *admin.InstitutionId)
+		if err != nil {
+			return nil, err
 		}
+	case authz.TaughtScope:
+		classrooms, err = uc.teacherService.AllClassrooms(ctx, metadata.UserId)
+	case authz.EnrolledScope:
+		classrooms, err = uc.studentService.AllClassrooms(ctx, metadata.UserId)
+	}
 
-		return classroomsResp, nil
+	if err != nil {
+		return nil, err
+	}
 
-	case core.StudentRole:
-		classrooms, err := uc.studentService.AllClassrooms(ctx, metadata.UserId)
+	// The institution scope has every classroom already, the others add the classrooms the user has a role in.
+	if scope != authz.InstitutionScope {
+		assigned, err := uc.classroomService.AssignedClassrooms(ctx, metadata.UserId)
 		if err != nil {
 			return nil, err
 		}
 
-		classroomsResp := make([]core.ClassroomResponse, 0, len(classrooms))
+		classrooms = appendMissingClassrooms(classrooms, assigned)
+	}
 
-		for _, classroom := range classrooms {
-			classroomsResp = append(classroomsResp, core.ClassroomResponse{
-				Id:          classroom.Id,
-				Title:       classroom.Title,
-				Description: classroom.Description,
-				TeacherId:   classroom.TeacherId,
-				MaxStudents: classroom.MaxStudents,
-			})
+	classroomsResp := make([]core.ClassroomResponse, 0, len(classrooms))
+
+	for _, classroom := range classrooms {
+		if (classroom.ArchivedAt != nil) != archived {
+			continue
 		}
 
-		return classroomsResp, nil
+		classroomsResp = append(classroomsResp, classroomResponse(classroom))
 	}
 
-	return nil, nil
+	return classroomsResp, nil
 }
 
 func (uc ClassroomUseCase) Create(
@@ -99,8 +173,12 @@ func (uc ClassroomUseCase) Create(
 	metadata core.TokenMetadata,
 	req core.CreateClassroomRequest,
 ) (core.ClassroomResponse, error) {
-	if core.RoleType(metadata.Role) != core.TeacherRole {
-		return core.ClassroomResponse{}, apperrors.AccessDenied
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.ClassroomCreate, authz.Any); err != nil {
+		return core.ClassroomResponse{}, err
+	}
+
+	if err := validateClassroom(&req.Title, req.Description, &req.MaxStudents); err != nil {
+		return core.ClassroomResponse{}, err
 	}
 
 	newClassroom, err := uc.classroomService.Create(ctx, core.Classroom{
@@ -113,55 +191,339 @@ func (uc ClassroomUseCase) Create(
 		return core.ClassroomResponse{}, err
 	}
 
-	return core.ClassroomResponse{
-		Id:          newClassroom.Id,
-		Title:       newClassroom.Title,
-		Description: newClassroom.Description,
-		TeacherId:   newClassroom.TeacherId,
-		MaxStudents: newClassroom.MaxStudents,
-	}, nil
-}
-
-func (uc ClassroomUseCase) Delete(ctx context.Context, metadata core.TokenMetadata, id int) error {
-	switch core.RoleType(metadata.Role) {
-	case core.AdminRole:
-		return apperrors.AccessDenied
-	case core.TeacherRole:
-		belongs, err := uc.classroomService.IsBelongs(ctx, id, metadata.UserId)
+	classroomResp := classroomResponse(newClassroom)
+
+	if err := uc.auditService.Record(
+		ctx,
+		metadata,
+		auditCreate(core.AuditClassroom, newClassroom.Id, classroomResp),
+	); err != nil {
+		return core.ClassroomResponse{}, err
+	}
+
+	return classroomResp, nil
+}
+
+// Clone copies the classroom with all its lessons into a new classroom of the teacher, for example for
+// a new term. The content blocks of the lessons get new ids. The students are carried over and the
+// lessons keep their active flag only when asked for, otherwise the lessons of the copy are inactive.
+func (uc ClassroomUseCase) Clone(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	id int,
+	req core.CloneClassroomRequest,
+) (core.ClassroomResponse, error) {
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.ClassroomCreate, authz.Any); err != nil {
+		return core.ClassroomResponse{}, err
+	}
+
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.ClassroomView, authz.Classroom(id)); err != nil {
+		return core.ClassroomResponse{}, err
+	}
+
+	if req.WithStudents {
+		if err := uc.authorizer.Authorize(
+			ctx,
+			metadata,
+			authz.ClassroomStudentsView,
+			authz.Classroom(id),
+		); err != nil {
+			return core.ClassroomResponse{}, err
+		}
+	}
+
+	if err := validateClassroom(req.Title, nil, nil); err != nil {
+		return core.ClassroomResponse{}, err
+	}
+
+	classroom, err := uc.classroomService.ById(ctx, id)
+	if err != nil {
+		return core.ClassroomResponse{}, err
+	}
+
+	title := classroom.Title
+	if req.Title != nil {
+		title = *req.Title
+	}
+
+	var clone core.Classroom
+
+	if err := uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		clone, err = uc.classroomService.Create(txCtx, core.Classroom{
+			Title:       title,
+			Description: classroom.Description,
+			TeacherId:   metadata.UserId,
+			MaxStudents: classroom.MaxStudents,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := uc.auditService.Record(
+			txCtx,
+			metadata,
+			auditCreate(core.AuditClassroom, clone.Id, classroomResponse(clone)),
+		); err != nil {
+			return err
+		}
+
+		lessons, err := uc.lessonService.All(txCtx, classroom.Id)
 		if err != nil {
 			return err
 		}
 
-		if !belongs {
-			return apperrors.AccessDenied
+		for _, lesson := range lessons {
+			newLesson, err := uc.lessonService.Create(txCtx, core.Lesson{
+				Title:       lesson.Title,
+				ClassroomId: clone.Id,
+				Content:     cloneLessonContent(lesson.Content),
+				Active:      req.KeepActive && lesson.Active,
+			})
+			if err != nil {
+				return err
+			}
+
+			if err := uc.auditService.Record(
+				txCtx,
+				metadata,
+				auditCreate(core.AuditLesson, newLesson.Id, lessonResponse(newLesson)),
+			); err != nil {
+				return err
+			}
 		}
 
-		if err := uc.classroomService.Delete(ctx, id); err != nil {
+		if !req.WithStudents {
+			return nil
+		}
+
+		students, err := uc.classroomService.Students(txCtx, classroom.Id)
+		if err != nil {
 			return err
 		}
-	case core.StudentRole:
-		return apperrors.AccessDenied
+
+		studentsId := make([]int, 0, len(students))
+
+		for _, student := range students {
+			studentsId = append(studentsId, student.Id)
+		}
+
+		enrollment, err := uc.classroomService.Enroll(txCtx, clone.Id, studentsId)
+		if err != nil {
+			return err
+		}
+
+		return recordEnrollment(txCtx, uc.auditService, metadata, clone.Id, enrollment)
+	}); err != nil {
+		return core.ClassroomResponse{}, err
 	}
 
-	return nil
+	return classroomResponse(clone), nil
 }
 
-func (uc ClassroomUseCase) Students(
+// Update changes the fields of the classroom which are set in the request. The number of students
+// cannot be lowered below the number of students already enrolled. Raising it gives the new seats to
+// the students from the waitlist.
+func (uc ClassroomUseCase) Update(
 	ctx context.Context,
 	metadata core.TokenMetadata,
-	classroomId int,
-) ([]core.StudentResponse, error) {
-	if core.RoleType(metadata.Role) == core.StudentRole {
-		return nil, apperrors.AccessDenied
+	id int,
+	req core.UpdateClassroomRequest,
+) (core.ClassroomResponse, error) {
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.ClassroomUpdate, authz.Classroom(id)); err != nil {
+		return core.ClassroomResponse{}, err
 	}
 
-	belongs, err := uc.classroomService.IsBelongs(ctx, classroomId, metadata.UserId)
+	if err := validateClassroom(req.Title, req.Description, req.MaxStudents); err != nil {
+		return core.ClassroomResponse{}, err
+	}
+
+	classroom, err := uc.classroomService.ById(ctx, id)
 	if err != nil {
-		return nil, err
+		return core.ClassroomResponse{}, err
+	}
+
+	if req.Title == nil && req.Description == nil && req.MaxStudents == nil {
+		return classroomResponse(classroom), nil
+	}
+
+	var (
+		updated  core.Classroom
+		promoted []core.WaitlistEntry
+	)
+
+	if err := uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		// The lock keeps students from being enrolled between the count and the update.
+		classroom, err = uc.classroomService.ByIdForUpdate(txCtx, id)
+		if err != nil {
+			return err
+		}
+
+		if req.MaxStudents != nil && *req.MaxStudents < classroom.MaxStudents {
+			occupied, err := uc.classroomService.OccupiedSeats(txCtx, id)
+			if err != nil {
+				return err
+			}
+
+			if *req.MaxStudents < occupied {
+				return apperrors.MaxStudentsBelowEnrolled
+			}
+		}
+
+		updated, err = uc.classroomService.Update(txCtx, core.UpdateClassroom{
+			Id:          id,
+			Title:       req.Title,
+			Description: req.Description,
+			MaxStudents: req.MaxStudents,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := uc.auditService.Record(
+			txCtx,
+			metadata,
+			auditUpdate(core.AuditClassroom, id, classroomResponse(classroom), classroomResponse(updated)),
+		); err != nil {
+			return err
+		}
+
+		if updated.MaxStudents <= classroom.MaxStudents {
+			return nil
+		}
+
+		promoted, err = promoteWaitlisted(txCtx, uc.auditService, uc.classroomService, metadata, id)
+
+		return err
+	}); err != nil {
+		return core.ClassroomResponse{}, err
 	}
 
-	if !belongs {
-		return nil, apperrors.AccessDenied
+	uc.notifyPromotions(ctx, id, promoted)
+
+	return classroomResponse(updated), nil
+}
+
+// Archive makes the classroom read-only and hides it from the default lists. Its lessons and students
+// are kept, so it can be restored.
+func (uc ClassroomUseCase) Archive(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	id int,
+) (core.ClassroomResponse, error) {
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.ClassroomArchive, authz.Classroom(id)); err != nil {
+		return core.ClassroomResponse{}, err
+	}
+
+	classroom, err := uc.classroomService.ById(ctx, id)
+	if err != nil {
+		return core.ClassroomResponse{}, err
+	}
+
+	if classroom.ArchivedAt != nil {
+		return classroomResponse(classroom), nil
+	}
+
+	archived, err := uc.classroomService.Archive(ctx, id)
+	if err != nil {
+		return core.ClassroomResponse{}, err
+	}
+
+	if err := uc.auditService.Record(
+		ctx,
+		metadata,
+		auditUpdate(core.AuditClassroom, id, classroomResponse(classroom), classroomResponse(archived)),
+	); err != nil {
+		return core.ClassroomResponse{}, err
+	}
+
+	return classroomResponse(archived), nil
+}
+
+// Restore makes the archived classroom writable again and gives its free seats to the waitlist.
+func (uc ClassroomUseCase) Restore(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	id int,
+) (core.ClassroomResponse, error) {
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.ClassroomArchive, authz.Classroom(id)); err != nil {
+		return core.ClassroomResponse{}, err
+	}
+
+	classroom, err := uc.classroomService.ById(ctx, id)
+	if err != nil {
+		return core.ClassroomResponse{}, err
+	}
+
+	if classroom.ArchivedAt == nil {
+		return classroomResponse(classroom), nil
+	}
+
+	var (
+		restored core.Classroom
+		promoted []core.WaitlistEntry
+	)
+
+	if err := uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		restored, err = uc.classroomService.Restore(txCtx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := uc.auditService.Record(
+			txCtx,
+			metadata,
+			auditUpdate(core.AuditClassroom, id, classroomResponse(classroom), classroomResponse(restored)),
+		); err != nil {
+			return err
+		}
+
+		// The waitlist was frozen while the classroom was archived, seats freed in the meantime go to it now.
+		promoted, err = promoteWaitlisted(txCtx, uc.auditService, uc.classroomService, metadata, id)
+
+		return err
+	}); err != nil {
+		return core.ClassroomResponse{}, err
+	}
+
+	uc.notifyPromotions(ctx, id, promoted)
+
+	return classroomResponse(restored), nil
+}
+
+// Purge deletes the archived classroom for good, together with its lessons and enrollments, once the
+// retention period is over.
+func (uc ClassroomUseCase) Purge(ctx context.Context, metadata core.TokenMetadata, id int) error {
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.ClassroomPurge, authz.Classroom(id)); err != nil {
+		return err
+	}
+
+	classroom, err := uc.classroomService.ById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.classroomService.Purge(ctx, classroom); err != nil {
+		return err
+	}
+
+	return uc.auditService.Record(ctx, metadata, auditDelete(core.AuditClassroom, id, classroomResponse(classroom)))
+}
+
+// Students lists the students enrolled in the classroom. Deactivated and deleted students keep their
+// seats but are left out unless includeInactive is set.
+func (uc ClassroomUseCase) Students(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	classroomId int,
+	includeInactive bool,
+) ([]core.StudentResponse, error) {
+	if err := uc.authorizer.Authorize(
+		ctx,
+		metadata,
+		authz.ClassroomStudentsView,
+		authz.Classroom(classroomId),
+	); err != nil {
+		return nil, err
 	}
 
 	students, err := uc.classroomService.Students(ctx, classroomId)
@@ -172,13 +534,359 @@ func (uc ClassroomUseCase) Students(
 	studentsResp := make([]core.StudentResponse, 0, len(students))
 
 	for _, student := range students {
+		if !includeInactive && !student.Active() {
+			continue
+		}
+
 		studentsResp = append(studentsResp, core.StudentResponse{
-			Id:       student.Id,
-			FullName: student.FullName,
-			Phone:    student.Phone,
-			Email:    student.Email,
+			Id:            student.Id,
+			FullName:      student.FullName,
+			Phone:         student.Phone,
+			Email:         student.Email,
+			DeactivatedAt: student.DeactivatedAt,
+			DeletedAt:     student.DeletedAt,
 		})
 	}
 
 	return studentsResp, nil
 }
+
+// Enroll adds existing students of the institution to the classroom, for example to move them from
+// another classroom. Students who do not fit into the classroom are put on its waitlist. Students who
+// are already in the classroom or on the waitlist are skipped.
+func (uc ClassroomUseCase) Enroll(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	classroomId int,
+	studentsId []int,
+) (core.EnrollStudentsResponse, error) {
+	if err := uc.authorizer.Authorize(
+		ctx,
+		metadata,
+		authz.ClassroomStudentsManage,
+		authz.Classroom(classroomId),
+	); err != nil {
+		return core.EnrollStudentsResponse{}, err
+	}
+
+	classroom, err := uc.classroomService.ById(ctx, classroomId)
+	if err != nil {
+		return core.EnrollStudentsResponse{}, err
+	}
+
+	students, err := uc.institutionStudents(ctx, classroom, studentsId)
+	if err != nil {
+		return core.EnrollStudentsResponse{}, err
+	}
+
+	var enrollment core.Enrollment
+
+	if err := uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		enrollment, err = uc.classroomService.Enroll(txCtx, classroomId, studentsId)
+		if err != nil {
+			return err
+		}
+
+		for _, student := range students {
+			entityType := core.AuditEnrollment
+
+			if containsId(enrollment.Waitlisted, student.Id) {
+				entityType = core.AuditWaitlist
+			} else if !containsId(enrollment.Enrolled, student.Id) {
+				continue
+			}
+
+			if err := uc.auditService.Record(txCtx, metadata, core.AuditEvent{
+				Action:     core.AuditCreate,
+				EntityType: entityType,
+				EntityId:   enrollmentAuditId(classroomId, student.Id),
+				After:      enrollmentAudit{ClassroomId: classroomId, StudentId: student.Id},
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return core.EnrollStudentsResponse{}, err
+	}
+
+	return core.EnrollStudentsResponse{Enrolled: enrollment.Enrolled, Waitlisted: enrollment.Waitlisted}, nil
+}
+
+// Unenroll removes the students from the classroom or from its waitlist. Their accounts and other
+// classrooms are kept. Students who are in neither are skipped. The seats that opened up are given to
+// the students from the waitlist, and the staff is told about it.
+func (uc ClassroomUseCase) Unenroll(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	classroomId int,
+	studentsId []int,
+) error {
+	if err := uc.authorizer.Authorize(
+		ctx,
+		metadata,
+		authz.ClassroomStudentsManage,
+		authz.Classroom(classroomId),
+	); err != nil {
+		return err
+	}
+
+	var promoted []core.WaitlistEntry
+
+	if err := uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := uc.leaveWaitlist(txCtx, metadata, classroomId, studentsId); err != nil {
+			return err
+		}
+
+		enrolled, err := uc.classroomService.Students(txCtx, classroomId)
+		if err != nil {
+			return err
+		}
+
+		removed := make([]core.Student, 0, len(studentsId))
+
+		for _, student := range enrolled {
+			if containsId(studentsId, student.Id) {
+				removed = append(removed, student)
+			}
+		}
+
+		if len(removed) == 0 {
+			return nil
+		}
+
+		ids := make([]int, 0, len(removed))
+
+		for _, student := range removed {
+			ids = append(ids, student.Id)
+		}
+
+		if err := uc.classroomService.RemoveStudents(txCtx, classroomId, ids); err != nil {
+			return err
+		}
+
+		for _, student := range removed {
+			if err := uc.auditService.Record(txCtx, metadata, core.AuditEvent{
+				Action:     core.AuditDelete,
+				EntityType: core.AuditEnrollment,
+				EntityId:   enrollmentAuditId(classroomId, student.Id),
+				Before:     enrollmentAudit{ClassroomId: classroomId, StudentId: student.Id},
+			}); err != nil {
+				return err
+			}
+		}
+
+		promoted, err = promoteWaitlisted(txCtx, uc.auditService, uc.classroomService, metadata, classroomId)
+
+		return err
+	}); err != nil {
+		return err
+	}
+
+	uc.notifyPromotions(ctx, classroomId, promoted)
+
+	return nil
+}
+
+// leaveWaitlist takes the students off the waitlist of the classroom.
+func (uc ClassroomUseCase) leaveWaitlist(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	classroomId int,
+	studentsId []int,
+) error {
+	waitlist, err := uc.classroomService.Waitlist(ctx, classroomId)
+	if err != nil {
+		return err
+	}
+
+	removed := make([]int, 0, len(studentsId))
+
+	for _, entry := range waitlist {
+		if !containsId(studentsId, entry.StudentId) {
+			continue
+		}
+
+		if err := uc.auditService.Record(ctx, metadata, core.AuditEvent{
+			Action:     core.AuditDelete,
+			EntityType: core.AuditWaitlist,
+			EntityId:   enrollmentAuditId(classroomId, entry.StudentId),
+			Before:     waitlistEntryAudit(entry),
+		}); err != nil {
+			return err
+		}
+
+		removed = append(removed, entry.StudentId)
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	return uc.classroomService.RemoveFromWaitlist(ctx, classroomId, removed)
+}
+
+// institutionStudents returns the students if all of them are in the institution of the classroom.
+func (uc ClassroomUseCase) institutionStudents(
+	ctx context.Context,
+	classroom core.Classroom,
+	studentsId []int,
+) ([]core.User, error) {
+	teacher, err := uc.userService.ById(ctx, classroom.TeacherId)
+	if err != nil {
+		return nil, err
+	}
+
+	students := make([]core.User, 0, len(studentsId))
+
+	for _, id := range studentsId {
+		if containsUser(students, id) {
+			continue
+		}
+
+		student, err := uc.userService.ById(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if student.Role != core.StudentRole || student.DeletedAt != nil || student.InstitutionId == nil ||
+			teacher.InstitutionId == nil || *student.InstitutionId != *teacher.InstitutionId {
+			return nil, apperrors.EntityNotFound
+		}
+
+		students = append(students, student)
+	}
+
+	return students, nil
+}
+
+func containsUser(users []core.User, id int) bool {
+	for _, user := range users {
+		if user.Id == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsId(ids []int, id int) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// enrollmentAuditId identifies a student in a classroom, like "3:17".
+func enrollmentAuditId(classroomId, studentId int) string {
+	return strconv.Itoa(classroomId) + ":" + strconv.Itoa(studentId)
+}
+
+// recordEnrollment records the students who were enrolled into the classroom or put on its waitlist.
+func recordEnrollment(
+	ctx context.Context,
+	auditService AuditService,
+	metadata core.TokenMetadata,
+	classroomId int,
+	enrollment core.Enrollment,
+) error {
+	groups := []struct {
+		entityType string
+		studentsId []int
+	}{
+		{entityType: core.AuditEnrollment, studentsId: enrollment.Enrolled},
+		{entityType: core.AuditWaitlist, studentsId: enrollment.Waitlisted},
+	}
+
+	for _, group := range groups {
+		for _, studentId := range group.studentsId {
+			if err := auditService.Record(ctx, metadata, core.AuditEvent{
+				Action:     core.AuditCreate,
+				EntityType: group.entityType,
+				EntityId:   enrollmentAuditId(classroomId, studentId),
+				After:      enrollmentAudit{ClassroomId: classroomId, StudentId: studentId},
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func appendMissingClassrooms(classrooms []core.Classroom, more []core.Classroom) []core.Classroom {
+	for _, classroom := range more {
+		found := false
+
+		for _, c := range classrooms {
+			if c.Id == classroom.Id {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			classrooms = append(classrooms, classroom)
+		}
+	}
+
+	return classrooms
+}
+
+// cloneLessonContent copies the content blocks of a lesson giving them new ids.
+func cloneLessonContent(content *[]core.LessonContent) *[]core.LessonContent {
+	if content == nil {
+		return nil
+	}
+
+	blocks := make([]core.LessonContent, 0, len(*content))
+
+	for _, block := range *content {
+		block.Id = uuid.NewString()
+		blocks = append(blocks, block)
+	}
+
+	return &blocks
+}
+
+// notifyPromotions tells the staff about the promoted students. The promotions are committed already,
+// so a failed email is logged instead of failing the request.
+func (uc ClassroomUseCase) notifyPromotions(ctx context.Context, classroomId int, promoted []core.WaitlistEntry) {
+	if err := notifyPromotions(ctx, uc.classroomService, uc.mailService, classroomId, promoted); err != nil {
+		uc.logger.Errorf("Failed to notify the staff of classroom %d about waitlist promotions: %v", classroomId, err)
+	}
+}
+
+// validateClassroom checks the fields which are set against the limits of the classrooms table.
+func validateClassroom(title *string, description *string, maxStudents *int) error {
+	// The title is also used in email subjects, where control characters have no place.
+	if title != nil && (*title == "" || utf8.RuneCountInString(*title) > classroomTitleMaxLength ||
+		strings.IndexFunc(*title, unicode.IsControl) >= 0) {
+		return apperrors.InvalidClassroom
+	}
+
+	if description != nil && utf8.RuneCountInString(*description) > classroomDescriptionMaxLength {
+		return apperrors.InvalidClassroom
+	}
+
+	if maxStudents != nil && *maxStudents < 1 {
+		return apperrors.InvalidClassroom
+	}
+
+	return nil
+}
+
+func classroomResponse(classroom core.Classroom) core.ClassroomResponse {
+	return core.ClassroomResponse{
+		Id:          classroom.Id,
+		Title:       classroom.Title,
+		Description: classroom.Description,
+		TeacherId:   classroom.TeacherId,
+		MaxStudents: classroom.MaxStudents,
+		ArchivedAt:  classroom.ArchivedAt,
+	}
+}
@@ -0,0 +1,161 @@
+package usecase
+
+import (
+	"context"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/authz"
+	"github.com/migmatore/study-platform-api/internal/core"
+)
+
+type ExportService interface {
+	Create(ctx context.Context, institutionId, requestedBy int) (core.ExportJob, error)
+	Start(job core.ExportJob)
+	ById(ctx context.Context, id int) (core.ExportJob, error)
+	InstitutionJobs(ctx context.Context, institutionId int) ([]core.ExportJob, error)
+	IssueLink(ctx context.Context, job core.ExportJob) (core.ExportLink, error)
+	ByToken(ctx context.Context, token string) (core.ExportFile, error)
+}
+
+type ExportUseCase struct {
+	authorizer         Authorizer
+	auditService       AuditService
+	transactionService TransactionService
+	exportService      ExportService
+	userService        InstitutionUserService
+}
+
+func NewExportUseCase(
+	authorizer Authorizer,
+	auditService AuditService,
+	transactionService TransactionService,
+	exportService ExportService,
+	userService InstitutionUserService,
+) *ExportUseCase {
+	return &ExportUseCase{
+		authorizer:         authorizer,
+		auditService:       auditService,
+		transactionService: transactionService,
+		exportService:      exportService,
+		userService:        userService,
+	}
+}
+
+// Start begins an export of all the data of the institution of the admin. The export runs in the
+// background, its progress is polled through ById.
+func (uc ExportUseCase) Start(ctx context.Context, metadata core.TokenMetadata) (core.ExportJobResponse, error) {
+	institutionId, err := uc.institutionId(ctx, metadata)
+	if err != nil {
+		return core.ExportJobResponse{}, err
+	}
+
+	var job core.ExportJob
+
+	if err := uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		job, err = uc.exportService.Create(txCtx, institutionId, metadata.UserId)
+		if err != nil {
+			return err
+		}
+
+		return uc.auditService.Record(txCtx, metadata, auditCreate(core.AuditExport, job.Id, exportJobResponse(job)))
+	}); err != nil {
+		return core.ExportJobResponse{}, err
+	}
+
+	uc.exportService.Start(job)
+
+	return exportJobResponse(job), nil
+}
+
+func (uc ExportUseCase) All(ctx context.Context, metadata core.TokenMetadata) ([]core.ExportJobResponse, error) {
+	institutionId, err := uc.institutionId(ctx, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs, err := uc.exportService.InstitutionJobs(ctx, institutionId)
+	if err != nil {
+		return nil, err
+	}
+
+	jobsResp := make([]core.ExportJobResponse, 0, len(jobs))
+
+	for _, job := range jobs {
+		jobsResp = append(jobsResp, exportJobResponse(job))
+	}
+
+	return jobsResp, nil
+}
+
+func (uc ExportUseCase) ById(ctx context.Context, metadata core.TokenMetadata, id int) (core.ExportJobResponse, error) {
+	job, err := uc.job(ctx, metadata, id)
+	if err != nil {
+		return core.ExportJobResponse{}, err
+	}
+
+	return exportJobResponse(job), nil
+}
+
+// Link issues a download token of the finished export. The returned link expires with the export.
+func (uc ExportUseCase) Link(ctx context.Context, metadata core.TokenMetadata, id int) (core.ExportLink, error) {
+	job, err := uc.job(ctx, metadata, id)
+	if err != nil {
+		return core.ExportLink{}, err
+	}
+
+	return uc.exportService.IssueLink(ctx, job)
+}
+
+// Download returns the file the token was issued for. The token is the only credential, so links
+// can be opened directly in a browser.
+func (uc ExportUseCase) Download(ctx context.Context, token string) (core.ExportFile, error) {
+	return uc.exportService.ByToken(ctx, token)
+}
+
+// job returns the export if it belongs to the institution of the admin. Exports of other
+// institutions are reported as missing.
+func (uc ExportUseCase) job(ctx context.Context, metadata core.TokenMetadata, id int) (core.ExportJob, error) {
+	institutionId, err := uc.institutionId(ctx, metadata)
+	if err != nil {
+		return core.ExportJob{}, err
+	}
+
+	job, err := uc.exportService.ById(ctx, id)
+	if err != nil {
+		return core.ExportJob{}, err
+	}
+
+	if job.InstitutionId != institutionId {
+		return core.ExportJob{}, apperrors.EntityNotFound
+	}
+
+	return job, nil
+}
+
+func (uc ExportUseCase) institutionId(ctx context.Context, metadata core.TokenMetadata) (int, error) {
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.InstitutionExport, authz.Any); err != nil {
+		return 0, err
+	}
+
+	admin, err := uc.userService.ById(ctx, metadata.UserId)
+	if err != nil {
+		return 0, err
+	}
+
+	if admin.InstitutionId == nil {
+		return 0, apperrors.AccessDenied
+	}
+
+	return *admin.InstitutionId, nil
+}
+
+func exportJobResponse(job core.ExportJob) core.ExportJobResponse {
+	return core.ExportJobResponse{
+		Id:         job.Id,
+		Status:     job.Status,
+		Progress:   job.Progress,
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		FinishedAt: job.FinishedAt,
+		ExpiresAt:  job.ExpiresAt,
+	}
+}
@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"context"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/authz"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"time"
+)
+
+type ImpersonationTokenService interface {
+	ImpersonationToken(userId int, role string, adminId int) (core.TokenWithClaims, int64, error)
+}
+
+type ImpersonationService interface {
+	Record(ctx context.Context, event core.ImpersonationEvent) error
+}
+
+type ImpersonationUseCase struct {
+	authorizer           Authorizer
+	tokenService         ImpersonationTokenService
+	userService          InstitutionUserService
+	impersonationService ImpersonationService
+}
+
+func NewImpersonationUseCase(
+	authorizer Authorizer,
+	tokenService ImpersonationTokenService,
+	userService InstitutionUserService,
+	impersonationService ImpersonationService,
+) *ImpersonationUseCase {
+	return &ImpersonationUseCase{
+		authorizer:           authorizer,
+		tokenService:         tokenService,
+		userService:          userService,
+		impersonationService: impersonationService,
+	}
+}
+
+// Impersonate lets an admin see the application as a teacher or a student of the same institution.
+// Admins can not be impersonated, and an impersonation can not be started from another one.
+func (uc ImpersonationUseCase) Impersonate(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	userId int,
+	client core.ClientInfo,
+) (core.ImpersonationResponse, error) {
+	if delegated(metadata) {
+		return core.ImpersonationResponse{}, apperrors.AccessDenied
+	}
+
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.UserImpersonate, authz.User(userId)); err != nil {
+		return core.ImpersonationResponse{}, err
+	}
+
+	user, err := uc.userService.ById(ctx, userId)
+	if err != nil {
+		return core.ImpersonationResponse{}, err
+	}
+
+	if user.Role == core.AdminRole {
+		return core.ImpersonationResponse{}, apperrors.AccessDenied
+	}
+
+	if !user.Active() {
+		return core.ImpersonationResponse{}, apperrors.UserDeactivated
+	}
+
+	if err := uc.impersonationService.Record(ctx, core.ImpersonationEvent{
+		AdminId: metadata.UserId,
+		UserId:  user.Id,
+		Event:   core.ImpersonationStarted,
+		Client:  client,
+	}); err != nil {
+		return core.ImpersonationResponse{}, err
+	}
+
+	token, expires, err := uc.tokenService.ImpersonationToken(user.Id, string(user.Role), metadata.UserId)
+	if err != nil {
+		return core.ImpersonationResponse{}, err
+	}
+
+	return core.ImpersonationResponse{
+		Token:     token.Token,
+		UserId:    token.UserId,
+		Role:      token.Role,
+		ExpiresAt: time.Unix(expires, 0),
+	}, nil
+}
+
+// RecordRequest writes a request made with an impersonation token to the audit trail.
+func (uc ImpersonationUseCase) RecordRequest(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	method string,
+	path string,
+	client core.ClientInfo,
+) error {
+	return uc.impersonationService.Record(ctx, core.ImpersonationEvent{
+		AdminId: metadata.ImpersonatorId,
+		UserId:  metadata.UserId,
+		Event:   core.ImpersonatedRequest,
+		Method:  method,
+		Path:    path,
+		Client:  client,
+	})
+}
+
+// delegated reports whether the request is made on behalf of the user, with an API key or under
+// impersonation. Such requests can not change how the account is accessed.
+func delegated(metadata core.TokenMetadata) bool {
+	return metadata.APIKeyId != 0 || metadata.ImpersonatorId != 0
+}
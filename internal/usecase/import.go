@@ -0,0 +1,440 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/authz"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+	"golang.org/x/crypto/bcrypt"
+	"net/mail"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// importMaxRows limits the size of an import, every account costs a password hash.
+	importMaxRows = 500
+	// temporaryPasswordBytes gives temporary passwords of 12 characters.
+	temporaryPasswordBytes = 9
+)
+
+// The limits of the users table columns.
+const (
+	userFullNameMaxLength = 100
+	userEmailMaxLength    = 50
+	userPhoneMaxLength    = 20
+)
+
+type ImportFileService interface {
+	Rows(file core.ImportFile, maxRows int) ([]core.ImportRow, error)
+}
+
+type ImportUserService interface {
+	ById(ctx context.Context, id int) (core.User, error)
+	IsExist(ctx context.Context, email string) (bool, error)
+	Create(ctx context.Context, user core.User) (core.User, error)
+}
+
+type ImportClassroomService interface {
+	Enroll(ctx context.Context, classroomId int, studentsId []int) (core.Enrollment, error)
+	AddStaff(ctx context.Context, classroomId, userId int, role core.StaffRole) error
+}
+
+type ImportUseCase struct {
+	authorizer         Authorizer
+	auditService       AuditService
+	transactionService TransactionService
+	importService      ImportFileService
+	userService        ImportUserService
+	classroomService   ImportClassroomService
+}
+
+func NewImportUseCase(
+	authorizer Authorizer,
+	auditService AuditService,
+	transactionService TransactionService,
+	importService ImportFileService,
+	userService ImportUserService,
+	classroomService ImportClassroomService,
+) *ImportUseCase {
+	return &ImportUseCase{
+		authorizer:         authorizer,
+		auditService:       auditService,
+		transactionService: transactionService,
+		importService:      importService,
+		userService:        userService,
+		classroomService:   classroomService,
+	}
+}
+
+// importAccount is a row of the import which passed the validation.
+type importAccount struct {
+	row          core.ImportRow
+	role         core.RoleType
+	classroomsId []int
+}
+
+// importGrant is a permission checked while validating an import.
+type importGrant struct {
+	permission  authz.Permission
+	classroomId int
+}
+
+// Users creates student and teacher accounts from the file. The rows are validated first and every
+// row with errors is reported. A dry run stops there. Otherwise the accounts are created in one
+// transaction, and only if no row has errors. Students are enrolled into their classrooms, or put on
+// the waitlists of the full ones, teachers join the staff of their classrooms as co-teachers. Every
+// account gets a temporary password, which is only returned in the response.
+func (uc ImportUseCase) Users(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	file core.ImportFile,
+	dryRun bool,
+) (core.ImportResponse, error) {
+	checked := make(map[importGrant]error)
+
+	studentErr := uc.authorize(ctx, metadata, importGrant{permission: authz.StudentCreate}, checked)
+	teacherErr := uc.authorize(ctx, metadata, importGrant{permission: authz.TeacherCreate}, checked)
+
+	if studentErr != nil && teacherErr != nil {
+		if errors.Is(studentErr, apperrors.AccessDenied) {
+			return core.ImportResponse{}, teacherErr
+		}
+
+		return core.ImportResponse{}, studentErr
+	}
+
+	rows, err := uc.importService.Rows(file, importMaxRows)
+	if err != nil {
+		return core.ImportResponse{}, err
+	}
+
+	if len(rows) == 0 {
+		return core.ImportResponse{}, fmt.Errorf("%w: the file has no accounts", apperrors.InvalidImportFile)
+	}
+
+	if len(rows) > importMaxRows {
+		return core.ImportResponse{}, fmt.Errorf(
+			"%w: the file has more than %d accounts",
+			apperrors.InvalidImportFile,
+			importMaxRows,
+		)
+	}
+
+	accounts, rowErrors, err := uc.validate(ctx, metadata, rows, checked)
+	if err != nil {
+		return core.ImportResponse{}, err
+	}
+
+	resp := core.ImportResponse{DryRun: dryRun, Rows: len(rows), Errors: rowErrors}
+
+	if dryRun {
+		return resp, nil
+	}
+
+	if len(rowErrors) > 0 {
+		return resp, apperrors.InvalidImport
+	}
+
+	importer, err := uc.userService.ById(ctx, metadata.UserId)
+	if err != nil {
+		return core.ImportResponse{}, err
+	}
+
+	// The passwords are hashed before the transaction, so it does not stay open for that long.
+	imported := make([]core.ImportedAccount, len(accounts))
+	hashes := make([]string, len(accounts))
+
+	for i, account := range accounts {
+		password, err := utils.RandomToken(temporaryPasswordBytes)
+		if err != nil {
+			return core.ImportResponse{}, err
+		}
+
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return core.ImportResponse{}, err
+		}
+
+		hashes[i] = string(hash)
+		imported[i] = core.ImportedAccount{
+			Line:              account.row.Line,
+			FullName:          account.row.FullName,
+			Email:             account.row.Email,
+			Role:              account.role,
+			TemporaryPassword: password,
+			ClassroomsId:      account.classroomsId,
+		}
+	}
+
+	if err := uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		students := make(map[int][]int)
+		teachers := make(map[int][]int)
+
+		for i, account := range accounts {
+			var phone *string
+
+			if account.row.Phone != "" {
+				phone = &account.row.Phone
+			}
+
+			user, err := uc.userService.Create(txCtx, core.User{
+				FullName:      account.row.FullName,
+				Phone:         phone,
+				Email:         account.row.Email,
+				PasswordHash:  hashes[i],
+				Role:          account.role,
+				InstitutionId: importer.InstitutionId,
+			})
+			if err != nil {
+				return err
+			}
+
+			imported[i].Id = user.Id
+
+			event := auditCreate(core.AuditTeacher, user.Id, userAudit{Id: user.Id})
+
+			if account.role == core.StudentRole {
+				event = auditCreate(core.AuditStudent, user.Id, userAudit{
+					Id:           user.Id,
+					ClassroomsId: account.classroomsId,
+				})
+			}
+
+			if err := uc.auditService.Record(txCtx, metadata, event); err != nil {
+				return err
+			}
+
+			for _, classroomId := range account.classroomsId {
+				if account.role == core.StudentRole {
+					students[classroomId] = append(students[classroomId], user.Id)
+				} else {
+					teachers[classroomId] = append(teachers[classroomId], user.Id)
+				}
+			}
+		}
+
+		// The classrooms are locked in the same order by every request, so they cannot deadlock.
+		for _, classroomId := range sortedKeys(students) {
+			enrollment, err := uc.classroomService.Enroll(txCtx, classroomId, students[classroomId])
+			if err != nil {
+				return err
+			}
+
+			if err := recordEnrollment(txCtx, uc.auditService, metadata, classroomId, enrollment); err != nil {
+				return err
+			}
+
+			for i := range imported {
+				if containsId(enrollment.Waitlisted, imported[i].Id) {
+					imported[i].WaitlistedId = append(imported[i].WaitlistedId, classroomId)
+				}
+			}
+		}
+
+		for _, classroomId := range sortedKeys(teachers) {
+			for _, teacherId := range teachers[classroomId] {
+				if err := uc.classroomService.AddStaff(txCtx, classroomId, teacherId, core.StaffCoTeacher); err != nil {
+					return err
+				}
+
+				if err := uc.auditService.Record(txCtx, metadata, core.AuditEvent{
+					Action:     core.AuditCreate,
+					EntityType: core.AuditClassroomStaff,
+					EntityId:   classroomStaffAuditId(classroomId, teacherId),
+					After:      staffAudit{UserId: teacherId, Role: core.StaffCoTeacher},
+				}); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return core.ImportResponse{}, err
+	}
+
+	resp.Accounts = imported
+
+	return resp, nil
+}
+
+// validate checks every row of the import and returns the valid accounts together with the errors of
+// the other rows.
+func (uc ImportUseCase) validate(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	rows []core.ImportRow,
+	checked map[importGrant]error,
+) ([]importAccount, []core.ImportRowError, error) {
+	accounts := make([]importAccount, 0, len(rows))
+	rowErrors := make([]core.ImportRowError, 0)
+	emails := make(map[string]int, len(rows))
+
+	for _, row := range rows {
+		var errs []string
+
+		if row.FullName == "" {
+			errs = append(errs, "the full name is empty")
+		} else if utf8.RuneCountInString(row.FullName) > userFullNameMaxLength {
+			errs = append(errs, fmt.Sprintf("the full name is longer than %d characters", userFullNameMaxLength))
+		}
+
+		if utf8.RuneCountInString(row.Phone) > userPhoneMaxLength {
+			errs = append(errs, fmt.Sprintf("the phone is longer than %d characters", userPhoneMaxLength))
+		}
+
+		switch line, ok := emails[strings.ToLower(row.Email)]; {
+		case row.Email == "":
+			errs = append(errs, "the email is empty")
+		case utf8.RuneCountInString(row.Email) > userEmailMaxLength:
+			errs = append(errs, fmt.Sprintf("the email is longer than %d characters", userEmailMaxLength))
+		case !validEmail(row.Email):
+			errs = append(errs, "the email is invalid")
+		case ok:
+			errs = append(errs, fmt.Sprintf("the email is already used in line %d", line))
+		default:
+			emails[strings.ToLower(row.Email)] = row.Line
+
+			exist, err := uc.userService.IsExist(ctx, row.Email)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if exist {
+				errs = append(errs, "an account with the email already exists")
+			}
+		}
+
+		var createPermission, classroomPermission authz.Permission
+
+		role := core.RoleType(strings.ToLower(row.Role))
+
+		switch role {
+		case core.StudentRole:
+			createPermission, classroomPermission = authz.StudentCreate, authz.ClassroomStudentsManage
+		case core.TeacherRole:
+			createPermission, classroomPermission = authz.TeacherCreate, authz.ClassroomStaffManage
+		default:
+			errs = append(errs, "the role must be student or teacher")
+		}
+
+		if createPermission != "" {
+			err := uc.authorize(ctx, metadata, importGrant{permission: createPermission}, checked)
+			if err != nil && !errors.Is(err, apperrors.AccessDenied) {
+				return nil, nil, err
+			}
+
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("you cannot create %s accounts", role))
+			}
+		}
+
+		classroomsId, err := parseClassroomsId(row.Classrooms)
+		if err != nil {
+			errs = append(errs, "the classrooms must be ids separated by commas or semicolons")
+		}
+
+		for _, classroomId := range classroomsId {
+			if classroomPermission == "" {
+				break
+			}
+
+			err := uc.authorize(
+				ctx,
+				metadata,
+				importGrant{permission: classroomPermission, classroomId: classroomId},
+				checked,
+			)
+			if err == nil {
+				continue
+			}
+
+			if !errors.Is(err, apperrors.AccessDenied) &&
+				!errors.Is(err, apperrors.EntityNotFound) &&
+				!errors.Is(err, apperrors.ClassroomArchived) {
+				return nil, nil, err
+			}
+
+			errs = append(errs, fmt.Sprintf("classroom %d: %v", classroomId, err))
+		}
+
+		if len(errs) > 0 {
+			rowErrors = append(rowErrors, core.ImportRowError{Line: row.Line, Email: row.Email, Errors: errs})
+			continue
+		}
+
+		accounts = append(accounts, importAccount{row: row, role: role, classroomsId: classroomsId})
+	}
+
+	return accounts, rowErrors, nil
+}
+
+// authorize checks the permission once per import, the rows share the result.
+func (uc ImportUseCase) authorize(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	grant importGrant,
+	checked map[importGrant]error,
+) error {
+	if err, ok := checked[grant]; ok {
+		return err
+	}
+
+	resource := authz.Any
+	if grant.classroomId != 0 {
+		resource = authz.Classroom(grant.classroomId)
+	}
+
+	err := uc.authorizer.Authorize(ctx, metadata, grant.permission, resource)
+	checked[grant] = err
+
+	return err
+}
+
+// parseClassroomsId parses the classroom ids separated by commas, semicolons or spaces. Repeated ids
+// are skipped.
+func parseClassroomsId(s string) ([]int, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ';' || unicode.IsSpace(r)
+	})
+
+	ids := make([]int, 0, len(fields))
+
+	for _, field := range fields {
+		id, err := strconv.Atoi(field)
+		if err != nil || id <= 0 {
+			return nil, errors.New("invalid classroom id")
+		}
+
+		if !containsId(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+func validEmail(email string) bool {
+	address, err := mail.ParseAddress(email)
+
+	return err == nil && address.Address == email
+}
+
+func sortedKeys(m map[int][]int) []int {
+	keys := make([]int, 0, len(m))
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Ints(keys)
+
+	return keys
+}
@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/authz"
+	"github.com/migmatore/study-platform-api/internal/core"
+)
+
+type InstitutionUserService interface {
+	ById(ctx context.Context, id int) (core.User, error)
+}
+
+type OIDCService interface {
+	AuthURL(ctx context.Context, institutionId int) (string, error)
+	Authenticate(ctx context.Context, institutionId int, code string, state string) (core.OIDCIdentity, error)
+	UserIdByIdentity(ctx context.Context, issuer string, subject string) (int, error)
+	LinkIdentity(ctx context.Context, userId int, issuer string, subject string) error
+	Provider(ctx context.Context, institutionId int) (core.OIDCProvider, error)
+	SaveProvider(ctx context.Context, p core.OIDCProvider) error
+	RedirectURI(institutionId int) string
+}
+
+type InstitutionOIDCService interface {
+	Provider(ctx context.Context, institutionId int) (core.OIDCProvider, error)
+	SaveProvider(ctx context.Context, p core.OIDCProvider) error
+	RedirectURI(institutionId int) string
+}
+
+type InstitutionUseCase struct {
+	authorizer   Authorizer
+	auditService AuditService
+	userService  InstitutionUserService
+	oidcService  InstitutionOIDCService
+}
+
+func NewInstitutionUseCase(
+	authorizer Authorizer,
+	auditService AuditService,
+	userService InstitutionUserService,
+	oidcService InstitutionOIDCService,
+) *InstitutionUseCase {
+	return &InstitutionUseCase{
+		authorizer:   authorizer,
+		auditService: auditService,
+		userService:  userService,
+		oidcService:  oidcService,
+	}
+}
+
+func (uc InstitutionUseCase) OIDCProvider(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+) (core.OIDCProviderResponse, error) {
+	institutionId, err := adminInstitutionId(ctx, uc.authorizer, uc.userService, metadata)
+	if err != nil {
+		return core.OIDCProviderResponse{}, err
+	}
+
+	p, err := uc.oidcService.Provider(ctx, institutionId)
+	if err != nil {
+		return core.OIDCProviderResponse{}, err
+	}
+
+	return uc.oidcProviderResponse(p), nil
+}
+
+// UpdateOIDCProvider sets up single sign-on for the institution of the admin. The client secret is
+// kept when the request does not carry a new one.
+func (uc InstitutionUseCase) UpdateOIDCProvider(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	req core.UpdateOIDCProviderRequest,
+) (core.OIDCProviderResponse, error) {
+	institutionId, err := adminInstitutionId(ctx, uc.authorizer, uc.userService, metadata)
+	if err != nil {
+		return core.OIDCProviderResponse{}, err
+	}
+
+	for _, role := range req.RoleMapping {
+		if !validRole(role) {
+			return core.OIDCProviderResponse{}, apperrors.InvalidOIDCProvider
+		}
+	}
+
+	if req.DefaultRole != nil && !validRole(*req.DefaultRole) {
+		return core.OIDCProviderResponse{}, apperrors.InvalidOIDCProvider
+	}
+
+	var before interface{}
+
+	current, err := uc.oidcService.Provider(ctx, institutionId)
+	if err != nil && !errors.Is(err, apperrors.EntityNotFound) {
+		return core.OIDCProviderResponse{}, err
+	}
+
+	exist := err == nil
+	if exist {
+		before = uc.oidcProviderResponse(current)
+	}
+
+	clientSecret := current.ClientSecret
+
+	if req.ClientSecret != nil {
+		clientSecret = *req.ClientSecret
+	} else if !exist {
+		return core.OIDCProviderResponse{}, apperrors.InvalidOIDCProvider
+	}
+
+	p := core.OIDCProvider{
+		InstitutionId: institutionId,
+		Issuer:        req.Issuer,
+		ClientId:      req.ClientId,
+		ClientSecret:  clientSecret,
+		Scopes:        req.Scopes,
+		RoleClaim:     req.RoleClaim,
+		RoleMapping:   req.RoleMapping,
+		DefaultRole:   req.DefaultRole,
+		Enabled:       req.Enabled,
+	}
+
+	if err := uc.oidcService.SaveProvider(ctx, p); err != nil {
+		return core.OIDCProviderResponse{}, err
+	}
+
+	// The client secret is not part of the response, so it is never recorded.
+	after := uc.oidcProviderResponse(p)
+
+	event := auditUpdate(core.AuditOIDCProvider, institutionId, before, after)
+	if !exist {
+		event = auditCreate(core.AuditOIDCProvider, institutionId, after)
+	}
+
+	if err := uc.auditService.Record(ctx, metadata, event); err != nil {
+		return core.OIDCProviderResponse{}, err
+	}
+
+	return after, nil
+}
+
+func (uc InstitutionUseCase) oidcProviderResponse(p core.OIDCProvider) core.OIDCProviderResponse {
+	return core.OIDCProviderResponse{
+		Issuer:      p.Issuer,
+		ClientId:    p.ClientId,
+		Scopes:      p.Scopes,
+		RoleClaim:   p.RoleClaim,
+		RoleMapping: p.RoleMapping,
+		DefaultRole: p.DefaultRole,
+		Enabled:     p.Enabled,
+		RedirectURI: uc.oidcService.RedirectURI(p.InstitutionId),
+	}
+}
+
+// adminInstitutionId returns the institution of the admin making the request.
+func adminInstitutionId(
+	ctx context.Context,
+	authorizer Authorizer,
+	userService InstitutionUserService,
+	metadata core.TokenMetadata,
+) (int, error) {
+	if err := authorizer.Authorize(ctx, metadata, authz.InstitutionManage, authz.Any); err != nil {
+		return 0, err
+	}
+
+	admin, err := userService.ById(ctx, metadata.UserId)
+	if err != nil {
+		return 0, err
+	}
+
+	if admin.InstitutionId == nil {
+		return 0, apperrors.AccessDenied
+	}
+
+	return *admin.InstitutionId, nil
+}
+
+func validRole(role core.RoleType) bool {
+	return role == core.AdminRole || role == core.TeacherRole || role == core.StudentRole
+}
@@ -0,0 +1,229 @@
+package usecase
+
+import (
+	"context"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/authz"
+	"github.com/migmatore/study-platform-api/internal/core"
+)
+
+type InvitationService interface {
+	CreateInvitation(ctx context.Context, classroomId int, createdBy int, email string) (core.Invitation, string, error)
+	CreateJoinCode(
+		ctx context.Context,
+		classroomId int,
+		createdBy int,
+		expHour int,
+		maxUses *int,
+	) (core.Invitation, string, error)
+	ById(ctx context.Context, id int) (core.Invitation, error)
+	ActiveByClassroomId(ctx context.Context, classroomId int) ([]core.Invitation, error)
+	ByTokenForUpdate(ctx context.Context, token string) (core.Invitation, error)
+	ByCodeForUpdate(ctx context.Context, code string) (core.Invitation, error)
+	Use(ctx context.Context, id int) error
+	Revoke(ctx context.Context, id int) error
+}
+
+type InvitationClassroomService interface {
+	ById(ctx context.Context, id int) (core.Classroom, error)
+}
+
+type InvitationUserService interface {
+	IsExist(ctx context.Context, email string) (bool, error)
+}
+
+type InvitationMailService interface {
+	SendInvitation(ctx context.Context, to string, classroomTitle string, token string) error
+}
+
+type InvitationUseCase struct {
+	authorizer        Authorizer
+	auditService      AuditService
+	invitationService InvitationService
+	classroomService  InvitationClassroomService
+	userService       InvitationUserService
+	mailService       InvitationMailService
+}
+
+func NewInvitationUseCase(
+	authorizer Authorizer,
+	auditService AuditService,
+	invitationService InvitationService,
+	classroomService InvitationClassroomService,
+	userService InvitationUserService,
+	mailService InvitationMailService,
+) *InvitationUseCase {
+	return &InvitationUseCase{
+		authorizer:        authorizer,
+		auditService:      auditService,
+		invitationService: invitationService,
+		classroomService:  classroomService,
+		userService:       userService,
+		mailService:       mailService,
+	}
+}
+
+// Invite emails a personal invitation into the classroom of the teacher.
+func (uc InvitationUseCase) Invite(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	classroomId int,
+	req core.CreateInvitationRequest,
+) (core.InvitationResponse, error) {
+	classroom, err := uc.teacherClassroom(ctx, metadata, classroomId)
+	if err != nil {
+		return core.InvitationResponse{}, err
+	}
+
+	exist, err := uc.userService.IsExist(ctx, req.Email)
+	if err != nil {
+		return core.InvitationResponse{}, err
+	}
+
+	if exist {
+		return core.InvitationResponse{}, apperrors.EntityAlreadyExist
+	}
+
+	invitation, token, err := uc.invitationService.CreateInvitation(ctx, classroom.Id, metadata.UserId, req.Email)
+	if err != nil {
+		return core.InvitationResponse{}, err
+	}
+
+	if err := uc.mailService.SendInvitation(ctx, req.Email, classroom.Title, token); err != nil {
+		return core.InvitationResponse{}, err
+	}
+
+	invitationResp := invitationResponse(invitation, "")
+
+	if err := uc.auditService.Record(
+		ctx,
+		metadata,
+		auditCreate(core.AuditInvitation, invitation.Id, invitationAudit(invitation)),
+	); err != nil {
+		return core.InvitationResponse{}, err
+	}
+
+	return invitationResp, nil
+}
+
+// CreateJoinCode issues a code the teacher can share with the whole class. The code is returned only once.
+func (uc InvitationUseCase) CreateJoinCode(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	classroomId int,
+	req core.CreateJoinCodeRequest,
+) (core.InvitationResponse, error) {
+	classroom, err := uc.teacherClassroom(ctx, metadata, classroomId)
+	if err != nil {
+		return core.InvitationResponse{}, err
+	}
+
+	invitation, code, err := uc.invitationService.CreateJoinCode(
+		ctx,
+		classroom.Id,
+		metadata.UserId,
+		req.ExpiresInHours,
+		req.MaxUses,
+	)
+	if err != nil {
+		return core.InvitationResponse{}, err
+	}
+
+	// The code works like a password, so it is not recorded.
+	if err := uc.auditService.Record(
+		ctx,
+		metadata,
+		auditCreate(core.AuditInvitation, invitation.Id, invitationAudit(invitation)),
+	); err != nil {
+		return core.InvitationResponse{}, err
+	}
+
+	return invitationResponse(invitation, code), nil
+}
+
+func (uc InvitationUseCase) All(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	classroomId int,
+) ([]core.InvitationResponse, error) {
+	if _, err := uc.teacherClassroom(ctx, metadata, classroomId); err != nil {
+		return nil, err
+	}
+
+	invitations, err := uc.invitationService.ActiveByClassroomId(ctx, classroomId)
+	if err != nil {
+		return nil, err
+	}
+
+	invitationsResp := make([]core.InvitationResponse, 0, len(invitations))
+
+	for _, invitation := range invitations {
+		invitationsResp = append(invitationsResp, invitationResponse(invitation, ""))
+	}
+
+	return invitationsResp, nil
+}
+
+func (uc InvitationUseCase) Revoke(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	classroomId int,
+	invitationId int,
+) error {
+	if _, err := uc.teacherClassroom(ctx, metadata, classroomId); err != nil {
+		return err
+	}
+
+	invitation, err := uc.invitationService.ById(ctx, invitationId)
+	if err != nil {
+		return err
+	}
+
+	if invitation.ClassroomId != classroomId {
+		return apperrors.EntityNotFound
+	}
+
+	if err := uc.invitationService.Revoke(ctx, invitationId); err != nil {
+		return err
+	}
+
+	return uc.auditService.Record(
+		ctx,
+		metadata,
+		auditDelete(core.AuditInvitation, invitationId, invitationAudit(invitation)),
+	)
+}
+
+// teacherClassroom returns the classroom when it belongs to the teacher making the request.
+func (uc InvitationUseCase) teacherClassroom(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	classroomId int,
+) (core.Classroom, error) {
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.InvitationManage, authz.Classroom(classroomId)); err != nil {
+		return core.Classroom{}, err
+	}
+
+	return uc.classroomService.ById(ctx, classroomId)
+}
+
+// invitationAudit is the audited state of an invitation. The email of the invited person is left out,
+// like the personal data of users.
+func invitationAudit(invitation core.Invitation) core.InvitationResponse {
+	resp := invitationResponse(invitation, "")
+	resp.Email = nil
+
+	return resp
+}
+
+func invitationResponse(invitation core.Invitation, code string) core.InvitationResponse {
+	return core.InvitationResponse{
+		Id:        invitation.Id,
+		Email:     invitation.Email,
+		Code:      code,
+		MaxUses:   invitation.MaxUses,
+		Uses:      invitation.Uses,
+		ExpiresAt: invitation.ExpiresAt,
+		CreatedAt: invitation.CreatedAt,
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/authz"
 	"github.com/migmatore/study-platform-api/internal/core"
 )
 
@@ -13,36 +14,16 @@ type LessonService interface {
 	Create(ctx context.Context, lesson core.Lesson) (core.Lesson, error)
 	Update(ctx context.Context, lesson core.UpdateLesson) error
 	Delete(ctx context.Context, id int) error
-	IsBelongs(ctx context.Context, lessonId int, teacherId int) (bool, error)
-}
-
-type LessonClassroomService interface {
-	ById(ctx context.Context, id int) (core.Classroom, error)
-	IsBelongs(ctx context.Context, classroomId int, teacherId int) (bool, error)
-	IsIn(ctx context.Context, classroomId, studentId int) (bool, error)
-	Students(ctx context.Context, classroomId int) ([]core.Student, error)
-}
-
-type LessonTeacherService interface {
-	ById(ctx context.Context, id int) (core.User, error)
 }
 
 type LessonUseCase struct {
-	lessonsService   LessonService
-	teacherService   LessonTeacherService
-	classroomService LessonClassroomService
+	authorizer     Authorizer
+	auditService   AuditService
+	lessonsService LessonService
 }
 
-func NewLessonUseCase(
-	lessonsService LessonService,
-	classroomService LessonClassroomService,
-	teacherService LessonTeacherService,
-) *LessonUseCase {
-	return &LessonUseCase{
-		lessonsService:   lessonsService,
-		classroomService: classroomService,
-		teacherService:   teacherService,
-	}
+func NewLessonUseCase(authorizer Authorizer, auditService AuditService, lessonsService LessonService) *LessonUseCase {
+	return &LessonUseCase{authorizer: authorizer, auditService: auditService, lessonsService: lessonsService}
 }
 
 func (uc LessonUseCase) All(
@@ -50,15 +31,10 @@ func (uc LessonUseCase) All(
 	metadata core.TokenMetadata,
 	classroomId int,
 ) ([]core.LessonResponse, error) {
-	belongs, err := uc.classroomService.IsBelongs(ctx, classroomId, metadata.UserId)
-	if err != nil {
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.LessonView, authz.Classroom(classroomId)); err != nil {
 		return nil, err
 	}
 
-	if !belongs {
-		return nil, apperrors.AccessDenied
-	}
-
 	lessons, err := uc.lessonsService.All(ctx, classroomId)
 	if err != nil {
 		return nil, err
@@ -67,13 +43,7 @@ func (uc LessonUseCase) All(
 	lessonsResp := make([]core.LessonResponse, 0, len(lessons))
 
 	for _, lesson := range lessons {
-		lessonsResp = append(lessonsResp, core.LessonResponse{
-			Id:          lesson.Id,
-			Title:       lesson.Title,
-			ClassroomId: lesson.ClassroomId,
-			Content:     lesson.Content,
-			Active:      lesson.Active,
-		})
+		lessonsResp = append(lessonsResp, lessonResponse(lesson))
 	}
 
 	return lessonsResp, nil
@@ -84,33 +54,16 @@ func (uc LessonUseCase) ById(
 	metadata core.TokenMetadata,
 	lessonId int,
 ) (core.LessonResponse, error) {
-	if core.RoleType(metadata.Role) == core.StudentRole {
-		return core.LessonResponse{}, apperrors.AccessDenied
-	}
-
 	lesson, err := uc.lessonsService.ById(ctx, lessonId)
 	if err != nil {
 		return core.LessonResponse{}, err
 	}
 
-	// TODO: implement admin access check
-
-	belongs, err := uc.classroomService.IsBelongs(ctx, lesson.ClassroomId, metadata.UserId)
-	if err != nil {
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.LessonView, authz.Classroom(lesson.ClassroomId)); err != nil {
 		return core.LessonResponse{}, err
 	}
 
-	if !belongs {
-		return core.LessonResponse{}, apperrors.AccessDenied
-	}
-
-	return core.LessonResponse{
-		Id:          lesson.Id,
-		Title:       lesson.Title,
-		ClassroomId: lesson.ClassroomId,
-		Content:     lesson.Content,
-		Active:      lesson.Active,
-	}, nil
+	return lessonResponse(lesson), nil
 }
 
 func (uc LessonUseCase) Current(
@@ -118,15 +71,15 @@ func (uc LessonUseCase) Current(
 	metadata core.TokenMetadata,
 	classroomId int,
 ) (core.LessonResponse, error) {
-	in, err := uc.classroomService.IsIn(ctx, classroomId, metadata.UserId)
-	if err != nil {
+	if err := uc.authorizer.Authorize(
+		ctx,
+		metadata,
+		authz.LessonViewCurrent,
+		authz.Classroom(classroomId),
+	); err != nil {
 		return core.LessonResponse{}, err
 	}
 
-	if !in {
-		return core.LessonResponse{}, apperrors.AccessDenied
-	}
-
 	lessons, err := uc.lessonsService.All(ctx, classroomId)
 	if err != nil {
 		return core.LessonResponse{}, err
@@ -137,13 +90,7 @@ func (uc LessonUseCase) Current(
 			continue
 		}
 
-		return core.LessonResponse{
-			Id:          lesson.Id,
-			Title:       lesson.Title,
-			ClassroomId: lesson.ClassroomId,
-			Content:     lesson.Content,
-			Active:      lesson.Active,
-		}, nil
+		return lessonResponse(lesson), nil
 	}
 
 	return core.LessonResponse{}, apperrors.EntityNotFound
@@ -155,19 +102,10 @@ func (uc LessonUseCase) Create(
 	classroomId int,
 	req core.CreateLessonRequest,
 ) (core.LessonResponse, error) {
-	if core.RoleType(metadata.Role) != core.TeacherRole {
-		return core.LessonResponse{}, apperrors.AccessDenied
-	}
-
-	belongs, err := uc.classroomService.IsBelongs(ctx, classroomId, metadata.UserId)
-	if err != nil {
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.LessonCreate, authz.Classroom(classroomId)); err != nil {
 		return core.LessonResponse{}, err
 	}
 
-	if !belongs {
-		return core.LessonResponse{}, apperrors.AccessDenied
-	}
-
 	lessons, err := uc.lessonsService.All(ctx, classroomId)
 	if err != nil {
 		return core.LessonResponse{}, err
@@ -199,13 +137,13 @@ func (uc LessonUseCase) Create(
 		return core.LessonResponse{}, err
 	}
 
-	return core.LessonResponse{
-		Id:          newLesson.Id,
-		Title:       newLesson.Title,
-		ClassroomId: newLesson.ClassroomId,
-		Content:     newLesson.Content,
-		Active:      newLesson.Active,
-	}, nil
+	lessonResp := lessonResponse(newLesson)
+
+	if err := uc.auditService.Record(ctx, metadata, auditCreate(core.AuditLesson, newLesson.Id, lessonResp)); err != nil {
+		return core.LessonResponse{}, err
+	}
+
+	return lessonResp, nil
 }
 
 func (uc LessonUseCase) Update(
@@ -213,21 +151,20 @@ func (uc LessonUseCase) Update(
 	metadata core.TokenMetadata,
 	req core.UpdateLessonRequest,
 ) error {
-	if core.RoleType(metadata.Role) != core.TeacherRole {
-		return apperrors.AccessDenied
-	}
-
 	if req.ClassroomId == nil || req.LessonId == nil {
 		return errors.New("classroomId or lessonId must be number")
 	}
 
-	belongs, err := uc.classroomService.IsBelongs(ctx, *req.ClassroomId, metadata.UserId)
+	lesson, err := uc.lessonsService.ById(ctx, *req.LessonId)
 	if err != nil {
 		return err
 	}
 
-	if !belongs {
-		return apperrors.AccessDenied
+	// The lesson can be moved to another classroom, so both of them are checked.
+	for _, classroomId := range []int{lesson.ClassroomId, *req.ClassroomId} {
+		if err := uc.authorizer.Authorize(ctx, metadata, authz.LessonUpdate, authz.Classroom(classroomId)); err != nil {
+			return err
+		}
 	}
 
 	lessons, err := uc.lessonsService.All(ctx, *req.ClassroomId)
@@ -262,29 +199,41 @@ func (uc LessonUseCase) Update(
 		return err
 	}
 
-	return nil
+	updated, err := uc.lessonsService.ById(ctx, *req.LessonId)
+	if err != nil {
+		return err
+	}
+
+	return uc.auditService.Record(
+		ctx,
+		metadata,
+		auditUpdate(core.AuditLesson, lesson.Id, lessonResponse(lesson), lessonResponse(updated)),
+	)
 }
 
 func (uc LessonUseCase) Delete(ctx context.Context, metadata core.TokenMetadata, lessonId int) error {
-	switch core.RoleType(metadata.Role) {
-	case core.AdminRole:
-		return apperrors.AccessDenied
-	case core.TeacherRole:
-		isBelongs, err := uc.lessonsService.IsBelongs(ctx, lessonId, metadata.UserId)
-		if err != nil {
-			return err
-		}
+	lesson, err := uc.lessonsService.ById(ctx, lessonId)
+	if err != nil {
+		return err
+	}
 
-		if !isBelongs {
-			return apperrors.AccessDenied
-		}
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.LessonDelete, authz.Classroom(lesson.ClassroomId)); err != nil {
+		return err
+	}
 
-		if err := uc.lessonsService.Delete(ctx, lessonId); err != nil {
-			return err
-		}
-	case core.StudentRole:
-		return apperrors.AccessDenied
+	if err := uc.lessonsService.Delete(ctx, lessonId); err != nil {
+		return err
 	}
 
-	return nil
+	return uc.auditService.Record(ctx, metadata, auditDelete(core.AuditLesson, lessonId, lessonResponse(lesson)))
+}
+
+func lessonResponse(lesson core.Lesson) core.LessonResponse {
+	return core.LessonResponse{
+		Id:          lesson.Id,
+		Title:       lesson.Title,
+		ClassroomId: lesson.ClassroomId,
+		Content:     lesson.Content,
+		Active:      lesson.Active,
+	}
 }
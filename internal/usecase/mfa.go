@@ -0,0 +1,175 @@
+package usecase
+
+import (
+	"context"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/authz"
+	"github.com/migmatore/study-platform-api/internal/core"
+)
+
+type MFAUserService interface {
+	ById(ctx context.Context, id int) (core.User, error)
+}
+
+type MFAService interface {
+	IsEnabled(ctx context.Context, userId int) (bool, error)
+	Enroll(ctx context.Context, userId int, account string) (core.MFAEnrollment, error)
+	Confirm(ctx context.Context, userId int, code string) ([]string, error)
+	Verify(ctx context.Context, userId int, code string) (bool, error)
+	Disable(ctx context.Context, userId int) error
+	RegenerateRecoveryCodes(ctx context.Context, userId int) ([]string, error)
+	CreateChallenge(ctx context.Context, userId int) (string, error)
+	ChallengeForUpdate(ctx context.Context, token string) (core.MFAChallenge, error)
+	FailChallenge(ctx context.Context, challenge core.MFAChallenge) error
+	CompleteChallenge(ctx context.Context, id int) error
+}
+
+type MFAUseCase struct {
+	authorizer         Authorizer
+	auditService       AuditService
+	transactionService TransactionService
+	userService        MFAUserService
+	mfaService         MFAService
+}
+
+func NewMFAUseCase(
+	authorizer Authorizer,
+	auditService AuditService,
+	transactionService TransactionService,
+	userService MFAUserService,
+	mfaService MFAService,
+) *MFAUseCase {
+	return &MFAUseCase{
+		authorizer:         authorizer,
+		auditService:       auditService,
+		transactionService: transactionService,
+		userService:        userService,
+		mfaService:         mfaService,
+	}
+}
+
+func (uc MFAUseCase) Status(ctx context.Context, metadata core.TokenMetadata) (core.MFAStatusResponse, error) {
+	enabled, err := uc.mfaService.IsEnabled(ctx, metadata.UserId)
+	if err != nil {
+		return core.MFAStatusResponse{}, err
+	}
+
+	return core.MFAStatusResponse{Enabled: enabled}, nil
+}
+
+// Enroll starts setting up an authenticator app. Only admins and teachers can use the second factor.
+func (uc MFAUseCase) Enroll(ctx context.Context, metadata core.TokenMetadata) (core.MFAEnrollResponse, error) {
+	if delegated(metadata) {
+		return core.MFAEnrollResponse{}, apperrors.AccessDenied
+	}
+
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.MFAEnroll, authz.Any); err != nil {
+		return core.MFAEnrollResponse{}, err
+	}
+
+	user, err := uc.userService.ById(ctx, metadata.UserId)
+	if err != nil {
+		return core.MFAEnrollResponse{}, err
+	}
+
+	enrollment, err := uc.mfaService.Enroll(ctx, user.Id, user.Email)
+	if err != nil {
+		return core.MFAEnrollResponse{}, err
+	}
+
+	return core.MFAEnrollResponse{
+		Secret: enrollment.Secret,
+		URI:    enrollment.URI,
+	}, nil
+}
+
+func (uc MFAUseCase) Confirm(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	req core.MFACodeRequest,
+) (core.MFARecoveryCodesResponse, error) {
+	if delegated(metadata) {
+		return core.MFARecoveryCodesResponse{}, apperrors.AccessDenied
+	}
+
+	var codes []string
+
+	if err := uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+
+		codes, err = uc.mfaService.Confirm(txCtx, metadata.UserId, req.Code)
+		if err != nil {
+			return err
+		}
+
+		return uc.auditService.Record(txCtx, metadata, auditUpdate(core.AuditUser, metadata.UserId, nil, map[string]bool{
+			"mfa_enabled": true,
+		}))
+	}); err != nil {
+		return core.MFARecoveryCodesResponse{}, err
+	}
+
+	return core.MFARecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Disable turns the second factor off. It requires a current code, so a stolen access token is not enough.
+func (uc MFAUseCase) Disable(ctx context.Context, metadata core.TokenMetadata, req core.MFACodeRequest) error {
+	if delegated(metadata) {
+		return apperrors.AccessDenied
+	}
+
+	return uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := uc.verify(txCtx, metadata.UserId, req.Code); err != nil {
+			return err
+		}
+
+		if err := uc.mfaService.Disable(txCtx, metadata.UserId); err != nil {
+			return err
+		}
+
+		return uc.auditService.Record(txCtx, metadata, auditUpdate(core.AuditUser, metadata.UserId, nil, map[string]bool{
+			"mfa_enabled": false,
+		}))
+	})
+}
+
+func (uc MFAUseCase) RecoveryCodes(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	req core.MFACodeRequest,
+) (core.MFARecoveryCodesResponse, error) {
+	if delegated(metadata) {
+		return core.MFARecoveryCodesResponse{}, apperrors.AccessDenied
+	}
+
+	var codes []string
+
+	if err := uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := uc.verify(txCtx, metadata.UserId, req.Code); err != nil {
+			return err
+		}
+
+		var err error
+
+		codes, err = uc.mfaService.RegenerateRecoveryCodes(txCtx, metadata.UserId)
+
+		return err
+	}); err != nil {
+		return core.MFARecoveryCodesResponse{}, err
+	}
+
+	return core.MFARecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (uc MFAUseCase) verify(ctx context.Context, userId int, code string) error {
+	ok, err := uc.mfaService.Verify(ctx, userId, code)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return apperrors.InvalidMFACode
+	}
+
+	return nil
+}
@@ -0,0 +1,296 @@
+package usecase
+
+import (
+	"context"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/authz"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/logger"
+	"github.com/migmatore/study-platform-api/pkg/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type PrivacyService interface {
+	PersonalData(ctx context.Context, user core.User) (core.PersonalData, error)
+	RequestErasure(ctx context.Context, userId int, requestedBy int, reason *string) (core.ErasureRequest, error)
+	ErasureById(ctx context.Context, id int) (core.ErasureRequest, error)
+	ErasureForReview(ctx context.Context, id int) (core.ErasureRequest, error)
+	InstitutionErasures(ctx context.Context, institutionId int) ([]core.ErasureRequest, error)
+	Approve(ctx context.Context, request core.ErasureRequest, reviewerId int, passwordHash string) error
+	Reject(ctx context.Context, request core.ErasureRequest, reviewerId int) error
+}
+
+type PrivacyUseCase struct {
+	logger                logger.Logger
+	authorizer            Authorizer
+	auditService          AuditService
+	transactionService    TransactionService
+	privacyService        PrivacyService
+	userService           InstitutionUserService
+	signinThrottleService UserSigninThrottleService
+	classroomService      SeatClassroomService
+	mailService           WaitlistMailService
+}
+
+func NewPrivacyUseCase(
+	logger logger.Logger,
+	authorizer Authorizer,
+	auditService AuditService,
+	transactionService TransactionService,
+	privacyService PrivacyService,
+	userService InstitutionUserService,
+	signinThrottleService UserSigninThrottleService,
+	classroomService SeatClassroomService,
+	mailService WaitlistMailService,
+) *PrivacyUseCase {
+	return &PrivacyUseCase{
+		logger:                logger,
+		authorizer:            authorizer,
+		auditService:          auditService,
+		transactionService:    transactionService,
+		privacyService:        privacyService,
+		userService:           userService,
+		signinThrottleService: signinThrottleService,
+		classroomService:      classroomService,
+		mailService:           mailService,
+	}
+}
+
+// PersonalData returns everything stored about the user. Only the user can download it, not an API key
+// or an admin impersonating the user.
+func (uc PrivacyUseCase) PersonalData(ctx context.Context, metadata core.TokenMetadata) (core.PersonalData, error) {
+	if delegated(metadata) {
+		return core.PersonalData{}, apperrors.AccessDenied
+	}
+
+	user, err := uc.userService.ById(ctx, metadata.UserId)
+	if err != nil {
+		return core.PersonalData{}, err
+	}
+
+	return uc.privacyService.PersonalData(ctx, user)
+}
+
+// RequestErasure asks to erase the personal data of the user. It is done once an admin approves it.
+func (uc PrivacyUseCase) RequestErasure(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	req core.CreateErasureRequest,
+) (core.ErasureRequestResponse, error) {
+	if delegated(metadata) {
+		return core.ErasureRequestResponse{}, apperrors.AccessDenied
+	}
+
+	return uc.requestErasure(ctx, metadata, metadata.UserId, req)
+}
+
+// RequestUserErasure lets an admin file the request for a user of the institution, for example when a
+// parent asks for it.
+func (uc PrivacyUseCase) RequestUserErasure(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	userId int,
+	req core.CreateErasureRequest,
+) (core.ErasureRequestResponse, error) {
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.UserManage, authz.User(userId)); err != nil {
+		return core.ErasureRequestResponse{}, err
+	}
+
+	return uc.requestErasure(ctx, metadata, userId, req)
+}
+
+func (uc PrivacyUseCase) Erasures(ctx context.Context, metadata core.TokenMetadata) ([]core.ErasureRequestResponse, error) {
+	institutionId, err := adminInstitutionId(ctx, uc.authorizer, uc.userService, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	requests, err := uc.privacyService.InstitutionErasures(ctx, institutionId)
+	if err != nil {
+		return nil, err
+	}
+
+	requestsResp := make([]core.ErasureRequestResponse, 0, len(requests))
+
+	for _, request := range requests {
+		requestsResp = append(requestsResp, erasureRequestResponse(request))
+	}
+
+	return requestsResp, nil
+}
+
+// Approve erases the personal data of the user. The seats of an erased student are given to the
+// waitlists. Admins can not approve the erasure of their own data.
+func (uc PrivacyUseCase) Approve(ctx context.Context, metadata core.TokenMetadata, id int) error {
+	request, err := uc.reviewable(ctx, metadata, id)
+	if err != nil {
+		return err
+	}
+
+	// Nobody knows the new password, the account can not be signed in to any more.
+	password, err := utils.RandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	var promoted []core.WaitlistEntry
+
+	if err := uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		request, err := uc.privacyService.ErasureForReview(txCtx, request.Id)
+		if err != nil {
+			return err
+		}
+
+		if err := uc.signinThrottleService.Reset(txCtx, request.Email); err != nil {
+			return err
+		}
+
+		if err := uc.privacyService.Approve(txCtx, request, metadata.UserId, string(hash)); err != nil {
+			return err
+		}
+
+		reviewed := request
+		reviewed.Status = core.ErasureApproved
+		reviewed.ReviewedBy = &metadata.UserId
+
+		if err := uc.auditService.Record(txCtx, metadata, auditUpdate(
+			core.AuditErasure,
+			request.Id,
+			erasureAudit(request),
+			erasureAudit(reviewed),
+		)); err != nil {
+			return err
+		}
+
+		// The audit log is kept forever, so it only tells that the data was erased, not what it was.
+		if err := uc.auditService.Record(txCtx, metadata, auditUpdate(core.AuditUser, request.UserId, nil, map[string]bool{
+			"erased": true,
+		})); err != nil {
+			return err
+		}
+
+		promoted, err = releaseSeats(txCtx, uc.auditService, uc.classroomService, metadata, request.UserId)
+
+		return err
+	}); err != nil {
+		return err
+	}
+
+	notifyReleasedSeats(ctx, uc.logger, uc.classroomService, uc.mailService, promoted)
+
+	return nil
+}
+
+func (uc PrivacyUseCase) Reject(ctx context.Context, metadata core.TokenMetadata, id int) error {
+	request, err := uc.reviewable(ctx, metadata, id)
+	if err != nil {
+		return err
+	}
+
+	return uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		request, err := uc.privacyService.ErasureForReview(txCtx, request.Id)
+		if err != nil {
+			return err
+		}
+
+		if err := uc.privacyService.Reject(txCtx, request, metadata.UserId); err != nil {
+			return err
+		}
+
+		reviewed := request
+		reviewed.Status = core.ErasureRejected
+		reviewed.ReviewedBy = &metadata.UserId
+
+		return uc.auditService.Record(txCtx, metadata, auditUpdate(
+			core.AuditErasure,
+			request.Id,
+			erasureAudit(request),
+			erasureAudit(reviewed),
+		))
+	})
+}
+
+func (uc PrivacyUseCase) requestErasure(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	userId int,
+	req core.CreateErasureRequest,
+) (core.ErasureRequestResponse, error) {
+	var request core.ErasureRequest
+
+	if err := uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+
+		request, err = uc.privacyService.RequestErasure(txCtx, userId, metadata.UserId, req.Reason)
+		if err != nil {
+			return err
+		}
+
+		return uc.auditService.Record(txCtx, metadata, auditCreate(core.AuditErasure, request.Id, erasureAudit(request)))
+	}); err != nil {
+		return core.ErasureRequestResponse{}, err
+	}
+
+	return erasureRequestResponse(request), nil
+}
+
+// reviewable returns the request if the admin may review it. Reviews are not delegated, an erasure
+// can not be undone.
+func (uc PrivacyUseCase) reviewable(ctx context.Context, metadata core.TokenMetadata, id int) (core.ErasureRequest, error) {
+	if delegated(metadata) {
+		return core.ErasureRequest{}, apperrors.AccessDenied
+	}
+
+	request, err := uc.privacyService.ErasureById(ctx, id)
+	if err != nil {
+		return core.ErasureRequest{}, err
+	}
+
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.UserManage, authz.User(request.UserId)); err != nil {
+		return core.ErasureRequest{}, err
+	}
+
+	if request.UserId == metadata.UserId {
+		return core.ErasureRequest{}, apperrors.AccessDenied
+	}
+
+	return request, nil
+}
+
+// erasureAuditState is the audited state of an erasure request. The name and the email are left out,
+// the audit log would keep them after the erasure.
+type erasureAuditState struct {
+	UserId      int                `json:"user_id"`
+	RequestedBy *int               `json:"requested_by"`
+	Status      core.ErasureStatus `json:"status"`
+	ReviewedBy  *int               `json:"reviewed_by,omitempty"`
+}
+
+func erasureAudit(request core.ErasureRequest) erasureAuditState {
+	return erasureAuditState{
+		UserId:      request.UserId,
+		RequestedBy: request.RequestedBy,
+		Status:      request.Status,
+		ReviewedBy:  request.ReviewedBy,
+	}
+}
+
+func erasureRequestResponse(request core.ErasureRequest) core.ErasureRequestResponse {
+	return core.ErasureRequestResponse{
+		Id:          request.Id,
+		UserId:      request.UserId,
+		FullName:    request.FullName,
+		Email:       request.Email,
+		RequestedBy: request.RequestedBy,
+		Reason:      request.Reason,
+		Status:      request.Status,
+		ReviewedBy:  request.ReviewedBy,
+		ReviewedAt:  request.ReviewedAt,
+		CreatedAt:   request.CreatedAt,
+	}
+}
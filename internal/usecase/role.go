@@ -0,0 +1,379 @@
+package usecase
+
+import (
+	"context"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/authz"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"strconv"
+	"unicode/utf8"
+)
+
+// roleNameMaxLength is the limit of the roles.name column.
+const roleNameMaxLength = 50
+
+type RoleService interface {
+	ById(ctx context.Context, id int) (core.Role, error)
+	IsExist(ctx context.Context, institutionId int, name string) (bool, error)
+	ByInstitutionId(ctx context.Context, institutionId int) ([]core.Role, error)
+	Create(ctx context.Context, role core.Role) (core.Role, error)
+	Update(ctx context.Context, role core.Role) (core.Role, error)
+	Delete(ctx context.Context, id int) error
+	Assign(ctx context.Context, classroomId, userId, roleId int) error
+	Unassign(ctx context.Context, classroomId, userId int) error
+	Assignments(ctx context.Context, classroomId int) ([]core.ClassroomRole, error)
+}
+
+type RoleClassroomService interface {
+	ById(ctx context.Context, id int) (core.Classroom, error)
+}
+
+type RoleUseCase struct {
+	authorizer       Authorizer
+	auditService     AuditService
+	roleService      RoleService
+	userService      InstitutionUserService
+	classroomService RoleClassroomService
+}
+
+func NewRoleUseCase(
+	authorizer Authorizer,
+	auditService AuditService,
+	roleService RoleService,
+	userService InstitutionUserService,
+	classroomService RoleClassroomService,
+) *RoleUseCase {
+	return &RoleUseCase{
+		authorizer:       authorizer,
+		auditService:     auditService,
+		roleService:      roleService,
+		userService:      userService,
+		classroomService: classroomService,
+	}
+}
+
+func (uc RoleUseCase) All(ctx context.Context, metadata core.TokenMetadata) ([]core.RoleResponse, error) {
+	institutionId, err := adminInstitutionId(ctx, uc.authorizer, uc.userService, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := uc.roleService.ByInstitutionId(ctx, institutionId)
+	if err != nil {
+		return nil, err
+	}
+
+	rolesResp := make([]core.RoleResponse, 0, len(roles))
+
+	for _, role := range roles {
+		rolesResp = append(rolesResp, roleResponse(role))
+	}
+
+	return rolesResp, nil
+}
+
+// Create defines a new role for the institution of the admin, like a teaching assistant.
+func (uc RoleUseCase) Create(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	req core.RoleRequest,
+) (core.RoleResponse, error) {
+	institutionId, err := adminInstitutionId(ctx, uc.authorizer, uc.userService, metadata)
+	if err != nil {
+		return core.RoleResponse{}, err
+	}
+
+	if err := validateRole(req); err != nil {
+		return core.RoleResponse{}, err
+	}
+
+	exist, err := uc.roleService.IsExist(ctx, institutionId, req.Name)
+	if err != nil {
+		return core.RoleResponse{}, err
+	}
+
+	if exist {
+		return core.RoleResponse{}, apperrors.EntityAlreadyExist
+	}
+
+	role, err := uc.roleService.Create(ctx, core.Role{
+		InstitutionId: institutionId,
+		Name:          req.Name,
+		Permissions:   req.Permissions,
+	})
+	if err != nil {
+		return core.RoleResponse{}, err
+	}
+
+	if err := uc.auditService.Record(ctx, metadata, auditCreate(core.AuditRole, role.Id, roleResponse(role))); err != nil {
+		return core.RoleResponse{}, err
+	}
+
+	return roleResponse(role), nil
+}
+
+func (uc RoleUseCase) Update(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	id int,
+	req core.RoleRequest,
+) (core.RoleResponse, error) {
+	role, err := uc.institutionRole(ctx, metadata, id)
+	if err != nil {
+		return core.RoleResponse{}, err
+	}
+
+	if err := validateRole(req); err != nil {
+		return core.RoleResponse{}, err
+	}
+
+	if req.Name != role.Name {
+		exist, err := uc.roleService.IsExist(ctx, role.InstitutionId, req.Name)
+		if err != nil {
+			return core.RoleResponse{}, err
+		}
+
+		if exist {
+			return core.RoleResponse{}, apperrors.EntityAlreadyExist
+		}
+	}
+
+	updated, err := uc.roleService.Update(ctx, core.Role{
+		Id:          role.Id,
+		Name:        req.Name,
+		Permissions: req.Permissions,
+	})
+	if err != nil {
+		return core.RoleResponse{}, err
+	}
+
+	if err := uc.auditService.Record(
+		ctx,
+		metadata,
+		auditUpdate(core.AuditRole, role.Id, roleResponse(role), roleResponse(updated)),
+	); err != nil {
+		return core.RoleResponse{}, err
+	}
+
+	return roleResponse(updated), nil
+}
+
+// Delete removes the role together with all its assignments.
+func (uc RoleUseCase) Delete(ctx context.Context, metadata core.TokenMetadata, id int) error {
+	role, err := uc.institutionRole(ctx, metadata, id)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.roleService.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	return uc.auditService.Record(ctx, metadata, auditDelete(core.AuditRole, id, roleResponse(role)))
+}
+
+func (uc RoleUseCase) Assignments(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	classroomId int,
+) ([]core.ClassroomRoleResponse, error) {
+	if err := uc.authorizer.Authorize(
+		ctx,
+		metadata,
+		authz.ClassroomRolesManage,
+		authz.Classroom(classroomId),
+	); err != nil {
+		return nil, err
+	}
+
+	assignments, err := uc.roleService.Assignments(ctx, classroomId)
+	if err != nil {
+		return nil, err
+	}
+
+	assignmentsResp := make([]core.ClassroomRoleResponse, 0, len(assignments))
+
+	for _, a := range assignments {
+		assignmentsResp = append(assignmentsResp, core.ClassroomRoleResponse{
+			UserId:   a.UserId,
+			RoleId:   a.RoleId,
+			RoleName: a.RoleName,
+		})
+	}
+
+	return assignmentsResp, nil
+}
+
+// Assign gives a user of the institution a role in the classroom. The role and the user have to
+// belong to the institution of the classroom.
+func (uc RoleUseCase) Assign(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	classroomId int,
+	userId int,
+	req core.AssignClassroomRoleRequest,
+) error {
+	if err := uc.authorizer.Authorize(
+		ctx,
+		metadata,
+		authz.ClassroomRolesManage,
+		authz.Classroom(classroomId),
+	); err != nil {
+		return err
+	}
+
+	classroom, err := uc.classroomService.ById(ctx, classroomId)
+	if err != nil {
+		return err
+	}
+
+	teacher, err := uc.userService.ById(ctx, classroom.TeacherId)
+	if err != nil {
+		return err
+	}
+
+	if teacher.InstitutionId == nil {
+		return apperrors.EntityNotFound
+	}
+
+	role, err := uc.roleService.ById(ctx, req.RoleId)
+	if err != nil {
+		return err
+	}
+
+	if role.InstitutionId != *teacher.InstitutionId {
+		return apperrors.EntityNotFound
+	}
+
+	user, err := uc.userService.ById(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	if user.InstitutionId == nil || *user.InstitutionId != *teacher.InstitutionId {
+		return apperrors.EntityNotFound
+	}
+
+	// Admins already have access to every classroom of the institution.
+	if user.Role == core.AdminRole {
+		return apperrors.AccessDenied
+	}
+
+	current, err := uc.assignment(ctx, classroomId, userId)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.roleService.Assign(ctx, classroomId, userId, role.Id); err != nil {
+		return err
+	}
+
+	after := core.ClassroomRoleResponse{UserId: userId, RoleId: role.Id, RoleName: role.Name}
+
+	event := core.AuditEvent{
+		Action:     core.AuditCreate,
+		EntityType: core.AuditClassroomRole,
+		EntityId:   classroomRoleAuditId(classroomId, userId),
+		After:      after,
+	}
+
+	if current != nil {
+		event.Action = core.AuditUpdate
+		event.Before = *current
+	}
+
+	return uc.auditService.Record(ctx, metadata, event)
+}
+
+func (uc RoleUseCase) Unassign(ctx context.Context, metadata core.TokenMetadata, classroomId int, userId int) error {
+	if err := uc.authorizer.Authorize(
+		ctx,
+		metadata,
+		authz.ClassroomRolesManage,
+		authz.Classroom(classroomId),
+	); err != nil {
+		return err
+	}
+
+	current, err := uc.assignment(ctx, classroomId, userId)
+	if err != nil {
+		return err
+	}
+
+	if current == nil {
+		return apperrors.EntityNotFound
+	}
+
+	if err := uc.roleService.Unassign(ctx, classroomId, userId); err != nil {
+		return err
+	}
+
+	return uc.auditService.Record(ctx, metadata, core.AuditEvent{
+		Action:     core.AuditDelete,
+		EntityType: core.AuditClassroomRole,
+		EntityId:   classroomRoleAuditId(classroomId, userId),
+		Before:     *current,
+	})
+}
+
+// assignment returns the role of the user in the classroom, or nil when the user has none.
+func (uc RoleUseCase) assignment(ctx context.Context, classroomId, userId int) (*core.ClassroomRoleResponse, error) {
+	assignments, err := uc.roleService.Assignments(ctx, classroomId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range assignments {
+		if a.UserId == userId {
+			return &core.ClassroomRoleResponse{UserId: a.UserId, RoleId: a.RoleId, RoleName: a.RoleName}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// institutionRole returns the role if it was defined by the institution of the admin.
+func (uc RoleUseCase) institutionRole(ctx context.Context, metadata core.TokenMetadata, id int) (core.Role, error) {
+	institutionId, err := adminInstitutionId(ctx, uc.authorizer, uc.userService, metadata)
+	if err != nil {
+		return core.Role{}, err
+	}
+
+	role, err := uc.roleService.ById(ctx, id)
+	if err != nil {
+		return core.Role{}, err
+	}
+
+	if role.InstitutionId != institutionId {
+		return core.Role{}, apperrors.EntityNotFound
+	}
+
+	return role, nil
+}
+
+func validateRole(req core.RoleRequest) error {
+	if req.Name == "" || utf8.RuneCountInString(req.Name) > roleNameMaxLength {
+		return apperrors.InvalidRoleName
+	}
+
+	for _, p := range req.Permissions {
+		if !authz.IsClassroomPermission(authz.Permission(p)) {
+			return apperrors.InvalidPermission
+		}
+	}
+
+	return nil
+}
+
+func roleResponse(role core.Role) core.RoleResponse {
+	return core.RoleResponse{
+		Id:          role.Id,
+		Name:        role.Name,
+		Permissions: role.Permissions,
+	}
+}
+
+// classroomRoleAuditId identifies the role of a user in a classroom, like "3:17".
+func classroomRoleAuditId(classroomId, userId int) string {
+	return strconv.Itoa(classroomId) + ":" + strconv.Itoa(userId)
+}
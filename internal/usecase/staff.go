@@ -0,0 +1,275 @@
+package usecase
+
+import (
+	"context"
+	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/authz"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"strconv"
+)
+
+type StaffClassroomService interface {
+	ById(ctx context.Context, id int) (core.Classroom, error)
+	Staff(ctx context.Context, classroomId int) ([]core.ClassroomStaff, error)
+	AddStaff(ctx context.Context, classroomId, userId int, role core.StaffRole) error
+	RemoveStaff(ctx context.Context, classroomId, userId int) error
+	SetOwner(ctx context.Context, classroomId, teacherId int) error
+}
+
+type StaffUseCase struct {
+	authorizer         Authorizer
+	auditService       AuditService
+	transactionService TransactionService
+	classroomService   StaffClassroomService
+	userService        ClassroomUserService
+}
+
+func NewStaffUseCase(
+	authorizer Authorizer,
+	auditService AuditService,
+	transactionService TransactionService,
+	classroomService StaffClassroomService,
+	userService ClassroomUserService,
+) *StaffUseCase {
+	return &StaffUseCase{
+		authorizer:         authorizer,
+		auditService:       auditService,
+		transactionService: transactionService,
+		classroomService:   classroomService,
+		userService:        userService,
+	}
+}
+
+func (uc StaffUseCase) All(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	classroomId int,
+) ([]core.ClassroomStaffResponse, error) {
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.ClassroomView, authz.Classroom(classroomId)); err != nil {
+		return nil, err
+	}
+
+	staff, err := uc.classroomService.Staff(ctx, classroomId)
+	if err != nil {
+		return nil, err
+	}
+
+	staffResp := make([]core.ClassroomStaffResponse, 0, len(staff))
+
+	for _, member := range staff {
+		staffResp = append(staffResp, staffResponse(member))
+	}
+
+	return staffResp, nil
+}
+
+// Add puts a teacher of the institution on the staff of the classroom as a co-teacher or an assistant,
+// or changes the role the teacher has there. The owner can only be changed by transferring ownership.
+func (uc StaffUseCase) Add(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	classroomId int,
+	req core.AddClassroomStaffRequest,
+) (core.ClassroomStaffResponse, error) {
+	if err := uc.authorizer.Authorize(
+		ctx,
+		metadata,
+		authz.ClassroomStaffManage,
+		authz.Classroom(classroomId),
+	); err != nil {
+		return core.ClassroomStaffResponse{}, err
+	}
+
+	if req.Role != core.StaffCoTeacher && req.Role != core.StaffAssistant {
+		return core.ClassroomStaffResponse{}, apperrors.InvalidStaffRole
+	}
+
+	classroom, err := uc.classroomService.ById(ctx, classroomId)
+	if err != nil {
+		return core.ClassroomStaffResponse{}, err
+	}
+
+	if req.UserId == classroom.TeacherId {
+		return core.ClassroomStaffResponse{}, apperrors.InvalidStaffRole
+	}
+
+	teacher, err := uc.institutionTeacher(ctx, classroom, req.UserId)
+	if err != nil {
+		return core.ClassroomStaffResponse{}, err
+	}
+
+	current, err := uc.member(ctx, classroomId, req.UserId)
+	if err != nil {
+		return core.ClassroomStaffResponse{}, err
+	}
+
+	if err := uc.classroomService.AddStaff(ctx, classroomId, teacher.Id, req.Role); err != nil {
+		return core.ClassroomStaffResponse{}, err
+	}
+
+	after := core.ClassroomStaffResponse{
+		UserId:   teacher.Id,
+		FullName: teacher.FullName,
+		Email:    teacher.Email,
+		Role:     req.Role,
+	}
+
+	event := core.AuditEvent{
+		Action:     core.AuditCreate,
+		EntityType: core.AuditClassroomStaff,
+		EntityId:   classroomStaffAuditId(classroomId, teacher.Id),
+		After:      staffAudit{UserId: teacher.Id, Role: req.Role},
+	}
+
+	if current != nil {
+		event.Action = core.AuditUpdate
+		event.Before = staffAudit{UserId: current.UserId, Role: current.Role}
+	}
+
+	if err := uc.auditService.Record(ctx, metadata, event); err != nil {
+		return core.ClassroomStaffResponse{}, err
+	}
+
+	return after, nil
+}
+
+func (uc StaffUseCase) Remove(ctx context.Context, metadata core.TokenMetadata, classroomId int, userId int) error {
+	if err := uc.authorizer.Authorize(
+		ctx,
+		metadata,
+		authz.ClassroomStaffManage,
+		authz.Classroom(classroomId),
+	); err != nil {
+		return err
+	}
+
+	current, err := uc.member(ctx, classroomId, userId)
+	if err != nil {
+		return err
+	}
+
+	if current == nil {
+		return apperrors.EntityNotFound
+	}
+
+	if current.Role == core.StaffOwner {
+		return apperrors.InvalidStaffRole
+	}
+
+	if err := uc.classroomService.RemoveStaff(ctx, classroomId, userId); err != nil {
+		return err
+	}
+
+	return uc.auditService.Record(ctx, metadata, core.AuditEvent{
+		Action:     core.AuditDelete,
+		EntityType: core.AuditClassroomStaff,
+		EntityId:   classroomStaffAuditId(classroomId, userId),
+		Before:     staffAudit{UserId: current.UserId, Role: current.Role},
+	})
+}
+
+// TransferOwnership makes a teacher of the institution the owner of the classroom. The previous owner
+// stays on the staff as a co-teacher.
+func (uc StaffUseCase) TransferOwnership(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	classroomId int,
+	req core.TransferClassroomOwnershipRequest,
+) (core.ClassroomResponse, error) {
+	if err := uc.authorizer.Authorize(
+		ctx,
+		metadata,
+		authz.ClassroomStaffManage,
+		authz.Classroom(classroomId),
+	); err != nil {
+		return core.ClassroomResponse{}, err
+	}
+
+	classroom, err := uc.classroomService.ById(ctx, classroomId)
+	if err != nil {
+		return core.ClassroomResponse{}, err
+	}
+
+	if req.UserId == classroom.TeacherId {
+		return classroomResponse(classroom), nil
+	}
+
+	teacher, err := uc.institutionTeacher(ctx, classroom, req.UserId)
+	if err != nil {
+		return core.ClassroomResponse{}, err
+	}
+
+	updated := classroom
+	updated.TeacherId = teacher.Id
+
+	if err := uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := uc.classroomService.SetOwner(txCtx, classroomId, teacher.Id); err != nil {
+			return err
+		}
+
+		return uc.auditService.Record(
+			txCtx,
+			metadata,
+			auditUpdate(core.AuditClassroom, classroomId, classroomResponse(classroom), classroomResponse(updated)),
+		)
+	}); err != nil {
+		return core.ClassroomResponse{}, err
+	}
+
+	return classroomResponse(updated), nil
+}
+
+// institutionTeacher returns the user if it is a teacher of the institution of the classroom owner.
+func (uc StaffUseCase) institutionTeacher(
+	ctx context.Context,
+	classroom core.Classroom,
+	userId int,
+) (core.User, error) {
+	owner, err := uc.userService.ById(ctx, classroom.TeacherId)
+	if err != nil {
+		return core.User{}, err
+	}
+
+	user, err := uc.userService.ById(ctx, userId)
+	if err != nil {
+		return core.User{}, err
+	}
+
+	if user.Role != core.TeacherRole || owner.InstitutionId == nil || user.InstitutionId == nil ||
+		*owner.InstitutionId != *user.InstitutionId {
+		return core.User{}, apperrors.EntityNotFound
+	}
+
+	return user, nil
+}
+
+// member returns the user on the staff of the classroom, or nil when the user is not on the staff.
+func (uc StaffUseCase) member(ctx context.Context, classroomId, userId int) (*core.ClassroomStaffResponse, error) {
+	staff, err := uc.classroomService.Staff(ctx, classroomId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, member := range staff {
+		if member.UserId == userId {
+			resp := staffResponse(member)
+			return &resp, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func staffResponse(member core.ClassroomStaff) core.ClassroomStaffResponse {
+	return core.ClassroomStaffResponse{
+		UserId:   member.UserId,
+		FullName: member.FullName,
+		Email:    member.Email,
+		Role:     member.Role,
+	}
+}
+
+// classroomStaffAuditId identifies a member of the staff of a classroom, like "3:17".
+func classroomStaffAuditId(classroomId, userId int) string {
+	return strconv.Itoa(classroomId) + ":" + strconv.Itoa(userId)
+}
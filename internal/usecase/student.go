@@ -3,8 +3,11 @@ package usecase
 import (
 	"context"
 	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/authz"
 	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/logger"
 	"golang.org/x/crypto/bcrypt"
+	"sort"
 )
 
 type StudentService interface {
@@ -24,38 +27,67 @@ type StudentUserService interface {
 }
 
 type StudentClassroomService interface {
-	AddStudent(ctx context.Context, studentId int, classroomsId []int) error
+	Enroll(ctx context.Context, classroomId int, studentsId []int) (core.Enrollment, error)
 	ById(ctx context.Context, id int) (core.Classroom, error)
-	Students(ctx context.Context, classroomId int) ([]core.Student, error)
+	Staff(ctx context.Context, classroomId int) ([]core.ClassroomStaff, error)
+	StudentClassrooms(ctx context.Context, studentId int) ([]core.Classroom, error)
+	PromoteWaitlisted(ctx context.Context, classroomId int) ([]core.WaitlistEntry, error)
+	Readmit(ctx context.Context, classroomId, studentId int) (bool, error)
 }
 
 type StudentUseCase struct {
+	logger                  logger.Logger
+	authorizer              Authorizer
+	auditService            AuditService
 	transactionService      TransactionService
 	studentService          StudentService
 	studentTeacherService   StudentTeacherService
 	studentUserService      StudentUserService
 	studentClassroomService StudentClassroomService
+	mailService             WaitlistMailService
+	connectionCloser        ConnectionCloser
 }
 
 func NewStudentsUseCase(
+	logger logger.Logger,
+	authorizer Authorizer,
+	auditService AuditService,
 	transactionService TransactionService,
 	studentService StudentService,
 	studentTeacherService TeacherService,
 	studentUserService StudentUserService,
 	studentClassroomService StudentClassroomService,
+	mailService WaitlistMailService,
+	connectionCloser ConnectionCloser,
 ) *StudentUseCase {
 	return &StudentUseCase{
+		logger:                  logger,
+		authorizer:              authorizer,
+		auditService:            auditService,
 		transactionService:      transactionService,
 		studentService:          studentService,
 		studentTeacherService:   studentTeacherService,
 		studentUserService:      studentUserService,
 		studentClassroomService: studentClassroomService,
+		mailService:             mailService,
+		connectionCloser:        connectionCloser,
 	}
 }
 
-func (uc StudentUseCase) All(ctx context.Context, metadata core.TokenMetadata) ([]core.StudentResponse, error) {
-	switch core.RoleType(metadata.Role) {
-	case core.AdminRole:
+// All lists the students of the institution for admins and the students of their classrooms for teachers.
+// Deactivated and deleted students are left out unless includeInactive is set.
+func (uc StudentUseCase) All(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	includeInactive bool,
+) ([]core.StudentResponse, error) {
+	scope, err := uc.authorizer.Scope(ctx, metadata, authz.StudentList)
+	if err != nil {
+		return nil, err
+	}
+
+	switch scope {
+	case authz.InstitutionScope:
 		admin, err := uc.studentUserService.ById(ctx, metadata.UserId)
 		if err != nil {
 			return nil, err
@@ -69,17 +101,23 @@ func (uc StudentUseCase) All(ctx context.Context, metadata core.TokenMetadata) (
 		studentsResponse := make([]core.StudentResponse, 0, len(students))
 
 		for _, student := range students {
+			if !includeInactive && !student.Active() {
+				continue
+			}
+
 			studentsResponse = append(studentsResponse, core.StudentResponse{
-				Id:           student.Id,
-				FullName:     student.FullName,
-				Phone:        student.Phone,
-				Email:        student.Email,
-				ClassroomsId: nil,
+				Id:            student.Id,
+				FullName:      student.FullName,
+				Phone:         student.Phone,
+				Email:         student.Email,
+				ClassroomsId:  nil,
+				DeactivatedAt: student.DeactivatedAt,
+				DeletedAt:     student.DeletedAt,
 			})
 		}
 
 		return studentsResponse, nil
-	case core.TeacherRole:
+	case authz.TaughtScope:
 		students, err := uc.studentTeacherService.Students(ctx, metadata.UserId)
 		if err != nil {
 			return nil, err
@@ -88,18 +126,22 @@ func (uc StudentUseCase) All(ctx context.Context, metadata core.TokenMetadata) (
 		studentsResponse := make([]core.StudentResponse, 0, len(students))
 
 		for _, student := range students {
+			if !includeInactive && !student.Active() {
+				continue
+			}
+
 			studentsResponse = append(studentsResponse, core.StudentResponse{
-				Id:           student.Id,
-				FullName:     student.FullName,
-				Phone:        student.Phone,
-				Email:        student.Email,
-				ClassroomsId: student.ClassroomsId,
+				Id:            student.Id,
+				FullName:      student.FullName,
+				Phone:         student.Phone,
+				Email:         student.Email,
+				ClassroomsId:  student.ClassroomsId,
+				DeactivatedAt: student.DeactivatedAt,
+				DeletedAt:     student.DeletedAt,
 			})
 		}
 
 		return studentsResponse, nil
-	case core.StudentRole:
-		return nil, apperrors.AccessDenied
 	}
 
 	return nil, apperrors.AccessDenied
@@ -110,8 +152,19 @@ func (uc StudentUseCase) Create(
 	metadata core.TokenMetadata,
 	req core.CreateStudentRequest,
 ) (core.StudentResponse, error) {
-	if core.RoleType(metadata.Role) == core.StudentRole {
-		return core.StudentResponse{}, apperrors.AccessDenied
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.StudentCreate, authz.Any); err != nil {
+		return core.StudentResponse{}, err
+	}
+
+	for _, classroomId := range req.ClassroomsId {
+		if err := uc.authorizer.Authorize(
+			ctx,
+			metadata,
+			authz.ClassroomStudentsManage,
+			authz.Classroom(classroomId),
+		); err != nil {
+			return core.StudentResponse{}, err
+		}
 	}
 
 	exist, err := uc.studentUserService.IsExist(ctx, req.Email)
@@ -123,22 +176,6 @@ func (uc StudentUseCase) Create(
 		return core.StudentResponse{}, apperrors.EntityAlreadyExist
 	}
 
-	for _, classroomId := range req.ClassroomsId {
-		classroom, err := uc.studentClassroomService.ById(ctx, classroomId)
-		if err != nil {
-			return core.StudentResponse{}, err
-		}
-
-		students, err := uc.studentTeacherService.Students(ctx, metadata.UserId)
-		if err != nil {
-			return core.StudentResponse{}, err
-		}
-
-		if len(students)+1 > classroom.MaxStudents {
-			return core.StudentResponse{}, apperrors.NumberOfStudentsExceeded
-		}
-	}
-
 	user, err := uc.studentUserService.ById(ctx, metadata.UserId)
 	if err != nil {
 		return core.StudentResponse{}, err
@@ -164,11 +201,20 @@ func (uc StudentUseCase) Create(
 			return err
 		}
 
-		if err := uc.studentClassroomService.AddStudent(txCtx, student.Id, req.ClassroomsId); err != nil {
-			return err
+		// The classrooms are locked in the same order by every request, so they cannot deadlock.
+		classroomsId := append([]int(nil), req.ClassroomsId...)
+		sort.Ints(classroomsId)
+
+		for _, classroomId := range classroomsId {
+			if _, err := uc.studentClassroomService.Enroll(txCtx, classroomId, []int{student.Id}); err != nil {
+				return err
+			}
 		}
 
-		return nil
+		return uc.auditService.Record(txCtx, metadata, auditCreate(core.AuditStudent, student.Id, userAudit{
+			Id:           student.Id,
+			ClassroomsId: req.ClassroomsId,
+		}))
 	}); err != nil {
 		return core.StudentResponse{}, err
 	}
@@ -182,17 +228,46 @@ func (uc StudentUseCase) Create(
 	}, nil
 }
 
+// Delete marks the student account as deleted, an admin can restore it with a reactivation. The
+// enrollments are kept with the rest of the history, but the seats are given to the waitlists.
 func (uc StudentUseCase) Delete(ctx context.Context, metadata core.TokenMetadata, id int) error {
-	switch core.RoleType(metadata.Role) {
-	case core.AdminRole:
-		return apperrors.AccessDenied
-	case core.TeacherRole:
-		if err := uc.studentUserService.Delete(ctx, id); err != nil {
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.StudentDelete, authz.User(id)); err != nil {
+		return err
+	}
+
+	student, err := uc.studentUserService.ById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if student.Role != core.StudentRole {
+		return apperrors.EntityNotFound
+	}
+
+	var promoted []core.WaitlistEntry
+
+	if err := uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := uc.studentUserService.Delete(txCtx, id); err != nil {
 			return err
 		}
-	case core.StudentRole:
-		return apperrors.AccessDenied
+
+		if err := uc.auditService.Record(
+			txCtx,
+			metadata,
+			auditDelete(core.AuditStudent, id, userAudit{Id: student.Id}),
+		); err != nil {
+			return err
+		}
+
+		promoted, err = releaseSeats(txCtx, uc.auditService, uc.studentClassroomService, metadata, id)
+
+		return err
+	}); err != nil {
+		return err
 	}
 
+	uc.connectionCloser.CloseUser(id)
+	notifyReleasedSeats(ctx, uc.logger, uc.studentClassroomService, uc.mailService, promoted)
+
 	return nil
 }
@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/authz"
 	"github.com/migmatore/study-platform-api/internal/core"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -22,17 +23,38 @@ type TeacherUserService interface {
 }
 
 type TeacherUseCase struct {
-	teacherService TeacherService
-	userService    TeacherUserService
+	authorizer       Authorizer
+	auditService     AuditService
+	teacherService   TeacherService
+	userService      TeacherUserService
+	connectionCloser ConnectionCloser
 }
 
-func NewTeacherUseCase(teacherService TeacherService, userService TeacherUserService) *TeacherUseCase {
-	return &TeacherUseCase{teacherService: teacherService, userService: userService}
+func NewTeacherUseCase(
+	authorizer Authorizer,
+	auditService AuditService,
+	teacherService TeacherService,
+	userService TeacherUserService,
+	connectionCloser ConnectionCloser,
+) *TeacherUseCase {
+	return &TeacherUseCase{
+		authorizer:       authorizer,
+		auditService:     auditService,
+		teacherService:   teacherService,
+		userService:      userService,
+		connectionCloser: connectionCloser,
+	}
 }
 
-func (uc TeacherUseCase) All(ctx context.Context, metadata core.TokenMetadata) ([]core.TeacherResponse, error) {
-	if core.RoleType(metadata.Role) != core.AdminRole {
-		return nil, apperrors.AccessDenied
+// All lists the teachers of the institution. Deactivated and deleted teachers are left out unless
+// includeInactive is set.
+func (uc TeacherUseCase) All(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	includeInactive bool,
+) ([]core.TeacherResponse, error) {
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.TeacherList, authz.Any); err != nil {
+		return nil, err
 	}
 
 	admin, err := uc.userService.ById(ctx, metadata.UserId)
@@ -48,11 +70,17 @@ func (uc TeacherUseCase) All(ctx context.Context, metadata core.TokenMetadata) (
 	teachersResp := make([]core.TeacherResponse, 0, len(teachers))
 
 	for _, teacher := range teachers {
+		if !includeInactive && !teacher.Active() {
+			continue
+		}
+
 		teachersResp = append(teachersResp, core.TeacherResponse{
-			Id:       teacher.Id,
-			FullName: teacher.FullName,
-			Phone:    teacher.Phone,
-			Email:    teacher.Email,
+			Id:            teacher.Id,
+			FullName:      teacher.FullName,
+			Phone:         teacher.Phone,
+			Email:         teacher.Email,
+			DeactivatedAt: teacher.DeactivatedAt,
+			DeletedAt:     teacher.DeletedAt,
 		})
 	}
 
@@ -64,8 +92,8 @@ func (uc TeacherUseCase) Create(
 	metadata core.TokenMetadata,
 	req core.CreateTeacherRequest,
 ) (core.TeacherResponse, error) {
-	if core.RoleType(metadata.Role) != core.AdminRole {
-		return core.TeacherResponse{}, apperrors.AccessDenied
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.TeacherCreate, authz.Any); err != nil {
+		return core.TeacherResponse{}, err
 	}
 
 	exist, err := uc.userService.IsExist(ctx, req.Email)
@@ -99,22 +127,53 @@ func (uc TeacherUseCase) Create(
 		return core.TeacherResponse{}, err
 	}
 
-	return core.TeacherResponse{
-		Id:       newTeacher.Id,
-		FullName: newTeacher.FullName,
-		Phone:    newTeacher.Phone,
-		Email:    newTeacher.Email,
-	}, nil
+	teacherResp := teacherResponse(newTeacher)
+
+	if err := uc.auditService.Record(
+		ctx,
+		metadata,
+		auditCreate(core.AuditTeacher, newTeacher.Id, userAudit{Id: newTeacher.Id}),
+	); err != nil {
+		return core.TeacherResponse{}, err
+	}
+
+	return teacherResp, nil
 }
 
+// Delete marks the teacher account as deleted, an admin can restore it with a reactivation. The
+// classrooms of the teacher are kept.
 func (uc TeacherUseCase) Delete(ctx context.Context, metadata core.TokenMetadata, id int) error {
-	if core.RoleType(metadata.Role) != core.AdminRole {
-		return apperrors.AccessDenied
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.TeacherDelete, authz.User(id)); err != nil {
+		return err
+	}
+
+	teacher, err := uc.userService.ById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if teacher.Role != core.TeacherRole {
+		return apperrors.EntityNotFound
 	}
 
 	if err := uc.userService.Delete(ctx, id); err != nil {
 		return err
 	}
 
+	if err := uc.auditService.Record(ctx, metadata, auditDelete(core.AuditTeacher, id, userAudit{Id: teacher.Id})); err != nil {
+		return err
+	}
+
+	uc.connectionCloser.CloseUser(id)
+
 	return nil
 }
+
+func teacherResponse(teacher core.User) core.TeacherResponse {
+	return core.TeacherResponse{
+		Id:       teacher.Id,
+		FullName: teacher.FullName,
+		Phone:    teacher.Phone,
+		Email:    teacher.Email,
+	}
+}
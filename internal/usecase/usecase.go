@@ -1,43 +1,209 @@
 package usecase
 
+import (
+	"context"
+	"github.com/migmatore/study-platform-api/config"
+	"github.com/migmatore/study-platform-api/internal/authz"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/logger"
+)
+
+type Authorizer interface {
+	Authorize(ctx context.Context, subject core.TokenMetadata, permission authz.Permission, resource authz.Resource) error
+	Scope(ctx context.Context, subject core.TokenMetadata, permission authz.Permission) (authz.Scope, error)
+}
+
+// ConnectionCloser closes the open websocket connections of a user.
+type ConnectionCloser interface {
+	CloseUser(userId int)
+}
+
 type Deps struct {
-	TransactionService TransactionService
-	UserService        UserService
-	InstitutionService InstitutionService
-	TokenService       TokenService
-	TeacherService     TeacherService
-	StudentService     StudentService
-	LessonService      LessonService
-	ClassroomService   ClassroomService
+	Logger                   logger.Logger
+	Authorizer               Authorizer
+	TransactionService       TransactionService
+	UserService              UserService
+	InstitutionService       InstitutionService
+	TokenService             TokenService
+	RefreshTokenService      RefreshTokenService
+	SessionService           SessionService
+	PasswordResetService     PasswordResetService
+	EmailVerificationService EmailVerificationService
+	MFAService               MFAService
+	SigninThrottleService    SigninThrottleService
+	OIDCService              OIDCService
+	InvitationService        InvitationService
+	APIKeyService            APIKeyService
+	ImpersonationService     ImpersonationService
+	AuditService             AuditService
+	RoleService              RoleService
+	MailService              MailService
+	ImportService            ImportFileService
+	ExportService            ExportService
+	PrivacyService           PrivacyService
+	TeacherService           TeacherService
+	StudentService           StudentService
+	LessonService            LessonService
+	ClassroomService         ClassroomService
+	ConnectionCloser         ConnectionCloser
 }
 
 type UseCase struct {
-	Auth      *AuthUseCase
-	User      *UserUseCase
-	Classroom *ClassroomUseCase
-	Lesson    *LessonUseCase
-	Student   *StudentUseCase
-	Teacher   *TeacherUseCase
+	Auth          *AuthUseCase
+	User          *UserUseCase
+	MFA           *MFAUseCase
+	Institution   *InstitutionUseCase
+	Invitation    *InvitationUseCase
+	APIKey        *APIKeyUseCase
+	Impersonation *ImpersonationUseCase
+	Role          *RoleUseCase
+	Audit         *AuditUseCase
+	Classroom     *ClassroomUseCase
+	Waitlist      *WaitlistUseCase
+	Staff         *StaffUseCase
+	Lesson        *LessonUseCase
+	Student       *StudentUseCase
+	Teacher       *TeacherUseCase
+	Import        *ImportUseCase
+	Export        *ExportUseCase
+	Privacy       *PrivacyUseCase
 }
 
-func New(deps Deps) *UseCase {
+func New(config *config.Config, deps Deps) *UseCase {
 	return &UseCase{
 		Auth: NewAuthUseCase(
+			config,
+			deps.AuditService,
 			deps.TransactionService,
 			deps.UserService,
 			deps.InstitutionService,
 			deps.TokenService,
+			deps.RefreshTokenService,
+			deps.SessionService,
+			deps.PasswordResetService,
+			deps.EmailVerificationService,
+			deps.MFAService,
+			deps.SigninThrottleService,
+			deps.OIDCService,
+			deps.InvitationService,
+			deps.ClassroomService,
+			deps.MailService,
+		),
+		User: NewUserUseCase(
+			deps.Logger,
+			deps.Authorizer,
+			deps.AuditService,
+			deps.TransactionService,
+			deps.UserService,
+			deps.SessionService,
+			deps.EmailVerificationService,
+			deps.MFAService,
+			deps.SigninThrottleService,
+			deps.ClassroomService,
+			deps.MailService,
+			deps.ConnectionCloser,
+		),
+		MFA: NewMFAUseCase(
+			deps.Authorizer,
+			deps.AuditService,
+			deps.TransactionService,
+			deps.UserService,
+			deps.MFAService,
 		),
-		User:      NewUserUseCase(deps.UserService),
-		Classroom: NewClassroomUseCase(deps.ClassroomService, deps.TeacherService, deps.StudentService),
-		Lesson:    NewLessonUseCase(deps.LessonService, deps.ClassroomService, deps.TeacherService),
+		Institution: NewInstitutionUseCase(deps.Authorizer, deps.AuditService, deps.UserService, deps.OIDCService),
+		Invitation: NewInvitationUseCase(
+			deps.Authorizer,
+			deps.AuditService,
+			deps.InvitationService,
+			deps.ClassroomService,
+			deps.UserService,
+			deps.MailService,
+		),
+		APIKey: NewAPIKeyUseCase(deps.Authorizer, deps.AuditService, deps.APIKeyService, deps.UserService),
+		Impersonation: NewImpersonationUseCase(
+			deps.Authorizer,
+			deps.TokenService,
+			deps.UserService,
+			deps.ImpersonationService,
+		),
+		Role: NewRoleUseCase(
+			deps.Authorizer,
+			deps.AuditService,
+			deps.RoleService,
+			deps.UserService,
+			deps.ClassroomService,
+		),
+		Audit: NewAuditUseCase(deps.Authorizer, deps.AuditService, deps.UserService),
+		Classroom: NewClassroomUseCase(
+			deps.Logger,
+			deps.Authorizer,
+			deps.AuditService,
+			deps.TransactionService,
+			deps.ClassroomService,
+			deps.LessonService,
+			deps.TeacherService,
+			deps.StudentService,
+			deps.UserService,
+			deps.MailService,
+		),
+		Waitlist: NewWaitlistUseCase(
+			deps.Authorizer,
+			deps.AuditService,
+			deps.TransactionService,
+			deps.ClassroomService,
+		),
+		Staff: NewStaffUseCase(
+			deps.Authorizer,
+			deps.AuditService,
+			deps.TransactionService,
+			deps.ClassroomService,
+			deps.UserService,
+		),
+		Lesson: NewLessonUseCase(deps.Authorizer, deps.AuditService, deps.LessonService),
 		Student: NewStudentsUseCase(
+			deps.Logger,
+			deps.Authorizer,
+			deps.AuditService,
 			deps.TransactionService,
 			deps.StudentService,
 			deps.TeacherService,
 			deps.UserService,
 			deps.ClassroomService,
+			deps.MailService,
+			deps.ConnectionCloser,
+		),
+		Teacher: NewTeacherUseCase(
+			deps.Authorizer,
+			deps.AuditService,
+			deps.TeacherService,
+			deps.UserService,
+			deps.ConnectionCloser,
+		),
+		Import: NewImportUseCase(
+			deps.Authorizer,
+			deps.AuditService,
+			deps.TransactionService,
+			deps.ImportService,
+			deps.UserService,
+			deps.ClassroomService,
+		),
+		Export: NewExportUseCase(
+			deps.Authorizer,
+			deps.AuditService,
+			deps.TransactionService,
+			deps.ExportService,
+			deps.UserService,
+		),
+		Privacy: NewPrivacyUseCase(
+			deps.Logger,
+			deps.Authorizer,
+			deps.AuditService,
+			deps.TransactionService,
+			deps.PrivacyService,
+			deps.UserService,
+			deps.SigninThrottleService,
+			deps.ClassroomService,
+			deps.MailService,
 		),
-		Teacher: NewTeacherUseCase(deps.TeacherService, deps.UserService),
 	}
 }
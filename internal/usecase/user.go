@@ -3,8 +3,11 @@ package usecase
 import (
 	"context"
 	"github.com/migmatore/study-platform-api/internal/apperrors"
+	"github.com/migmatore/study-platform-api/internal/authz"
 	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/logger"
 	"golang.org/x/crypto/bcrypt"
+	"time"
 )
 
 type UserService interface {
@@ -14,15 +17,88 @@ type UserService interface {
 	ById(ctx context.Context, id int) (core.User, error)
 	Create(ctx context.Context, user core.User) (core.User, error)
 	UpdateProfile(ctx context.Context, userId int, profile core.UpdateUserProfile) (core.UserProfile, error)
+	VerifyEmail(ctx context.Context, userId int, email string) error
 	Delete(ctx context.Context, id int) error
+	Deactivate(ctx context.Context, id int) error
+	Reactivate(ctx context.Context, id int) error
+}
+
+type SessionService interface {
+	Create(ctx context.Context, session core.Session) error
+	ById(ctx context.Context, id string) (core.Session, error)
+	ActiveByUserId(ctx context.Context, userId int) ([]core.Session, error)
+	Touch(ctx context.Context, session core.Session) error
+	Revoke(ctx context.Context, id string) error
+	RevokeByUserId(ctx context.Context, userId int) error
+}
+
+type UserSessionService interface {
+	ById(ctx context.Context, id string) (core.Session, error)
+	ActiveByUserId(ctx context.Context, userId int) ([]core.Session, error)
+	Revoke(ctx context.Context, id string) error
+	RevokeByUserId(ctx context.Context, userId int) error
+}
+
+type UserEmailVerificationService interface {
+	Create(ctx context.Context, userId int, email string) (string, error)
+}
+
+type UserMFAService interface {
+	Disable(ctx context.Context, userId int) error
+}
+
+type UserSigninThrottleService interface {
+	Reset(ctx context.Context, email string) error
+}
+
+type UserMailService interface {
+	SendEmailVerification(ctx context.Context, to string, fullName string, token string) error
+	SendWaitlistPromotion(ctx context.Context, to []string, classroomTitle string, studentsName []string) error
 }
 
 type UserUseCase struct {
-	userService UserService
+	logger                   logger.Logger
+	authorizer               Authorizer
+	auditService             AuditService
+	transactionService       TransactionService
+	userService              UserService
+	sessionService           UserSessionService
+	emailVerificationService UserEmailVerificationService
+	mfaService               UserMFAService
+	signinThrottleService    UserSigninThrottleService
+	classroomService         SeatClassroomService
+	mailService              UserMailService
+	connectionCloser         ConnectionCloser
 }
 
-func NewUserUseCase(userService UserService) *UserUseCase {
-	return &UserUseCase{userService: userService}
+func NewUserUseCase(
+	logger logger.Logger,
+	authorizer Authorizer,
+	auditService AuditService,
+	transactionService TransactionService,
+	userService UserService,
+	sessionService UserSessionService,
+	emailVerificationService UserEmailVerificationService,
+	mfaService UserMFAService,
+	signinThrottleService UserSigninThrottleService,
+	classroomService SeatClassroomService,
+	mailService UserMailService,
+	connectionCloser ConnectionCloser,
+) *UserUseCase {
+	return &UserUseCase{
+		logger:                   logger,
+		authorizer:               authorizer,
+		auditService:             auditService,
+		transactionService:       transactionService,
+		userService:              userService,
+		sessionService:           sessionService,
+		emailVerificationService: emailVerificationService,
+		mfaService:               mfaService,
+		signinThrottleService:    signinThrottleService,
+		classroomService:         classroomService,
+		mailService:              mailService,
+		connectionCloser:         connectionCloser,
+	}
 }
 
 func (uc UserUseCase) Profile(ctx context.Context, metadata core.TokenMetadata) (core.ProfileResponse, error) {
@@ -32,9 +108,10 @@ func (uc UserUseCase) Profile(ctx context.Context, metadata core.TokenMetadata)
 	}
 
 	return core.ProfileResponse{
-		FullName: user.FullName,
-		Phone:    user.Phone,
-		Email:    user.Email,
+		FullName:      user.FullName,
+		Phone:         user.Phone,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
 	}, nil
 }
 
@@ -44,8 +121,21 @@ func (uc UserUseCase) UpdateProfile(
 	metadata core.TokenMetadata,
 	req core.UpdateProfileRequest,
 ) (core.ProfileResponse, error) {
+	// Whoever acts on behalf of the user must not be able to take the account over.
+	if delegated(metadata) && (req.Email != nil || req.Password != nil) {
+		return core.ProfileResponse{}, apperrors.AccessDenied
+	}
+
+	before, err := uc.userService.ById(ctx, metadata.UserId)
+	if err != nil {
+		return core.ProfileResponse{}, err
+	}
+
 	var newProfile core.UpdateUserProfile
 
+	// The new email is applied only after it is confirmed through the link sent to it.
+	var pendingEmail *string
+
 	if req.Email != nil && *req.Email != "" {
 		isExist, err := uc.userService.IsExist(ctx, *req.Email)
 		if err != nil {
@@ -56,7 +146,7 @@ func (uc UserUseCase) UpdateProfile(
 			return core.ProfileResponse{}, apperrors.EntityAlreadyExist
 		}
 
-		newProfile.Email = req.Email
+		pendingEmail = req.Email
 	}
 
 	if req.Password != nil && *req.Password != "" {
@@ -78,14 +168,284 @@ func (uc UserUseCase) UpdateProfile(
 		newProfile.Phone = req.Phone
 	}
 
-	updatedProfile, err := uc.userService.UpdateProfile(ctx, metadata.UserId, newProfile)
+	if newProfile.FullName != nil || newProfile.Phone != nil || newProfile.Password != nil {
+		if _, err := uc.userService.UpdateProfile(ctx, metadata.UserId, newProfile); err != nil {
+			return core.ProfileResponse{}, err
+		}
+	}
+
+	user, err := uc.userService.ById(ctx, metadata.UserId)
 	if err != nil {
 		return core.ProfileResponse{}, err
 	}
 
-	return core.ProfileResponse{
-		FullName: updatedProfile.FullName,
-		Phone:    updatedProfile.Phone,
-		Email:    updatedProfile.Email,
-	}, nil
+	if pendingEmail != nil {
+		token, err := uc.emailVerificationService.Create(ctx, user.Id, *pendingEmail)
+		if err != nil {
+			return core.ProfileResponse{}, err
+		}
+
+		if err := uc.mailService.SendEmailVerification(ctx, *pendingEmail, user.FullName, token); err != nil {
+			return core.ProfileResponse{}, err
+		}
+	}
+
+	profileResp := core.ProfileResponse{
+		FullName:      user.FullName,
+		Phone:         user.Phone,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		PendingEmail:  pendingEmail,
+	}
+
+	if err := uc.auditService.Record(ctx, metadata, auditUpdate(core.AuditUser, user.Id, nil, profileAudit{
+		FullNameChanged:      newProfile.FullName != nil && *newProfile.FullName != before.FullName,
+		PhoneChanged:         newProfile.Phone != nil && (before.Phone == nil || *newProfile.Phone != *before.Phone),
+		EmailChangeRequested: pendingEmail != nil,
+		PasswordChanged:      newProfile.Password != nil,
+	})); err != nil {
+		return core.ProfileResponse{}, err
+	}
+
+	return profileResp, nil
+}
+
+func (uc UserUseCase) Sessions(ctx context.Context, metadata core.TokenMetadata) ([]core.SessionResponse, error) {
+	sessions, err := uc.sessionService.ActiveByUserId(ctx, metadata.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionsResp := make([]core.SessionResponse, 0, len(sessions))
+
+	for _, session := range sessions {
+		sessionsResp = append(sessionsResp, core.SessionResponse{
+			Id:         session.Id,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			Current:    session.Id == metadata.SessionId,
+		})
+	}
+
+	return sessionsResp, nil
+}
+
+func (uc UserUseCase) RevokeSession(ctx context.Context, metadata core.TokenMetadata, sessionId string) error {
+	session, err := uc.sessionService.ById(ctx, sessionId)
+	if err != nil {
+		return err
+	}
+
+	if session.UserId != metadata.UserId {
+		return apperrors.EntityNotFound
+	}
+
+	if err := uc.sessionService.Revoke(ctx, sessionId); err != nil {
+		return err
+	}
+
+	return uc.auditService.Record(ctx, metadata, sessionAudit(session))
+}
+
+func (uc UserUseCase) UserSessions(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	userId int,
+) ([]core.SessionResponse, error) {
+	if err := uc.checkSameInstitution(ctx, metadata, userId); err != nil {
+		return nil, err
+	}
+
+	sessions, err := uc.sessionService.ActiveByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionsResp := make([]core.SessionResponse, 0, len(sessions))
+
+	for _, session := range sessions {
+		sessionsResp = append(sessionsResp, core.SessionResponse{
+			Id:         session.Id,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			Current:    session.Id == metadata.SessionId,
+		})
+	}
+
+	return sessionsResp, nil
+}
+
+func (uc UserUseCase) RevokeUserSession(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	userId int,
+	sessionId string,
+) error {
+	if err := uc.checkSameInstitution(ctx, metadata, userId); err != nil {
+		return err
+	}
+
+	session, err := uc.sessionService.ById(ctx, sessionId)
+	if err != nil {
+		return err
+	}
+
+	if session.UserId != userId {
+		return apperrors.EntityNotFound
+	}
+
+	if err := uc.sessionService.Revoke(ctx, sessionId); err != nil {
+		return err
+	}
+
+	return uc.auditService.Record(ctx, metadata, sessionAudit(session))
+}
+
+// ResetUserMFA turns off the second factor of a user who lost access to the authenticator app
+// and the recovery codes.
+func (uc UserUseCase) ResetUserMFA(ctx context.Context, metadata core.TokenMetadata, userId int) error {
+	if err := uc.checkSameInstitution(ctx, metadata, userId); err != nil {
+		return err
+	}
+
+	if err := uc.mfaService.Disable(ctx, userId); err != nil {
+		return err
+	}
+
+	return uc.auditService.Record(ctx, metadata, auditUpdate(core.AuditUser, userId, nil, map[string]bool{
+		"mfa_enabled": false,
+	}))
+}
+
+// UnlockUser clears the failed sign in attempts of the user, lifting the lockout.
+func (uc UserUseCase) UnlockUser(ctx context.Context, metadata core.TokenMetadata, userId int) error {
+	if err := uc.checkSameInstitution(ctx, metadata, userId); err != nil {
+		return err
+	}
+
+	user, err := uc.userService.ById(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.signinThrottleService.Reset(ctx, user.Email); err != nil {
+		return err
+	}
+
+	return uc.auditService.Record(ctx, metadata, auditUpdate(core.AuditUser, userId, nil, map[string]bool{
+		"locked": false,
+	}))
+}
+
+// Deactivate suspends the user until an admin reactivates the user. The sessions of the user are
+// revoked and the open websocket connections are closed. The seats of a student are given to the
+// waitlists. Teachers can deactivate their students, users can not deactivate themselves.
+func (uc UserUseCase) Deactivate(ctx context.Context, metadata core.TokenMetadata, userId int) error {
+	if userId == metadata.UserId {
+		return apperrors.AccessDenied
+	}
+
+	if err := uc.authorizer.Authorize(ctx, metadata, authz.UserDeactivate, authz.User(userId)); err != nil {
+		return err
+	}
+
+	var promoted []core.WaitlistEntry
+
+	if err := uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := uc.userService.Deactivate(txCtx, userId); err != nil {
+			return err
+		}
+
+		if err := uc.sessionService.RevokeByUserId(txCtx, userId); err != nil {
+			return err
+		}
+
+		if err := uc.auditService.Record(txCtx, metadata, auditUpdate(core.AuditUser, userId, nil, map[string]bool{
+			"deactivated": true,
+		})); err != nil {
+			return err
+		}
+
+		var err error
+		promoted, err = releaseSeats(txCtx, uc.auditService, uc.classroomService, metadata, userId)
+
+		return err
+	}); err != nil {
+		return err
+	}
+
+	uc.connectionCloser.CloseUser(userId)
+	notifyReleasedSeats(ctx, uc.logger, uc.classroomService, uc.mailService, promoted)
+
+	return nil
+}
+
+// Reactivate lets a deactivated or deleted user sign in again. A student gets the seats back, or is put
+// on the waitlists of the classrooms which were filled meanwhile.
+func (uc UserUseCase) Reactivate(ctx context.Context, metadata core.TokenMetadata, userId int) error {
+	if err := uc.checkSameInstitution(ctx, metadata, userId); err != nil {
+		return err
+	}
+
+	user, err := uc.userService.ById(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	return uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := uc.userService.Reactivate(txCtx, userId); err != nil {
+			return err
+		}
+
+		if err := uc.auditService.Record(txCtx, metadata, auditUpdate(core.AuditUser, userId, map[string]bool{
+			"deactivated": user.DeactivatedAt != nil,
+			"deleted":     user.DeletedAt != nil,
+		}, map[string]bool{
+			"deactivated": false,
+			"deleted":     false,
+		})); err != nil {
+			return err
+		}
+
+		return reclaimSeats(txCtx, uc.auditService, uc.classroomService, metadata, userId)
+	})
+}
+
+// checkSameInstitution allows only admins to manage users of their own institution.
+func (uc UserUseCase) checkSameInstitution(ctx context.Context, metadata core.TokenMetadata, userId int) error {
+	return uc.authorizer.Authorize(ctx, metadata, authz.UserManage, authz.User(userId))
+}
+
+// profileAudit tells which parts of a profile were changed. The values are personal data and are
+// not recorded, the password is never recorded anyway.
+type profileAudit struct {
+	FullNameChanged      bool `json:"full_name_changed,omitempty"`
+	PhoneChanged         bool `json:"phone_changed,omitempty"`
+	EmailChangeRequested bool `json:"email_change_requested,omitempty"`
+	PasswordChanged      bool `json:"password_changed,omitempty"`
+}
+
+// sessionState is the audited state of a revoked session. The IP address and the user agent are
+// personal data and are not recorded.
+type sessionState struct {
+	Id         string    `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+func sessionAudit(session core.Session) core.AuditEvent {
+	return core.AuditEvent{
+		Action:     core.AuditDelete,
+		EntityType: core.AuditSession,
+		EntityId:   session.Id,
+		Before: sessionState{
+			Id:         session.Id,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+		},
+	}
 }
@@ -0,0 +1,383 @@
+package usecase
+
+import (
+	"context"
+	"github.com/migmatore/study-platform-api/internal/authz"
+	"github.com/migmatore/study-platform-api/internal/core"
+	"github.com/migmatore/study-platform-api/pkg/logger"
+)
+
+type WaitlistClassroomService interface {
+	Waitlist(ctx context.Context, classroomId int) ([]core.WaitlistEntry, error)
+	ReorderWaitlist(ctx context.Context, classroomId int, studentsId []int) error
+	Promote(ctx context.Context, classroomId, studentId int) (core.WaitlistEntry, error)
+}
+
+// PromotionClassroomService fills the seats that opened up in a classroom from its waitlist.
+type PromotionClassroomService interface {
+	ById(ctx context.Context, id int) (core.Classroom, error)
+	Staff(ctx context.Context, classroomId int) ([]core.ClassroomStaff, error)
+	PromoteWaitlisted(ctx context.Context, classroomId int) ([]core.WaitlistEntry, error)
+}
+
+// SeatClassroomService gives the seats of students who are deactivated, deleted or erased to the
+// waitlists and takes them back when the students are reactivated.
+type SeatClassroomService interface {
+	ById(ctx context.Context, id int) (core.Classroom, error)
+	Staff(ctx context.Context, classroomId int) ([]core.ClassroomStaff, error)
+	StudentClassrooms(ctx context.Context, studentId int) ([]core.Classroom, error)
+	PromoteWaitlisted(ctx context.Context, classroomId int) ([]core.WaitlistEntry, error)
+	Readmit(ctx context.Context, classroomId, studentId int) (bool, error)
+}
+
+type WaitlistMailService interface {
+	SendWaitlistPromotion(ctx context.Context, to []string, classroomTitle string, studentsName []string) error
+}
+
+type WaitlistUseCase struct {
+	authorizer         Authorizer
+	auditService       AuditService
+	transactionService TransactionService
+	classroomService   WaitlistClassroomService
+}
+
+func NewWaitlistUseCase(
+	authorizer Authorizer,
+	auditService AuditService,
+	transactionService TransactionService,
+	classroomService WaitlistClassroomService,
+) *WaitlistUseCase {
+	return &WaitlistUseCase{
+		authorizer:         authorizer,
+		auditService:       auditService,
+		transactionService: transactionService,
+		classroomService:   classroomService,
+	}
+}
+
+func (uc WaitlistUseCase) All(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	classroomId int,
+) ([]core.WaitlistEntryResponse, error) {
+	if err := uc.authorizer.Authorize(
+		ctx,
+		metadata,
+		authz.ClassroomStudentsView,
+		authz.Classroom(classroomId),
+	); err != nil {
+		return nil, err
+	}
+
+	waitlist, err := uc.classroomService.Waitlist(ctx, classroomId)
+	if err != nil {
+		return nil, err
+	}
+
+	return waitlistResponse(waitlist), nil
+}
+
+// Reorder changes the order the waitlisted students get seats in. The request has to list every
+// waitlisted student exactly once.
+func (uc WaitlistUseCase) Reorder(
+	ctx context.Context,
+	metadata core.TokenMetadata,
+	classroomId int,
+	req core.ReorderWaitlistRequest,
+) ([]core.WaitlistEntryResponse, error) {
+	if err := uc.authorizer.Authorize(
+		ctx,
+		metadata,
+		authz.ClassroomStudentsManage,
+		authz.Classroom(classroomId),
+	); err != nil {
+		return nil, err
+	}
+
+	var waitlistResp []core.WaitlistEntryResponse
+
+	if err := uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		before, err := uc.classroomService.Waitlist(txCtx, classroomId)
+		if err != nil {
+			return err
+		}
+
+		if err := uc.classroomService.ReorderWaitlist(txCtx, classroomId, req.StudentsId); err != nil {
+			return err
+		}
+
+		after, err := uc.classroomService.Waitlist(txCtx, classroomId)
+		if err != nil {
+			return err
+		}
+
+		waitlistResp = waitlistResponse(after)
+
+		return uc.auditService.Record(
+			txCtx,
+			metadata,
+			auditUpdate(core.AuditWaitlist, classroomId, waitlistOrderAudit(before), waitlistOrderAudit(after)),
+		)
+	}); err != nil {
+		return nil, err
+	}
+
+	return waitlistResp, nil
+}
+
+// Promote gives the waitlisted student a seat in the classroom out of turn. The classroom needs a free
+// seat for it, for example after its number of students was raised.
+func (uc WaitlistUseCase) Promote(ctx context.Context, metadata core.TokenMetadata, classroomId, studentId int) error {
+	if err := uc.authorizer.Authorize(
+		ctx,
+		metadata,
+		authz.ClassroomStudentsManage,
+		authz.Classroom(classroomId),
+	); err != nil {
+		return err
+	}
+
+	return uc.transactionService.WithinTransaction(ctx, func(txCtx context.Context) error {
+		entry, err := uc.classroomService.Promote(txCtx, classroomId, studentId)
+		if err != nil {
+			return err
+		}
+
+		return recordPromotions(txCtx, uc.auditService, metadata, []core.WaitlistEntry{entry})
+	})
+}
+
+// promoteWaitlisted fills the free seats of the classroom from its waitlist and records the promotions.
+// It has to be called within a transaction.
+func promoteWaitlisted(
+	ctx context.Context,
+	auditService AuditService,
+	classroomService PromotionClassroomService,
+	metadata core.TokenMetadata,
+	classroomId int,
+) ([]core.WaitlistEntry, error) {
+	promoted, err := classroomService.PromoteWaitlisted(ctx, classroomId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordPromotions(ctx, auditService, metadata, promoted); err != nil {
+		return nil, err
+	}
+
+	return promoted, nil
+}
+
+func recordPromotions(
+	ctx context.Context,
+	auditService AuditService,
+	metadata core.TokenMetadata,
+	promoted []core.WaitlistEntry,
+) error {
+	for _, entry := range promoted {
+		id := enrollmentAuditId(entry.ClassroomId, entry.StudentId)
+
+		if err := auditService.Record(ctx, metadata, core.AuditEvent{
+			Action:     core.AuditDelete,
+			EntityType: core.AuditWaitlist,
+			EntityId:   id,
+			Before:     waitlistEntryAudit(entry),
+		}); err != nil {
+			return err
+		}
+
+		if err := auditService.Record(ctx, metadata, core.AuditEvent{
+			Action:     core.AuditCreate,
+			EntityType: core.AuditEnrollment,
+			EntityId:   id,
+			After:      enrollmentAudit{ClassroomId: entry.ClassroomId, StudentId: entry.StudentId},
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// notifyPromotions tells the staff of the classroom which students got a seat from its waitlist. It is
+// called once the promotions are committed.
+func notifyPromotions(
+	ctx context.Context,
+	classroomService PromotionClassroomService,
+	mailService WaitlistMailService,
+	classroomId int,
+	promoted []core.WaitlistEntry,
+) error {
+	if len(promoted) == 0 {
+		return nil
+	}
+
+	classroom, err := classroomService.ById(ctx, classroomId)
+	if err != nil {
+		return err
+	}
+
+	staff, err := classroomService.Staff(ctx, classroomId)
+	if err != nil {
+		return err
+	}
+
+	to := make([]string, 0, len(staff))
+
+	for _, member := range staff {
+		to = append(to, member.Email)
+	}
+
+	studentsName := make([]string, 0, len(promoted))
+
+	for _, entry := range promoted {
+		studentsName = append(studentsName, entry.FullName)
+	}
+
+	return mailService.SendWaitlistPromotion(ctx, to, classroom.Title, studentsName)
+}
+
+// releaseSeats gives the seats of a student who was deactivated, deleted or erased to the waitlists of
+// the classrooms of the student. The student stays enrolled, so a reactivation can take the seats back.
+// It has to be called within a transaction, after the student stopped being active.
+func releaseSeats(
+	ctx context.Context,
+	auditService AuditService,
+	classroomService SeatClassroomService,
+	metadata core.TokenMetadata,
+	studentId int,
+) ([]core.WaitlistEntry, error) {
+	classrooms, err := classroomService.StudentClassrooms(ctx, studentId)
+	if err != nil {
+		return nil, err
+	}
+
+	promoted := make([]core.WaitlistEntry, 0)
+
+	for _, classroom := range classrooms {
+		entries, err := promoteWaitlisted(ctx, auditService, classroomService, metadata, classroom.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		promoted = append(promoted, entries...)
+	}
+
+	return promoted, nil
+}
+
+// reclaimSeats gives a reactivated student the seats back. Where the waitlist took the seat meanwhile,
+// the student is put at the end of the waitlist instead. It has to be called within a transaction,
+// after the student was reactivated.
+func reclaimSeats(
+	ctx context.Context,
+	auditService AuditService,
+	classroomService SeatClassroomService,
+	metadata core.TokenMetadata,
+	studentId int,
+) error {
+	classrooms, err := classroomService.StudentClassrooms(ctx, studentId)
+	if err != nil {
+		return err
+	}
+
+	for _, classroom := range classrooms {
+		waitlisted, err := classroomService.Readmit(ctx, classroom.Id, studentId)
+		if err != nil {
+			return err
+		}
+
+		if !waitlisted {
+			continue
+		}
+
+		id := enrollmentAuditId(classroom.Id, studentId)
+		state := enrollmentAudit{ClassroomId: classroom.Id, StudentId: studentId}
+
+		if err := auditService.Record(ctx, metadata, core.AuditEvent{
+			Action:     core.AuditDelete,
+			EntityType: core.AuditEnrollment,
+			EntityId:   id,
+			Before:     state,
+		}); err != nil {
+			return err
+		}
+
+		if err := auditService.Record(ctx, metadata, core.AuditEvent{
+			Action:     core.AuditCreate,
+			EntityType: core.AuditWaitlist,
+			EntityId:   id,
+			After:      state,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// notifyReleasedSeats tells the staff of every classroom which students got the released seats. The
+// promotions are committed already, so a failed email is logged instead of failing the request.
+func notifyReleasedSeats(
+	ctx context.Context,
+	logger logger.Logger,
+	classroomService PromotionClassroomService,
+	mailService WaitlistMailService,
+	promoted []core.WaitlistEntry,
+) {
+	classroomsId := make([]int, 0)
+	byClassroom := make(map[int][]core.WaitlistEntry)
+
+	for _, entry := range promoted {
+		if _, ok := byClassroom[entry.ClassroomId]; !ok {
+			classroomsId = append(classroomsId, entry.ClassroomId)
+		}
+
+		byClassroom[entry.ClassroomId] = append(byClassroom[entry.ClassroomId], entry)
+	}
+
+	for _, classroomId := range classroomsId {
+		if err := notifyPromotions(ctx, classroomService, mailService, classroomId, byClassroom[classroomId]); err != nil {
+			logger.Errorf("Failed to notify the staff of classroom %d about waitlist promotions: %v", classroomId, err)
+		}
+	}
+}
+
+func waitlistResponse(waitlist []core.WaitlistEntry) []core.WaitlistEntryResponse {
+	waitlistResp := make([]core.WaitlistEntryResponse, 0, len(waitlist))
+
+	for _, entry := range waitlist {
+		waitlistResp = append(waitlistResp, waitlistEntryResponse(entry))
+	}
+
+	return waitlistResp
+}
+
+func waitlistEntryAudit(entry core.WaitlistEntry) enrollmentAudit {
+	return enrollmentAudit{ClassroomId: entry.ClassroomId, StudentId: entry.StudentId, Position: entry.Position}
+}
+
+// waitlistOrder is the audited order of a waitlist, the ids of the students from the first one.
+type waitlistOrder struct {
+	StudentsId []int `json:"students_id"`
+}
+
+func waitlistOrderAudit(waitlist []core.WaitlistEntry) waitlistOrder {
+	order := waitlistOrder{StudentsId: make([]int, 0, len(waitlist))}
+
+	for _, entry := range waitlist {
+		order.StudentsId = append(order.StudentsId, entry.StudentId)
+	}
+
+	return order
+}
+
+func waitlistEntryResponse(entry core.WaitlistEntry) core.WaitlistEntryResponse {
+	return core.WaitlistEntryResponse{
+		StudentId: entry.StudentId,
+		FullName:  entry.FullName,
+		Email:     entry.Email,
+		Position:  entry.Position,
+		CreatedAt: entry.CreatedAt,
+	}
+}
@@ -10,18 +10,44 @@ import (
 //	jwt.Parse(token)
 //}
 
-// ExtractTokenMetadata func to extract metadata from JWT.
+// APIKeyContextKey is the key of the metadata of a request authenticated with an API key.
+const APIKeyContextKey = "apiKey"
+
+// ExtractTokenMetadata func to extract metadata from JWT or from the API key the request was
+// authenticated with.
 func ExtractTokenMetadata(c *fiber.Ctx) core.TokenMetadata {
+	if metadata, ok := c.Locals(APIKeyContextKey).(core.TokenMetadata); ok {
+		return metadata
+	}
+
 	jwtCtx := c.Locals("jwt").(*jwt.Token)
 	claims := jwtCtx.Claims.(jwt.MapClaims)
 
+	// Tokens issued before sessions were introduced do not carry a session id.
+	sessionId, _ := claims["sid"].(string)
+
 	return core.TokenMetadata{
-		UserId:  int(claims["user_id"].(float64)),
-		Role:    claims["role"].(string),
-		Expires: int64(claims["exp"].(float64)),
+		UserId:         int(claims["user_id"].(float64)),
+		Role:           claims["role"].(string),
+		SessionId:      sessionId,
+		Expires:        int64(claims["exp"].(float64)),
+		ImpersonatorId: ImpersonatorId(claims),
 	}
 }
 
+// ImpersonatorId returns the admin from the act claim of an impersonation token, or zero for
+// ordinary tokens.
+func ImpersonatorId(claims jwt.MapClaims) int {
+	act, ok := claims["act"].(map[string]interface{})
+	if !ok {
+		return 0
+	}
+
+	adminId, _ := act["user_id"].(float64)
+
+	return int(adminId)
+}
+
 func JwtError(c *fiber.Ctx, err error) error {
 	// Return status 401 and failed authentication error.
 	if err.Error() == "Missing or malformed JWT" {
@@ -0,0 +1,294 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/migmatore/study-platform-api/config"
+	"math/big"
+	"os"
+	"sort"
+)
+
+// Token types. Every token carries its type in the "typ" claim, so a token of one type is never
+// accepted where another is expected even though they are signed with the same keys.
+const (
+	AccessToken  = "access"
+	WSToken      = "ws"
+	RefreshToken = "refresh"
+)
+
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+type key struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// KeySet signs tokens with the active key and verifies them with any of the configured keys, which
+// allows rotating keys without signing everybody out. Tokens without a "kid" header were issued
+// before asymmetric keys were introduced and are verified with the HS256 secret of their type as
+// long as the secret is configured. Without asymmetric keys the set keeps signing with HS256.
+type KeySet struct {
+	signing *key
+	keys    map[string]*key
+	legacy  map[string][]byte
+}
+
+func NewKeySet(cfg *config.Config) (*KeySet, error) {
+	ks := &KeySet{
+		keys:   make(map[string]*key),
+		legacy: make(map[string][]byte),
+	}
+
+	legacy := map[string]string{
+		AccessToken:  cfg.Server.JwtSecretKey,
+		WSToken:      cfg.Server.WSJwtSecretKey,
+		RefreshToken: cfg.Server.JwtRefreshSecretKey,
+	}
+
+	for typ, secret := range legacy {
+		if secret != "" {
+			ks.legacy[typ] = []byte(secret)
+		}
+	}
+
+	for _, keyCfg := range cfg.JWT.Keys {
+		k, err := loadKey(keyCfg)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", keyCfg.Id, err)
+		}
+
+		if _, ok := ks.keys[k.id]; ok {
+			return nil, fmt.Errorf("jwt key %q is configured twice", k.id)
+		}
+
+		ks.keys[k.id] = k
+	}
+
+	if cfg.JWT.SigningKeyId != "" {
+		k, ok := ks.keys[cfg.JWT.SigningKeyId]
+		if !ok {
+			return nil, fmt.Errorf("signing jwt key %q is not configured", cfg.JWT.SigningKeyId)
+		}
+
+		if k.private == nil {
+			return nil, fmt.Errorf("signing jwt key %q has no private key", k.id)
+		}
+
+		ks.signing = k
+	}
+
+	if ks.signing == nil && len(ks.legacy) != len(legacy) {
+		return nil, errors.New("either a signing jwt key or all jwt secrets must be configured")
+	}
+
+	return ks, nil
+}
+
+// Sign returns a signed token of the given type.
+func (ks *KeySet) Sign(typ string, claims jwt.MapClaims) (string, error) {
+	claims["typ"] = typ
+
+	if ks.signing == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ks.legacy[typ])
+	}
+
+	token := jwt.NewWithClaims(ks.signing.method, claims)
+	token.Header["kid"] = ks.signing.id
+
+	return token.SignedString(ks.signing.private)
+}
+
+// Keyfunc returns a jwt.Keyfunc which accepts only tokens of the given type.
+func (ks *KeySet) Keyfunc(typ string) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok {
+			return nil, jwt.ErrTokenInvalidClaims
+		}
+
+		kid, ok := token.Header["kid"].(string)
+		if !ok {
+			secret, ok := ks.legacy[typ]
+			if !ok || token.Method != jwt.SigningMethodHS256 {
+				return nil, jwt.ErrTokenUnverifiable
+			}
+
+			// Legacy tokens did not carry a type, they were told apart by the secret alone.
+			if tokenTyp, ok := claims["typ"]; ok && tokenTyp != typ {
+				return nil, jwt.ErrTokenInvalidClaims
+			}
+
+			return secret, nil
+		}
+
+		if claims["typ"] != typ {
+			return nil, jwt.ErrTokenInvalidClaims
+		}
+
+		k, ok := ks.keys[kid]
+		if !ok {
+			return nil, jwt.ErrTokenUnverifiable
+		}
+
+		if token.Method.Alg() != k.method.Alg() {
+			return nil, jwt.ErrTokenSignatureInvalid
+		}
+
+		return k.public, nil
+	}
+}
+
+// JWK is a public key in the JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys which tokens are verified with.
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(ks.keys))}
+
+	for _, k := range ks.keys {
+		jwk := JWK{
+			Kid: k.id,
+			Use: "sig",
+			Alg: k.method.Alg(),
+		}
+
+		switch pub := k.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+
+	return set
+}
+
+func loadKey(cfg config.JWTKeyConfig) (*key, error) {
+	if cfg.Id == "" {
+		return nil, errors.New("key id is empty")
+	}
+
+	k := &key{id: cfg.Id}
+
+	switch cfg.Algorithm {
+	case AlgRS256:
+		k.method = jwt.SigningMethodRS256
+	case AlgEdDSA:
+		k.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", cfg.Algorithm)
+	}
+
+	switch {
+	case cfg.PrivateKeyFile != "":
+		block, err := readPEM(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		private, err := parsePrivateKey(block)
+		if err != nil {
+			return nil, err
+		}
+
+		k.private = private
+		k.public = private.Public()
+	case cfg.PublicKeyFile != "":
+		block, err := readPEM(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		k.public = public
+	default:
+		return nil, errors.New("neither private nor public key file is set")
+	}
+
+	switch k.public.(type) {
+	case *rsa.PublicKey:
+		if k.method != jwt.SigningMethodRS256 {
+			return nil, errors.New("rsa key can be used only with RS256")
+		}
+	case ed25519.PublicKey:
+		if k.method != jwt.SigningMethodEdDSA {
+			return nil, errors.New("ed25519 key can be used only with EdDSA")
+		}
+	default:
+		return nil, errors.New("unsupported key type")
+	}
+
+	return k, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", path)
+	}
+
+	return block, nil
+}
+
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key type")
+	}
+
+	return signer, nil
+}
@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"github.com/migmatore/study-platform-api/config"
+	"github.com/migmatore/study-platform-api/pkg/logger"
+)
+
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New creates a mailer for the configured driver. The file and log drivers are meant
+// for local development, where no real mail server is available.
+func New(cfg config.MailConfig, logger logger.Logger) (Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return NewSMTPMailer(cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.From), nil
+	case "file":
+		return NewFileMailer(cfg.Dir, cfg.From)
+	case "log", "":
+		return NewLogMailer(logger, cfg.From), nil
+	}
+
+	return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+}
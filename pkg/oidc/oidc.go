@@ -0,0 +1,296 @@
+// Package oidc implements the relying party side of the OpenID Connect authorization code flow
+// with PKCE: provider discovery, code exchange and ID token verification.
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const cacheTTL = time.Hour
+
+var (
+	ErrInvalidIDToken = errors.New("invalid id token")
+	ErrExchange       = errors.New("code exchange failed")
+)
+
+// Provider is the subset of the discovery document the flow needs.
+type Provider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	TokenType   string `json:"token_type"`
+}
+
+type cachedProvider struct {
+	provider  Provider
+	fetchedAt time.Time
+}
+
+type cachedKeys struct {
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+// Client talks to identity providers. Discovery documents and signing keys are cached, keys are
+// refetched when a token is signed with an unknown one.
+type Client struct {
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	providers map[string]cachedProvider
+	keys      map[string]cachedKeys
+}
+
+func NewClient(timeout time.Duration) *Client {
+	return &Client{
+		httpClient: &http.Client{Timeout: timeout},
+		providers:  make(map[string]cachedProvider),
+		keys:       make(map[string]cachedKeys),
+	}
+}
+
+// Discover fetches the discovery document of the issuer.
+func (c *Client) Discover(ctx context.Context, issuer string) (Provider, error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+
+	c.mu.Lock()
+	cached, ok := c.providers[issuer]
+	c.mu.Unlock()
+
+	if ok && time.Since(cached.fetchedAt) < cacheTTL {
+		return cached.provider, nil
+	}
+
+	var p Provider
+
+	if err := c.getJSON(ctx, issuer+"/.well-known/openid-configuration", &p); err != nil {
+		return Provider{}, err
+	}
+
+	if strings.TrimSuffix(p.Issuer, "/") != issuer {
+		return Provider{}, fmt.Errorf("discovery document issuer %q does not match %q", p.Issuer, issuer)
+	}
+
+	if p.AuthorizationEndpoint == "" || p.TokenEndpoint == "" || p.JWKSURI == "" {
+		return Provider{}, errors.New("discovery document is incomplete")
+	}
+
+	c.mu.Lock()
+	c.providers[issuer] = cachedProvider{provider: p, fetchedAt: time.Now()}
+	c.mu.Unlock()
+
+	return p, nil
+}
+
+// AuthCodeURL returns the address the user is sent to for signing in at the provider.
+func AuthCodeURL(
+	p Provider,
+	clientId string,
+	redirectURI string,
+	scopes []string,
+	state string,
+	nonce string,
+	codeVerifier string,
+) string {
+	v := url.Values{}
+	v.Set("response_type", "code")
+	v.Set("client_id", clientId)
+	v.Set("redirect_uri", redirectURI)
+	v.Set("scope", strings.Join(scopes, " "))
+	v.Set("state", state)
+	v.Set("nonce", nonce)
+	v.Set("code_challenge", CodeChallenge(codeVerifier))
+	v.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(p.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+
+	return p.AuthorizationEndpoint + sep + v.Encode()
+}
+
+// CodeChallenge returns the S256 PKCE challenge of the verifier.
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Exchange trades the authorization code for tokens.
+func (c *Client) Exchange(
+	ctx context.Context,
+	p Provider,
+	clientId string,
+	clientSecret string,
+	code string,
+	redirectURI string,
+	codeVerifier string,
+) (TokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(clientId), url.QueryEscape(clientSecret))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return TokenResponse{}, fmt.Errorf("%w: status %d: %s", ErrExchange, resp.StatusCode, body)
+	}
+
+	var tokens TokenResponse
+
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return TokenResponse{}, err
+	}
+
+	if tokens.IDToken == "" {
+		return TokenResponse{}, fmt.Errorf("%w: no id token in the response", ErrExchange)
+	}
+
+	return tokens, nil
+}
+
+// VerifyIDToken checks the signature, issuer, audience, expiration and nonce of the ID token and
+// returns its claims.
+func (c *Client) VerifyIDToken(
+	ctx context.Context,
+	p Provider,
+	clientId string,
+	rawIDToken string,
+	nonce string,
+) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(
+		rawIDToken,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+
+			return c.key(ctx, p.JWKSURI, kid)
+		},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "EdDSA"}),
+		jwt.WithIssuer(p.Issuer),
+		jwt.WithAudience(clientId),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidIDToken
+	}
+
+	if claims["nonce"] != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return nil, fmt.Errorf("%w: no subject", ErrInvalidIDToken)
+	}
+
+	return claims, nil
+}
+
+// key returns the verification key with the given id, refetching the key set when it is unknown.
+func (c *Client) key(ctx context.Context, jwksURI string, kid string) (interface{}, error) {
+	c.mu.Lock()
+	cached, ok := c.keys[jwksURI]
+	c.mu.Unlock()
+
+	if !ok || time.Since(cached.fetchedAt) >= cacheTTL || lookup(cached.keys, kid) == nil {
+		var set jwks
+
+		if err := c.getJSON(ctx, jwksURI, &set); err != nil {
+			return nil, err
+		}
+
+		keys, err := set.parse()
+		if err != nil {
+			return nil, err
+		}
+
+		cached = cachedKeys{keys: keys, fetchedAt: time.Now()}
+
+		c.mu.Lock()
+		c.keys[jwksURI] = cached
+		c.mu.Unlock()
+	}
+
+	key := lookup(cached.keys, kid)
+	if key == nil {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	return key, nil
+}
+
+// lookup finds the key by id. Tokens without a key id are accepted when the set has a single key.
+func lookup(keys map[string]interface{}, kid string) interface{} {
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key
+		}
+	}
+
+	return keys[kid]
+}
+
+func (c *Client) getJSON(ctx context.Context, u string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", u, resp.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
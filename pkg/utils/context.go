@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"context"
+	"github.com/migmatore/study-platform-api/internal/core"
+)
+
+type requestInfoKey struct{}
+
+// WithRequestInfo returns a copy of the context carrying the request info.
+func WithRequestInfo(ctx context.Context, info core.RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// RequestInfoFromContext returns the request info of the context, if there is any.
+func RequestInfoFromContext(ctx context.Context) core.RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(core.RequestInfo)
+
+	return info
+}
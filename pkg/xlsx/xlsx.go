@@ -0,0 +1,295 @@
+// Package xlsx reads cell values from Office Open XML workbooks. Only the stored values are read:
+// formatting is ignored, formulas give their cached results and dates stay serial numbers.
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxPartSize limits how much of a single part of the workbook is unpacked, so a small archive
+// cannot expand into gigabytes.
+const maxPartSize = 32 << 20
+
+// maxExcelRows is the number of rows Excel supports.
+const maxExcelRows = 1 << 20
+
+var (
+	ErrInvalidFile = errors.New("xlsx: invalid file")
+	ErrTooLarge    = errors.New("xlsx: the worksheet is too large")
+)
+
+type workbook struct {
+	Sheets []struct {
+		RelId string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type relationships struct {
+	Items []struct {
+		Id     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// richText is a string which is either plain or made of formatted runs.
+type richText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t richText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+
+	var b strings.Builder
+
+	for _, run := range t.Runs {
+		b.WriteString(run.T)
+	}
+
+	return b.String()
+}
+
+type sharedStrings struct {
+	Items []richText `xml:"si"`
+}
+
+type worksheet struct {
+	Rows []struct {
+		Ref   int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline richText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadRows returns the rows of the first worksheet of the workbook. Missing rows are empty, a row ends
+// with its last stored cell and missing cells before it are empty. Worksheets with more than maxRows
+// rows or maxColumns columns fail with ErrTooLarge before the rows are allocated.
+func ReadRows(r io.ReaderAt, size int64, maxRows int, maxColumns int) ([][]string, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var strs sharedStrings
+
+	// Workbooks without text cells have no shared strings part.
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodePart(f, &strs); err != nil {
+			return nil, err
+		}
+	}
+
+	f, ok := files[sheetPath]
+	if !ok {
+		return nil, fmt.Errorf("%w: no worksheet %s", ErrInvalidFile, sheetPath)
+	}
+
+	var sheet worksheet
+
+	if err := decodePart(f, &sheet); err != nil {
+		return nil, err
+	}
+
+	if err := checkSize(sheet, maxRows, maxColumns); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+
+	for _, sheetRow := range sheet.Rows {
+
+		// Rows are numbered from one, empty rows are usually not stored at all.
+		for sheetRow.Ref > len(rows)+1 {
+			rows = append(rows, nil)
+		}
+
+		row := make([]string, 0, len(sheetRow.Cells))
+
+		for _, cell := range sheetRow.Cells {
+			col := len(row)
+
+			if cell.Ref != "" {
+				if col, err = column(cell.Ref); err != nil {
+					return nil, err
+				}
+			}
+
+			for len(row) < col {
+				row = append(row, "")
+			}
+
+			value := cell.Value
+
+			switch cell.Type {
+			case "s":
+				i, err := strconv.Atoi(value)
+				if err != nil || i < 0 || i >= len(strs.Items) {
+					return nil, fmt.Errorf("%w: bad shared string in %s", ErrInvalidFile, cell.Ref)
+				}
+
+				value = strs.Items[i].String()
+			case "inlineStr":
+				value = cell.Inline.String()
+			}
+
+			if col < len(row) {
+				row[col] = value
+			} else {
+				row = append(row, value)
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// checkSize makes sure the rows and the cells of the worksheet are within the limits, so that a
+// crafted row or cell number cannot make ReadRows allocate the whole Excel grid.
+func checkSize(sheet worksheet, maxRows int, maxColumns int) error {
+	rowCount := 0
+
+	for _, sheetRow := range sheet.Rows {
+		if sheetRow.Ref > maxExcelRows {
+			return fmt.Errorf("%w: bad row number %d", ErrInvalidFile, sheetRow.Ref)
+		}
+
+		// Rows without a number follow the previous one.
+		if sheetRow.Ref > rowCount {
+			rowCount = sheetRow.Ref
+		} else {
+			rowCount++
+		}
+
+		if rowCount > maxRows {
+			return fmt.Errorf("%w: more than %d rows", ErrTooLarge, maxRows)
+		}
+
+		columnCount := 0
+
+		for _, cell := range sheetRow.Cells {
+			col := columnCount
+
+			if cell.Ref != "" {
+				var err error
+
+				if col, err = column(cell.Ref); err != nil {
+					return err
+				}
+			}
+
+			if col >= columnCount {
+				columnCount = col + 1
+			}
+
+			if columnCount > maxColumns {
+				return fmt.Errorf("%w: more than %d columns", ErrTooLarge, maxColumns)
+			}
+		}
+	}
+
+	return nil
+}
+
+// firstSheetPath finds the part of the first worksheet through the relationships of the workbook.
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	f, ok := files["xl/workbook.xml"]
+	if !ok {
+		return "", fmt.Errorf("%w: no workbook", ErrInvalidFile)
+	}
+
+	var book workbook
+
+	if err := decodePart(f, &book); err != nil {
+		return "", err
+	}
+
+	if len(book.Sheets) == 0 {
+		return "", fmt.Errorf("%w: no worksheets", ErrInvalidFile)
+	}
+
+	f, ok = files["xl/_rels/workbook.xml.rels"]
+	if !ok {
+		return "", fmt.Errorf("%w: no workbook relationships", ErrInvalidFile)
+	}
+
+	var rels relationships
+
+	if err := decodePart(f, &rels); err != nil {
+		return "", err
+	}
+
+	for _, rel := range rels.Items {
+		if rel.Id != book.Sheets[0].RelId {
+			continue
+		}
+
+		// The target is either absolute within the archive or relative to the workbook.
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(path.Clean(rel.Target), "/"), nil
+		}
+
+		return path.Join("xl", rel.Target), nil
+	}
+
+	return "", fmt.Errorf("%w: no relationship for the first worksheet", ErrInvalidFile)
+}
+
+func decodePart(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	defer rc.Close()
+
+	if err := xml.NewDecoder(io.LimitReader(rc, maxPartSize)).Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidFile, f.Name, err)
+	}
+
+	return nil
+}
+
+// column returns the zero-based column of the cell reference, for example 27 for "AB3".
+func column(ref string) (int, error) {
+	col := 0
+	i := 0
+
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		col = col*26 + int(ref[i]-'A'+1)
+	}
+
+	// Excel has 16384 columns, XFD is the last one.
+	if i == 0 || i > 3 || col > 16384 {
+		return 0, fmt.Errorf("%w: bad cell reference %q", ErrInvalidFile, ref)
+	}
+
+	return col - 1, nil
+}